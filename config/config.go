@@ -0,0 +1,243 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/muhammadisa/bareksanews/constant"
+	pb "github.com/muhammadisa/bareksanews/protoc/api/v1"
+	"github.com/muhammadisa/bareksanews/repository"
+	"github.com/muhammadisa/bareksanews/repository/cache"
+	"github.com/muhammadisa/bareksanews/util/auth"
+	"github.com/muhammadisa/bareksanews/util/crt"
+	"github.com/muhammadisa/bareksanews/util/dbc"
+	"github.com/muhammadisa/bareksanews/util/lgr"
+	"github.com/muhammadisa/bareksanews/util/lmt"
+)
+
+// Config everything the service is started with, see Load for where every
+// setting comes from
+type Config struct {
+	Listen         ListenConfig         `yaml:"listen"`
+	Repository     repository.RepoConf  `yaml:"repository"`
+	Tracing        TracingConfig        `yaml:"tracing"`
+	CircuitBreaker CircuitBreakerConfig `yaml:"circuit_breaker"`
+	Log            LogConfig            `yaml:"log"`
+	// Auth verify the bearer tokens, the service refuses to start without
+	// keys unless auth is disabled
+	Auth auth.Config `yaml:"auth"`
+	// RateLimit limit the calls of every client, every rpc is unlimited
+	// while it has no rate
+	RateLimit lmt.Config `yaml:"rate_limit"`
+	// WarmUpCache preload the cache from the database at startup
+	WarmUpCache bool `yaml:"warm_up_cache"`
+}
+
+type ListenConfig struct {
+	// Addr host:port shared by grpc and the rest gateway
+	Addr string `yaml:"addr"`
+	// ShutdownTimeout longest wait for the requests in flight once SIGINT
+	// or SIGTERM is received
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout"`
+	// ShutdownDelay time the service is reported unready before it stops
+	// accepting connections, for the load balancers to notice
+	ShutdownDelay time.Duration `yaml:"shutdown_delay"`
+	// TLS serve grpc and rest over tls once a certificate is given
+	TLS crt.Config `yaml:"tls"`
+}
+
+type TracingConfig struct {
+	// ZipkinURL spans are reported to, empty keeps the spans in process
+	ZipkinURL string `yaml:"zipkin_url"`
+	// SampleRate fraction of the requests traced, from 0 to 1
+	SampleRate float64 `yaml:"sample_rate"`
+}
+
+type CircuitBreakerConfig struct {
+	// Timeout of every endpoint call, in whole seconds
+	Timeout time.Duration `yaml:"timeout"`
+}
+
+type LogConfig struct {
+	// Level debug, info, warn or error
+	Level string `yaml:"level"`
+}
+
+// Defaults the settings of a local run, every service on localhost
+func Defaults() Config {
+	return Config{
+		Listen: ListenConfig{
+			Addr:            ":8010",
+			ShutdownTimeout: constant.ShutdownTimeout * time.Second,
+			ShutdownDelay:   constant.ShutdownDelaySeconds * time.Second,
+			TLS:             crt.Config{ReloadInterval: constant.TLSReloadSeconds * time.Second},
+		},
+		Repository: repository.RepoConf{
+			Driver: constant.DriverMySQL,
+			SQL: dbc.Config{
+				Username:        "root",
+				Password:        "root",
+				Host:            "localhost",
+				Port:            "3306",
+				Name:            "bareksa_news",
+				MaxOpenConns:    25,
+				MaxIdleConns:    25,
+				ConnMaxLifetime: 5 * time.Minute,
+				ConnMaxIdleTime: time.Minute,
+				DialTimeout:     5 * time.Second,
+				ReadTimeout:     30 * time.Second,
+				WriteTimeout:    30 * time.Second,
+				ConnectRetries:  5,
+				RetryBackoff:    time.Second,
+			},
+			CacheDriver: constant.CacheDriverRedis,
+			Cache: dbc.RedisConfig{
+				Mode: constant.RedisStandalone,
+				Config: dbc.Config{
+					Password:       "root",
+					Host:           "localhost",
+					Port:           "6379",
+					MaxOpenConns:   20,
+					DialTimeout:    5 * time.Second,
+					ReadTimeout:    3 * time.Second,
+					WriteTimeout:   3 * time.Second,
+					ConnectRetries: 5,
+					RetryBackoff:   time.Second,
+				},
+			},
+			CacheOptions: cache.Options{
+				Namespace: cache.Namespace{
+					Service:     constant.ServiceName,
+					Environment: "local",
+				},
+				Local: cache.LocalConfig{
+					Size: constant.LocalCacheSize,
+					TTL:  constant.LocalCacheSeconds * time.Second,
+				},
+				CompressAbove: constant.CacheCompressBytes,
+			},
+		},
+		Tracing: TracingConfig{
+			ZipkinURL:  "http://localhost:9411/api/v2/spans",
+			SampleRate: 1,
+		},
+		CircuitBreaker: CircuitBreakerConfig{Timeout: constant.CircuitBreakerTimeout * time.Second},
+		Log:            LogConfig{Level: "info"},
+		Auth:           auth.Config{RolesClaim: constant.RolesClaim},
+		RateLimit:      lmt.Config{Store: constant.RateLimitStoreMemory},
+		WarmUpCache:    constant.WarmUpCache,
+	}
+}
+
+// Validate report every setting the service can not start with at once
+func (c Config) Validate() error {
+	var problems []string
+	check := func(err error) {
+		if err != nil {
+			problems = append(problems, err.Error())
+		}
+	}
+
+	if _, _, err := net.SplitHostPort(c.Listen.Addr); err != nil {
+		check(fmt.Errorf("listen.addr: %v", err))
+	}
+	if c.Listen.ShutdownTimeout <= 0 {
+		check(fmt.Errorf("listen.shutdown_timeout: %s is not positive", c.Listen.ShutdownTimeout))
+	}
+	if c.Listen.ShutdownDelay < 0 {
+		check(fmt.Errorf("listen.shutdown_delay: %s is negative", c.Listen.ShutdownDelay))
+	}
+	if err := c.Listen.TLS.Validate(); err != nil {
+		check(fmt.Errorf("listen.tls: %v", err))
+	}
+	check(validateRepository(c.Repository))
+	if c.Tracing.ZipkinURL != "" {
+		if u, err := url.Parse(c.Tracing.ZipkinURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			check(fmt.Errorf("tracing.zipkin_url: %q is not an http url", c.Tracing.ZipkinURL))
+		}
+	}
+	if c.Tracing.SampleRate < 0 || c.Tracing.SampleRate > 1 {
+		check(fmt.Errorf("tracing.sample_rate: %v is not between 0 and 1", c.Tracing.SampleRate))
+	}
+	if c.CircuitBreaker.Timeout <= 0 || c.CircuitBreaker.Timeout%time.Second != 0 {
+		check(fmt.Errorf("circuit_breaker.timeout: %s is not a positive number of whole seconds", c.CircuitBreaker.Timeout))
+	}
+	if _, err := lgr.Filter(log.NewNopLogger(), c.Log.Level); err != nil {
+		check(fmt.Errorf("log.level: %v", err))
+	}
+	if err := c.Auth.Validate(rpcs()); err != nil {
+		check(fmt.Errorf("auth: %v", err))
+	}
+	if err := c.RateLimit.Validate(rpcs()); err != nil {
+		check(fmt.Errorf("rate_limit: %v", err))
+	}
+	if c.RateLimit.Store == constant.RateLimitStoreRedis && c.Repository.CacheDriver == constant.CacheDriverMemory {
+		check(errors.New("rate_limit.store: redis shares the connection of repository.cache, the memory cache driver has none"))
+	}
+
+	if len(problems) > 0 {
+		return errors.New("invalid configuration: " + strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// rpcs the names of every rpc of the service
+func rpcs() []string {
+	var names []string
+	for _, method := range pb.BareksaNewsService_ServiceDesc.Methods {
+		names = append(names, method.MethodName)
+	}
+	for _, stream := range pb.BareksaNewsService_ServiceDesc.Streams {
+		names = append(names, stream.StreamName)
+	}
+	return names
+}
+
+func validateRepository(rc repository.RepoConf) error {
+	var problems []string
+	switch rc.Driver {
+	case "", constant.DriverMySQL:
+		if rc.SQL.Host == "" || rc.SQL.Name == "" {
+			problems = append(problems, "repository.sql: host and name are required")
+		}
+	case constant.DriverSQLite:
+		if rc.SQL.Name == "" {
+			problems = append(problems, "repository.sql.name: the database file is required")
+		}
+	case constant.DriverMongoDB:
+		if rc.NoSQL.Host == "" || rc.NoSQL.Name == "" {
+			problems = append(problems, "repository.nosql: host and name are required")
+		}
+	default:
+		problems = append(problems, fmt.Sprintf("repository.driver: unknown driver %q", rc.Driver))
+	}
+
+	switch rc.CacheDriver {
+	case "", constant.CacheDriverRedis:
+		switch rc.Cache.Mode {
+		case "", constant.RedisStandalone, constant.RedisCluster:
+		case constant.RedisSentinel:
+			if rc.Cache.MasterName == "" {
+				problems = append(problems, "repository.cache.master_name: required in sentinel mode")
+			}
+		default:
+			problems = append(problems, fmt.Sprintf("repository.cache.mode: unknown mode %q", rc.Cache.Mode))
+		}
+		if err := rc.CacheOptions.Namespace.Validate(); err != nil {
+			problems = append(problems, "repository.cache_options.namespace: "+err.Error())
+		}
+	case constant.CacheDriverMemory:
+	default:
+		problems = append(problems, fmt.Sprintf("repository.cache_driver: unknown driver %q", rc.CacheDriver))
+	}
+
+	if len(problems) > 0 {
+		return errors.New(strings.Join(problems, "; "))
+	}
+	return nil
+}
@@ -0,0 +1,301 @@
+package config
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/muhammadisa/bareksanews/constant"
+	"github.com/muhammadisa/bareksanews/util/crt"
+	"github.com/muhammadisa/bareksanews/util/lmt"
+	"github.com/stretchr/testify/suite"
+)
+
+type configTestSuite struct {
+	suite.Suite
+}
+
+func TestConfigTestSuite(t *testing.T) {
+	suite.Run(t, new(configTestSuite))
+}
+
+// env look keys up in values like os.LookupEnv, auth is disabled unless
+// values say otherwise since the defaults have no keys
+func env(values map[string]string) func(key string) (string, bool) {
+	return func(key string) (string, bool) {
+		value, ok := values[key]
+		if !ok && key == envPrefix+"AUTH_DISABLED" {
+			return "true", true
+		}
+		return value, ok
+	}
+}
+
+func (ts *configTestSuite) writeFile(content string) string {
+	path := filepath.Join(ts.T().TempDir(), "config.yaml")
+	ts.Require().NoError(ioutil.WriteFile(path, []byte(content), 0600))
+	return path
+}
+
+func (ts *configTestSuite) TestDefaults() {
+	// the defaults have no auth keys, the service refuses to start open
+	_, err := Load(nil, func(string) (string, bool) { return "", false })
+	ts.Require().Error(err)
+	ts.Assert().Contains(err.Error(), "auth")
+
+	c, err := Load(nil, env(nil))
+	ts.Require().NoError(err)
+	want := Defaults()
+	want.Auth.Disabled = true
+	ts.Assert().Equal(want, c)
+	ts.Assert().Equal(":8010", c.Listen.Addr)
+	ts.Assert().Equal(constant.CircuitBreakerTimeout*time.Second, c.CircuitBreaker.Timeout)
+}
+
+func (ts *configTestSuite) TestLayers() {
+	path := ts.writeFile(`
+listen:
+  addr: ":9000"
+repository:
+  sql:
+    host: mysql.internal
+    username: news
+    conn_max_lifetime: 10m
+  cache:
+    mode: cluster
+    addrs: [node-1:6379, node-2:6379]
+  replicas:
+    - host: replica-1
+      port: "3306"
+log:
+  level: warn
+`)
+
+	c, err := Load([]string{"-config", path, "-log.level", "debug", "-repository.cache.addrs", "node-3:6379"}, env(map[string]string{
+		"BAREKSA_NEWS_LISTEN_ADDR":             ":9100",
+		"BAREKSA_NEWS_LOG_LEVEL":               "error",
+		"BAREKSA_NEWS_REPOSITORY_SQL_PORT":     "3307",
+		"BAREKSA_NEWS_TRACING_SAMPLE_RATE":     "0.25",
+		"BAREKSA_NEWS_CIRCUIT_BREAKER_TIMEOUT": "3s",
+	}))
+	ts.Require().NoError(err)
+
+	// the file overrides the defaults
+	ts.Assert().Equal("mysql.internal", c.Repository.SQL.Host)
+	ts.Assert().Equal("news", c.Repository.SQL.Username)
+	ts.Assert().Equal(10*time.Minute, c.Repository.SQL.ConnMaxLifetime)
+	ts.Assert().Equal(constant.RedisCluster, c.Repository.Cache.Mode)
+	ts.Require().Len(c.Repository.Replicas, 1)
+	ts.Assert().Equal("replica-1", c.Repository.Replicas[0].Host)
+	// settings the file leaves out keep their defaults
+	ts.Assert().Equal("bareksa_news", c.Repository.SQL.Name)
+	ts.Assert().Equal(25, c.Repository.SQL.MaxOpenConns)
+
+	// the environment overrides the file
+	ts.Assert().Equal(":9100", c.Listen.Addr)
+	ts.Assert().Equal("3307", c.Repository.SQL.Port)
+	ts.Assert().Equal(0.25, c.Tracing.SampleRate)
+	ts.Assert().Equal(3*time.Second, c.CircuitBreaker.Timeout)
+
+	// the flags override everything
+	ts.Assert().Equal("debug", c.Log.Level)
+	ts.Assert().Equal([]string{"node-3:6379"}, c.Repository.Cache.Addrs)
+}
+
+func (ts *configTestSuite) TestConfigFileFromEnv() {
+	path := ts.writeFile("listen:\n  addr: \":9200\"\n")
+	c, err := Load(nil, env(map[string]string{"BAREKSA_NEWS_CONFIG": path}))
+	ts.Require().NoError(err)
+	ts.Assert().Equal(":9200", c.Listen.Addr)
+}
+
+func (ts *configTestSuite) TestMapsFromFile() {
+	path := ts.writeFile("auth:\n  permissions:\n    GetNewses: reader\n    ExportNews: editor\n")
+	c, err := Load([]string{"-config", path}, env(nil))
+	ts.Require().NoError(err)
+	ts.Assert().Equal(map[string]string{"GetNewses": constant.RoleReader, "ExportNews": constant.RoleEditor}, c.Auth.Permissions)
+
+	path = ts.writeFile("rate_limit:\n  default:\n    rate: 50\n  limits:\n    GetNewses:\n      rate: 5\n      burst: 10\n")
+	c, err = Load([]string{"-config", path, "-rate_limit.default.burst", "100"}, env(nil))
+	ts.Require().NoError(err)
+	ts.Assert().Equal(lmt.Limit{Rate: 50, Burst: 100}, c.RateLimit.Default)
+	ts.Assert().Equal(map[string]lmt.Limit{"GetNewses": {Rate: 5, Burst: 10}}, c.RateLimit.Limits)
+
+	// maps are only read from the file
+	_, err = Load([]string{"-auth.permissions", "GetNewses=reader"}, env(nil))
+	ts.Assert().Error(err)
+}
+
+func (ts *configTestSuite) TestInvalidSources() {
+	tests := []struct {
+		Name string
+		Args []string
+		Env  map[string]string
+		File string
+	}{
+		{
+			Name: "unknown key in the file",
+			File: "repository:\n  sql:\n    hots: mysql.internal\n",
+		},
+		{
+			Name: "unparsable environment variable",
+			Env:  map[string]string{"BAREKSA_NEWS_REPOSITORY_SQL_MAX_OPEN_CONNS": "many"},
+		},
+		{
+			Name: "unparsable flag",
+			Args: []string{"-repository.sql.conn_max_lifetime", "forever"},
+		},
+		{
+			Name: "unknown flag",
+			Args: []string{"-listen.port", "8010"},
+		},
+		{
+			Name: "missing file",
+			Args: []string{"-config", "missing.yaml"},
+		},
+	}
+
+	for _, test := range tests {
+		ts.Run(test.Name, func() {
+			args := test.Args
+			if test.File != "" {
+				args = append(args, "-config", ts.writeFile(test.File))
+			}
+			_, err := Load(args, env(test.Env))
+			ts.Assert().Error(err)
+		})
+	}
+}
+
+func (ts *configTestSuite) TestValidate() {
+	tests := []struct {
+		Name   string
+		Change func(c *Config)
+		Want   string
+	}{
+		{
+			Name:   "listen address without port",
+			Change: func(c *Config) { c.Listen.Addr = "localhost" },
+			Want:   "listen.addr",
+		},
+		{
+			Name:   "tls key without certificate",
+			Change: func(c *Config) { c.Listen.TLS.KeyFile = "server.key" },
+			Want:   "listen.tls",
+		},
+		{
+			Name: "unknown client auth",
+			Change: func(c *Config) {
+				c.Listen.TLS = crt.Config{CertFile: "server.crt", KeyFile: "server.key", ClientAuth: "optional", ReloadInterval: time.Second}
+			},
+			Want: "listen.tls",
+		},
+		{
+			Name:   "unknown driver",
+			Change: func(c *Config) { c.Repository.Driver = "postgres" },
+			Want:   "repository.driver",
+		},
+		{
+			Name:   "sentinel without master name",
+			Change: func(c *Config) { c.Repository.Cache.Mode = constant.RedisSentinel },
+			Want:   "repository.cache.master_name",
+		},
+		{
+			Name:   "namespace without environment",
+			Change: func(c *Config) { c.Repository.CacheOptions.Namespace.Environment = "" },
+			Want:   "repository.cache_options.namespace",
+		},
+		{
+			Name:   "zipkin url without scheme",
+			Change: func(c *Config) { c.Tracing.ZipkinURL = "localhost:9411" },
+			Want:   "tracing.zipkin_url",
+		},
+		{
+			Name:   "sample rate above one",
+			Change: func(c *Config) { c.Tracing.SampleRate = 2 },
+			Want:   "tracing.sample_rate",
+		},
+		{
+			Name:   "fractional circuit breaker timeout",
+			Change: func(c *Config) { c.CircuitBreaker.Timeout = 1500 * time.Millisecond },
+			Want:   "circuit_breaker.timeout",
+		},
+		{
+			Name:   "permission of an unknown rpc",
+			Change: func(c *Config) { c.Auth.Permissions = map[string]string{"GetNews": constant.RoleReader} },
+			Want:   "auth",
+		},
+		{
+			Name:   "limit of an unknown rpc",
+			Change: func(c *Config) { c.RateLimit.Limits = map[string]lmt.Limit{"GetNews": {Rate: 10}} },
+			Want:   "rate_limit",
+		},
+		{
+			Name: "redis rate limit without redis",
+			Change: func(c *Config) {
+				c.Repository.CacheDriver = constant.CacheDriverMemory
+				c.RateLimit.Store = constant.RateLimitStoreRedis
+			},
+			Want: "rate_limit.store",
+		},
+		{
+			Name:   "unknown log level",
+			Change: func(c *Config) { c.Log.Level = "verbose" },
+			Want:   "log.level",
+		},
+	}
+
+	for _, test := range tests {
+		ts.Run(test.Name, func() {
+			c := Defaults()
+			c.Auth.Disabled = true
+			test.Change(&c)
+			err := c.Validate()
+			ts.Require().Error(err)
+			ts.Assert().Contains(err.Error(), test.Want)
+		})
+	}
+
+	// the memory cache needs no namespace
+	c := Defaults()
+	c.Auth.Disabled = true
+	c.Repository.CacheDriver = constant.CacheDriverMemory
+	c.Repository.CacheOptions.Namespace.Environment = ""
+	ts.Assert().NoError(c.Validate())
+}
+
+func (ts *configTestSuite) TestStringRedactsSecrets() {
+	path := ts.writeFile(`
+repository:
+  replicas:
+    - host: replica-1
+      password: replica-secret
+`)
+	c, err := Load([]string{"-config", path}, env(map[string]string{
+		"BAREKSA_NEWS_REPOSITORY_SQL_PASSWORD":            "sql-secret",
+		"BAREKSA_NEWS_REPOSITORY_CACHE_SENTINEL_PASSWORD": "sentinel-secret",
+	}))
+	ts.Require().NoError(err)
+
+	printed := c.String()
+	for _, secret := range []string{"sql-secret", "replica-secret", "sentinel-secret", "password: root"} {
+		ts.Assert().False(strings.Contains(printed, secret), secret)
+	}
+	ts.Assert().Contains(printed, "password: "+redacted)
+	ts.Assert().Contains(printed, "host: replica-1")
+
+	// printing leaves the configuration alone
+	ts.Assert().Equal("sql-secret", c.Repository.SQL.Password)
+	ts.Assert().Equal("replica-secret", c.Repository.Replicas[0].Password)
+}
+
+func (ts *configTestSuite) TestSecretsHaveNoFlag() {
+	_, err := Load([]string{"-repository.sql.password", "sql-secret"}, env(nil))
+	ts.Assert().Error(err)
+
+	c, err := Load(nil, env(map[string]string{"BAREKSA_NEWS_REPOSITORY_SQL_PASSWORD": "sql-secret"}))
+	ts.Require().NoError(err)
+	ts.Assert().Equal("sql-secret", c.Repository.SQL.Password)
+}
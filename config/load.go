@@ -0,0 +1,249 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// envPrefix of the environment variable of every setting, the setting
+	// repository.sql.host is read from BAREKSA_NEWS_REPOSITORY_SQL_HOST
+	envPrefix = "BAREKSA_NEWS_"
+
+	// redacted replace the secrets printed by String
+	redacted = "REDACTED"
+)
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// setting one value of the configuration, path holds the yaml keys leading
+// to it
+type setting struct {
+	path   []string
+	value  reflect.Value
+	secret bool
+	// listed settings live in a list or a map of the yaml file, they can
+	// not be named by environment variables or flags
+	listed bool
+}
+
+func (s setting) flag() string {
+	return strings.Join(s.path, ".")
+}
+
+func (s setting) env() string {
+	return envPrefix + strings.ToUpper(strings.Join(s.path, "_"))
+}
+
+// settings walk the struct behind v down to its values
+func settings(v reflect.Value, path []string, listed bool, visit func(setting)) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+		name, options := field.Tag.Get("yaml"), ""
+		if comma := strings.Index(name, ","); comma >= 0 {
+			name, options = name[:comma], name[comma+1:]
+		}
+		if name == "-" {
+			continue
+		}
+		value := v.Field(i)
+		if options == "inline" {
+			settings(value, path, listed, visit)
+			continue
+		}
+		if name == "" {
+			name = strings.ToLower(field.Name)
+		}
+		fieldPath := join(path, name)
+
+		switch {
+		case value.Kind() == reflect.Struct:
+			settings(value, fieldPath, listed, visit)
+		case value.Kind() == reflect.Slice && value.Type().Elem().Kind() == reflect.Struct:
+			for j := 0; j < value.Len(); j++ {
+				settings(value.Index(j), join(fieldPath, strconv.Itoa(j)), true, visit)
+			}
+		case value.Kind() == reflect.Map:
+			visit(setting{path: fieldPath, value: value, listed: true})
+		default:
+			visit(setting{path: fieldPath, value: value, secret: field.Tag.Get("secret") == "true", listed: listed})
+		}
+	}
+}
+
+// join a copy of path followed by name, settings keep their paths
+func join(path []string, name string) []string {
+	return append(append(make([]string, 0, len(path)+1), path...), name)
+}
+
+// set parse s into v
+func set(v reflect.Value, s string) error {
+	if v.Type() == durationType {
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(i)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(s, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	case reflect.Slice:
+		if v.Type().Elem().Kind() != reflect.String {
+			return fmt.Errorf("unsupported list of %s", v.Type().Elem())
+		}
+		var items []string
+		for _, item := range strings.Split(s, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		v.Set(reflect.ValueOf(items))
+	default:
+		return fmt.Errorf("unsupported type %s", v.Type())
+	}
+	return nil
+}
+
+// format v the way set parses it
+func format(v reflect.Value) string {
+	if v.Type() == durationType {
+		return time.Duration(v.Int()).String()
+	}
+	if v.Kind() == reflect.Slice {
+		items := make([]string, v.Len())
+		for i := range items {
+			items[i] = fmt.Sprint(v.Index(i).Interface())
+		}
+		return strings.Join(items, ",")
+	}
+	return fmt.Sprint(v.Interface())
+}
+
+// Load layer the settings of Defaults, the yaml file named by the -config
+// flag or BAREKSA_NEWS_CONFIG, the BAREKSA_NEWS_* environment variables and
+// the flags in args, every layer overrides the previous ones, the result is
+// validated
+//
+// secrets have no flag, the command line of a process is readable by every
+// user of the host
+func Load(args []string, lookupEnv func(key string) (string, bool)) (Config, error) {
+	c := Defaults()
+
+	fs := flag.NewFlagSet(os.Args[0], flag.ContinueOnError)
+	configFile := fs.String("config", "", "yaml file of the settings, "+envPrefix+"CONFIG")
+	flagged := make(map[string]setting)
+	settings(reflect.ValueOf(&c).Elem(), nil, false, func(s setting) {
+		if s.listed || s.secret {
+			return
+		}
+		flagged[s.flag()] = s
+		fs.String(s.flag(), format(s.value), s.env())
+	})
+	if err := fs.Parse(args); err != nil {
+		return Config{}, err
+	}
+
+	if *configFile == "" {
+		*configFile, _ = lookupEnv(envPrefix + "CONFIG")
+	}
+	if *configFile != "" {
+		if err := decodeFile(*configFile, &c); err != nil {
+			return Config{}, err
+		}
+	}
+
+	var err error
+	settings(reflect.ValueOf(&c).Elem(), nil, false, func(s setting) {
+		value, ok := lookupEnv(s.env())
+		if s.listed || !ok || err != nil {
+			return
+		}
+		if setErr := set(s.value, value); setErr != nil {
+			err = fmt.Errorf("%s: %v", s.env(), setErr)
+		}
+	})
+	if err != nil {
+		return Config{}, err
+	}
+
+	fs.Visit(func(f *flag.Flag) {
+		s, ok := flagged[f.Name]
+		if !ok || err != nil {
+			return
+		}
+		if setErr := set(s.value, f.Value.String()); setErr != nil {
+			err = fmt.Errorf("-%s: %v", f.Name, setErr)
+		}
+	})
+	if err != nil {
+		return Config{}, err
+	}
+
+	return c, c.Validate()
+}
+
+// decodeFile read the yaml file at path over c, unknown keys are rejected
+// to catch typos
+func decodeFile(path string, c *Config) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	decoder := yaml.NewDecoder(file)
+	decoder.KnownFields(true)
+	if err = decoder.Decode(c); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("%s: %v", path, err)
+	}
+	return nil
+}
+
+// String the effective configuration as yaml, secrets are redacted
+func (c Config) String() string {
+	// the replicas are shared with the caller
+	c.Repository.Replicas = append(c.Repository.Replicas[:0:0], c.Repository.Replicas...)
+	settings(reflect.ValueOf(&c).Elem(), nil, false, func(s setting) {
+		if s.secret && s.value.String() != "" {
+			s.value.SetString(redacted)
+		}
+	})
+	out, err := yaml.Marshal(c)
+	if err != nil {
+		return err.Error()
+	}
+	return string(out)
+}
@@ -4,11 +4,154 @@ const (
 	CircuitBreakerTimeout = 10
 )
 
+const (
+	// CacheCircuitBreaker hystrix command guarding redis
+	CacheCircuitBreaker = `bareksa_news_cache`
+
+	// CacheCircuitBreakerTimeoutMillis slowest redis call before it counts as failed
+	CacheCircuitBreakerTimeoutMillis = 1000
+
+	// CacheCircuitBreakerMaxConcurrent concurrent redis calls, the cache is on every read path
+	CacheCircuitBreakerMaxConcurrent = 1000
+
+	// CacheCircuitBreakerSleepMillis interval between redis probes while the circuit is open
+	CacheCircuitBreakerSleepMillis = 5000
+)
+
+const (
+	// ReplicaHealthCheckSeconds interval between sql replica pings
+	ReplicaHealthCheckSeconds = 5
+
+	// ReplicaStickySeconds reads stay on the sql primary for this long after a write
+	ReplicaStickySeconds = 5
+
+	// NoSQLDisconnectSeconds longest wait for the mongodb operations in progress on shutdown
+	NoSQLDisconnectSeconds = 5
+)
+
+const (
+	// ShutdownTimeout longest wait for the requests in flight on shutdown
+	ShutdownTimeout = 30
+
+	// ShutdownDelaySeconds time the service is reported unready before it stops accepting connections
+	ShutdownDelaySeconds = 5
+
+	// HealthCheckTimeoutMillis longest wait for every readiness check
+	HealthCheckTimeoutMillis = 1000
+
+	// HealthWatchSeconds interval between the checks published by the grpc health service
+	HealthWatchSeconds = 5
+)
+
+const (
+	// TLSReloadSeconds interval between checks of the certificate files for a renewal
+	TLSReloadSeconds = 10
+
+	// ClientAuthRequire every client presents a certificate signed by the client CAs
+	ClientAuthRequire = `require`
+
+	// ClientAuthVerifyIfGiven clients without certificate are accepted, the others are verified
+	ClientAuthVerifyIfGiven = `verify_if_given`
+)
+
+const (
+	// RolePublic permission of the rpcs served without token
+	RolePublic = `public`
+
+	// RoleReader reads every entity
+	RoleReader = `reader`
+
+	// RoleEditor reads and edits tags, topics and newses
+	RoleEditor = `editor`
+
+	// RoleAdmin every rpc, deletes tags and topics and manages the cache
+	RoleAdmin = `admin`
+
+	// RolesClaim jwt claim holding the roles of the caller
+	RolesClaim = `roles`
+)
+
+const (
+	// RateLimitStoreMemory token buckets kept in process, every replica limits on its own
+	RateLimitStoreMemory = `memory`
+
+	// RateLimitStoreRedis token buckets shared by every replica through redis
+	RateLimitStoreRedis = `redis`
+
+	// RateLimitMaxBuckets buckets the memory store holds before it evicts the full ones
+	RateLimitMaxBuckets = 100000
+)
+
+const (
+	// ImportBatchSize newses written within one transaction while importing
+	ImportBatchSize = 100
+	// ExportBatchSize newses read per query while exporting every news
+	ExportBatchSize = 100
+)
+
+const (
+	// SummarySentences sentences of the content extracted into an empty news summary
+	SummarySentences = 3
+
+	// SummaryMaxLength longest extracted summary in runes, fits the news.summary column
+	SummaryMaxLength = 1024
+)
+
 const (
 	// ServiceName service log name
 	ServiceName = `bareksa_news`
 )
 
+const (
+	// DriverMySQL sql repository backed by mysql server
+	DriverMySQL = `mysql`
+
+	// DriverSQLite sql repository backed by embedded sqlite database
+	DriverSQLite = `sqlite`
+
+	// DriverMongoDB nosql repository backed by mongodb
+	DriverMongoDB = `mongodb`
+)
+
+const (
+	// RedisStandalone single redis node
+	RedisStandalone = `standalone`
+
+	// RedisSentinel redis master found through sentinels, failing over with them
+	RedisSentinel = `sentinel`
+
+	// RedisCluster redis cluster, keys used together share a hash tag
+	RedisCluster = `cluster`
+)
+
+const (
+	// CacheDriverRedis cache shared by every replica through redis
+	CacheDriverRedis = `redis`
+
+	// CacheDriverMemory cache kept in process, for tests and local runs
+	CacheDriverMemory = `memory`
+)
+
+const (
+	// TagsCollection mongodb collection
+	TagsCollection = `tags`
+
+	// TopicsCollection mongodb collection
+	TopicsCollection = `topics`
+
+	// NewsCollection mongodb collection, tags are embedded in every news
+	NewsCollection = `news`
+)
+
+const (
+	// CacheSchemaVersion prefix of every redis key holding encoded messages,
+	// bump it whenever a cached message changes
+	CacheSchemaVersion = `v1`
+
+	// CacheCompressBytes cached values of at least this size are compressed
+	CacheCompressBytes = 1024
+)
+
 const (
 	// Tags redis key
 	Tags = `tags`
@@ -16,12 +159,54 @@ const (
 	// Topics redis key
 	Topics = `topics`
 
-	// News redis key
-	News = `news`
+	// Newses redis key prefix of the newses listed with one filter
+	Newses = `newses`
+
+	// NewsesGeneration redis key of the counter embedded in every Newses key
+	NewsesGeneration = `newses_generation`
+
+	// StaleNewses redis key prefix of the hash holding the last newses listed
+	// with one filter and their key, renamed from the string stale_newses
+	StaleNewses = `stale_listing`
+
+	// Lock redis key prefix of the lock held while a key is reloaded
+	Lock = `lock`
+
+	// CacheInvalidations redis channel of the keys every replica drops from its local cache
+	CacheInvalidations = `cache_invalidations`
+)
+
+const (
+	// NewsesCacheSeconds time to live of a cached newses listing
+	NewsesCacheSeconds = 300
+
+	// StaleNewsesCacheSeconds time to live of the listing served while it is reloaded
+	StaleNewsesCacheSeconds = 3600
+
+	// CacheLockSeconds time to live of the lock of a reloaded key
+	CacheLockSeconds = 5
+
+	// CacheLockPollMillis interval between checks for a key reloaded by another replica
+	CacheLockPollMillis = 50
+
+	// LocalCacheSize entries kept in the in-process cache of every replica
+	LocalCacheSize = 1024
+
+	// LocalCacheSeconds time to live of an in-process entry, bounds how long a lost invalidation is served
+	LocalCacheSeconds = 30
+
+	// MaxPageSize largest page_size accepted by GetNewses
+	MaxPageSize = 100
+
+	// CacheScanCount keys asked from redis by every SCAN of the cache namespace
+	CacheScanCount = 100
+
+	// MaxCacheKeys largest limit accepted by ListCacheKeys
+	MaxCacheKeys = 1000
 
-	// ReloadNewses redis key
-	ReloadNewses = `reload_newses`
+	// WarmUpPageSize page_size of the newses listing pages preloaded by a warm-up
+	WarmUpPageSize = 20
 
-	// FilterNewses redis key
-	FilterNewses = `filter_newses`
+	// WarmUpCache preload the cache from the database at startup
+	WarmUpCache = true
 )
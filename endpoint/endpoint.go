@@ -6,6 +6,8 @@ import (
 	kitoc "github.com/go-kit/kit/tracing/opencensus"
 	"github.com/muhammadisa/bareksanews/constant"
 	_interface "github.com/muhammadisa/bareksanews/service/interface"
+	"github.com/muhammadisa/bareksanews/util/auth"
+	"github.com/muhammadisa/bareksanews/util/lmt"
 	"github.com/muhammadisa/bareksanews/util/mw"
 )
 
@@ -24,9 +26,18 @@ type BareksaNewsEndpoint struct {
 	EditNewsEndpoint   endpoint.Endpoint
 	DeleteNewsEndpoint endpoint.Endpoint
 	GetNewsesEndpoint  endpoint.Endpoint
+	ImportNewsEndpoint endpoint.Endpoint
+	ExportNewsEndpoint endpoint.Endpoint
+
+	ListCacheKeysEndpoint endpoint.Endpoint
+	FlushCacheEndpoint    endpoint.Endpoint
+	RebuildCacheEndpoint  endpoint.Endpoint
 }
 
-func NewBareksaNewsEndpoint(tagSvc _interface.Service, logger logger.Logger) (BareksaNewsEndpoint, error) {
+// NewBareksaNewsEndpoint wrap every method of tagSvc with its middlewares,
+// instruments record the metrics of every endpoint, authorizer checks the
+// caller of every endpoint and limiter limits its calls
+func NewBareksaNewsEndpoint(tagSvc _interface.Service, logger logger.Logger, instruments mw.Instruments, authorizer *auth.Authorizer, limiter *lmt.Limiter) (BareksaNewsEndpoint, error) {
 
 	var addTagEp endpoint.Endpoint
 	{
@@ -34,6 +45,9 @@ func NewBareksaNewsEndpoint(tagSvc _interface.Service, logger logger.Logger) (Ba
 		addTagEp = makeAddTagEndpoint(tagSvc)
 		addTagEp = mw.LoggingMiddleware(logger)(addTagEp)
 		addTagEp = mw.CircuitBreakerMiddleware(constant.ServiceName)(addTagEp)
+		addTagEp = mw.RateLimitMiddleware(limiter, name)(addTagEp)
+		addTagEp = mw.AuthMiddleware(authorizer, name)(addTagEp)
+		addTagEp = mw.MetricsMiddleware(instruments, name)(addTagEp)
 		addTagEp = kitoc.TraceEndpoint(name)(addTagEp)
 	}
 
@@ -43,6 +57,9 @@ func NewBareksaNewsEndpoint(tagSvc _interface.Service, logger logger.Logger) (Ba
 		editTagEp = makeEditTagEndpoint(tagSvc)
 		editTagEp = mw.LoggingMiddleware(logger)(editTagEp)
 		editTagEp = mw.CircuitBreakerMiddleware(constant.ServiceName)(editTagEp)
+		editTagEp = mw.RateLimitMiddleware(limiter, name)(editTagEp)
+		editTagEp = mw.AuthMiddleware(authorizer, name)(editTagEp)
+		editTagEp = mw.MetricsMiddleware(instruments, name)(editTagEp)
 		editTagEp = kitoc.TraceEndpoint(name)(editTagEp)
 	}
 
@@ -52,15 +69,21 @@ func NewBareksaNewsEndpoint(tagSvc _interface.Service, logger logger.Logger) (Ba
 		deleteTagEp = makeDeleteTagEndpoint(tagSvc)
 		deleteTagEp = mw.LoggingMiddleware(logger)(deleteTagEp)
 		deleteTagEp = mw.CircuitBreakerMiddleware(constant.ServiceName)(deleteTagEp)
+		deleteTagEp = mw.RateLimitMiddleware(limiter, name)(deleteTagEp)
+		deleteTagEp = mw.AuthMiddleware(authorizer, name)(deleteTagEp)
+		deleteTagEp = mw.MetricsMiddleware(instruments, name)(deleteTagEp)
 		deleteTagEp = kitoc.TraceEndpoint(name)(deleteTagEp)
 	}
 
 	var getTagsEp endpoint.Endpoint
 	{
-		const name = `DeleteTag`
+		const name = `GetTags`
 		getTagsEp = makeGetTagsEndpoint(tagSvc)
 		getTagsEp = mw.LoggingMiddleware(logger)(getTagsEp)
 		getTagsEp = mw.CircuitBreakerMiddleware(constant.ServiceName)(getTagsEp)
+		getTagsEp = mw.RateLimitMiddleware(limiter, name)(getTagsEp)
+		getTagsEp = mw.AuthMiddleware(authorizer, name)(getTagsEp)
+		getTagsEp = mw.MetricsMiddleware(instruments, name)(getTagsEp)
 		getTagsEp = kitoc.TraceEndpoint(name)(getTagsEp)
 	}
 
@@ -72,6 +95,9 @@ func NewBareksaNewsEndpoint(tagSvc _interface.Service, logger logger.Logger) (Ba
 		addTopicEp = makeAddTopicEndpoint(tagSvc)
 		addTopicEp = mw.LoggingMiddleware(logger)(addTopicEp)
 		addTopicEp = mw.CircuitBreakerMiddleware(constant.ServiceName)(addTopicEp)
+		addTopicEp = mw.RateLimitMiddleware(limiter, name)(addTopicEp)
+		addTopicEp = mw.AuthMiddleware(authorizer, name)(addTopicEp)
+		addTopicEp = mw.MetricsMiddleware(instruments, name)(addTopicEp)
 		addTopicEp = kitoc.TraceEndpoint(name)(addTopicEp)
 	}
 
@@ -81,6 +107,9 @@ func NewBareksaNewsEndpoint(tagSvc _interface.Service, logger logger.Logger) (Ba
 		editTopicEp = makeEditTopicEndpoint(tagSvc)
 		editTopicEp = mw.LoggingMiddleware(logger)(editTopicEp)
 		editTopicEp = mw.CircuitBreakerMiddleware(constant.ServiceName)(editTopicEp)
+		editTopicEp = mw.RateLimitMiddleware(limiter, name)(editTopicEp)
+		editTopicEp = mw.AuthMiddleware(authorizer, name)(editTopicEp)
+		editTopicEp = mw.MetricsMiddleware(instruments, name)(editTopicEp)
 		editTopicEp = kitoc.TraceEndpoint(name)(editTopicEp)
 	}
 
@@ -90,15 +119,21 @@ func NewBareksaNewsEndpoint(tagSvc _interface.Service, logger logger.Logger) (Ba
 		deleteTopicEp = makeDeleteTopicEndpoint(tagSvc)
 		deleteTopicEp = mw.LoggingMiddleware(logger)(deleteTopicEp)
 		deleteTopicEp = mw.CircuitBreakerMiddleware(constant.ServiceName)(deleteTopicEp)
+		deleteTopicEp = mw.RateLimitMiddleware(limiter, name)(deleteTopicEp)
+		deleteTopicEp = mw.AuthMiddleware(authorizer, name)(deleteTopicEp)
+		deleteTopicEp = mw.MetricsMiddleware(instruments, name)(deleteTopicEp)
 		deleteTopicEp = kitoc.TraceEndpoint(name)(deleteTopicEp)
 	}
 
 	var getTopicsEp endpoint.Endpoint
 	{
-		const name = `DeleteTopic`
+		const name = `GetTopics`
 		getTopicsEp = makeGetTopicsEndpoint(tagSvc)
 		getTopicsEp = mw.LoggingMiddleware(logger)(getTopicsEp)
 		getTopicsEp = mw.CircuitBreakerMiddleware(constant.ServiceName)(getTopicsEp)
+		getTopicsEp = mw.RateLimitMiddleware(limiter, name)(getTopicsEp)
+		getTopicsEp = mw.AuthMiddleware(authorizer, name)(getTopicsEp)
+		getTopicsEp = mw.MetricsMiddleware(instruments, name)(getTopicsEp)
 		getTopicsEp = kitoc.TraceEndpoint(name)(getTopicsEp)
 	}
 
@@ -110,6 +145,9 @@ func NewBareksaNewsEndpoint(tagSvc _interface.Service, logger logger.Logger) (Ba
 		addNewsEp = makeAddNewsEndpoint(tagSvc)
 		addNewsEp = mw.LoggingMiddleware(logger)(addNewsEp)
 		addNewsEp = mw.CircuitBreakerMiddleware(constant.ServiceName)(addNewsEp)
+		addNewsEp = mw.RateLimitMiddleware(limiter, name)(addNewsEp)
+		addNewsEp = mw.AuthMiddleware(authorizer, name)(addNewsEp)
+		addNewsEp = mw.MetricsMiddleware(instruments, name)(addNewsEp)
 		addNewsEp = kitoc.TraceEndpoint(name)(addNewsEp)
 	}
 
@@ -119,6 +157,9 @@ func NewBareksaNewsEndpoint(tagSvc _interface.Service, logger logger.Logger) (Ba
 		editNewsEp = makeEditNewsEndpoint(tagSvc)
 		editNewsEp = mw.LoggingMiddleware(logger)(editNewsEp)
 		editNewsEp = mw.CircuitBreakerMiddleware(constant.ServiceName)(editNewsEp)
+		editNewsEp = mw.RateLimitMiddleware(limiter, name)(editNewsEp)
+		editNewsEp = mw.AuthMiddleware(authorizer, name)(editNewsEp)
+		editNewsEp = mw.MetricsMiddleware(instruments, name)(editNewsEp)
 		editNewsEp = kitoc.TraceEndpoint(name)(editNewsEp)
 	}
 
@@ -128,6 +169,9 @@ func NewBareksaNewsEndpoint(tagSvc _interface.Service, logger logger.Logger) (Ba
 		deleteNewsEp = makeDeleteNewsEndpoint(tagSvc)
 		deleteNewsEp = mw.LoggingMiddleware(logger)(deleteNewsEp)
 		deleteNewsEp = mw.CircuitBreakerMiddleware(constant.ServiceName)(deleteNewsEp)
+		deleteNewsEp = mw.RateLimitMiddleware(limiter, name)(deleteNewsEp)
+		deleteNewsEp = mw.AuthMiddleware(authorizer, name)(deleteNewsEp)
+		deleteNewsEp = mw.MetricsMiddleware(instruments, name)(deleteNewsEp)
 		deleteNewsEp = kitoc.TraceEndpoint(name)(deleteNewsEp)
 	}
 
@@ -137,9 +181,73 @@ func NewBareksaNewsEndpoint(tagSvc _interface.Service, logger logger.Logger) (Ba
 		getNewsesEp = makeGetNewsesEndpoint(tagSvc)
 		getNewsesEp = mw.LoggingMiddleware(logger)(getNewsesEp)
 		getNewsesEp = mw.CircuitBreakerMiddleware(constant.ServiceName)(getNewsesEp)
+		getNewsesEp = mw.RateLimitMiddleware(limiter, name)(getNewsesEp)
+		getNewsesEp = mw.AuthMiddleware(authorizer, name)(getNewsesEp)
+		getNewsesEp = mw.MetricsMiddleware(instruments, name)(getNewsesEp)
 		getNewsesEp = kitoc.TraceEndpoint(name)(getNewsesEp)
 	}
 
+	// streaming endpoints run as long as their client keeps sending or
+	// receiving, the circuit breaker timeout would cut them off
+	var importNewsEp endpoint.Endpoint
+	{
+		const name = `ImportNews`
+		importNewsEp = makeImportNewsEndpoint(tagSvc)
+		importNewsEp = mw.LoggingMiddleware(logger)(importNewsEp)
+		importNewsEp = mw.RateLimitMiddleware(limiter, name)(importNewsEp)
+		importNewsEp = mw.AuthMiddleware(authorizer, name)(importNewsEp)
+		importNewsEp = mw.MetricsMiddleware(instruments, name)(importNewsEp)
+		importNewsEp = kitoc.TraceEndpoint(name)(importNewsEp)
+	}
+
+	var exportNewsEp endpoint.Endpoint
+	{
+		const name = `ExportNews`
+		exportNewsEp = makeExportNewsEndpoint(tagSvc)
+		exportNewsEp = mw.LoggingMiddleware(logger)(exportNewsEp)
+		exportNewsEp = mw.RateLimitMiddleware(limiter, name)(exportNewsEp)
+		exportNewsEp = mw.AuthMiddleware(authorizer, name)(exportNewsEp)
+		exportNewsEp = mw.MetricsMiddleware(instruments, name)(exportNewsEp)
+		exportNewsEp = kitoc.TraceEndpoint(name)(exportNewsEp)
+	}
+
+	var listCacheKeysEp endpoint.Endpoint
+	{
+		const name = `ListCacheKeys`
+		listCacheKeysEp = makeListCacheKeysEndpoint(tagSvc)
+		listCacheKeysEp = mw.LoggingMiddleware(logger)(listCacheKeysEp)
+		listCacheKeysEp = mw.CircuitBreakerMiddleware(constant.ServiceName)(listCacheKeysEp)
+		listCacheKeysEp = mw.RateLimitMiddleware(limiter, name)(listCacheKeysEp)
+		listCacheKeysEp = mw.AuthMiddleware(authorizer, name)(listCacheKeysEp)
+		listCacheKeysEp = mw.MetricsMiddleware(instruments, name)(listCacheKeysEp)
+		listCacheKeysEp = kitoc.TraceEndpoint(name)(listCacheKeysEp)
+	}
+
+	// a flush walks the whole namespace and a rebuild reads every tag and
+	// topic, like the streaming endpoints they may outlast the circuit
+	// breaker timeout
+	var flushCacheEp endpoint.Endpoint
+	{
+		const name = `FlushCache`
+		flushCacheEp = makeFlushCacheEndpoint(tagSvc)
+		flushCacheEp = mw.LoggingMiddleware(logger)(flushCacheEp)
+		flushCacheEp = mw.RateLimitMiddleware(limiter, name)(flushCacheEp)
+		flushCacheEp = mw.AuthMiddleware(authorizer, name)(flushCacheEp)
+		flushCacheEp = mw.MetricsMiddleware(instruments, name)(flushCacheEp)
+		flushCacheEp = kitoc.TraceEndpoint(name)(flushCacheEp)
+	}
+
+	var rebuildCacheEp endpoint.Endpoint
+	{
+		const name = `RebuildCache`
+		rebuildCacheEp = makeRebuildCacheEndpoint(tagSvc)
+		rebuildCacheEp = mw.LoggingMiddleware(logger)(rebuildCacheEp)
+		rebuildCacheEp = mw.RateLimitMiddleware(limiter, name)(rebuildCacheEp)
+		rebuildCacheEp = mw.AuthMiddleware(authorizer, name)(rebuildCacheEp)
+		rebuildCacheEp = mw.MetricsMiddleware(instruments, name)(rebuildCacheEp)
+		rebuildCacheEp = kitoc.TraceEndpoint(name)(rebuildCacheEp)
+	}
+
 	return BareksaNewsEndpoint{
 		AddTagEndpoint:    addTagEp,
 		EditTagEndpoint:   editTagEp,
@@ -155,5 +263,11 @@ func NewBareksaNewsEndpoint(tagSvc _interface.Service, logger logger.Logger) (Ba
 		EditNewsEndpoint:   editNewsEp,
 		DeleteNewsEndpoint: deleteNewsEp,
 		GetNewsesEndpoint:  getNewsesEp,
+		ImportNewsEndpoint: importNewsEp,
+		ExportNewsEndpoint: exportNewsEp,
+
+		ListCacheKeysEndpoint: listCacheKeysEp,
+		FlushCacheEndpoint:    flushCacheEp,
+		RebuildCacheEndpoint:  rebuildCacheEp,
 	}, nil
 }
@@ -0,0 +1,55 @@
+package endpoint
+
+import (
+	"context"
+
+	"github.com/go-kit/kit/endpoint"
+	pb "github.com/muhammadisa/bareksanews/protoc/api/v1"
+	_interface "github.com/muhammadisa/bareksanews/service/interface"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+func makeListCacheKeysEndpoint(usecase _interface.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		res, err := usecase.ListCacheKeys(ctx, request.(*pb.CacheKeysRequest))
+		return res, err
+	}
+}
+
+func (e BareksaNewsEndpoint) ListCacheKeys(ctx context.Context, req *pb.CacheKeysRequest) (*pb.CacheKeys, error) {
+	res, err := e.ListCacheKeysEndpoint(ctx, req)
+	if err != nil {
+		return &pb.CacheKeys{}, err
+	}
+	return res.(*pb.CacheKeys), nil
+}
+
+func makeFlushCacheEndpoint(usecase _interface.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		res, err := usecase.FlushCache(ctx, &emptypb.Empty{})
+		return res, err
+	}
+}
+
+func (e BareksaNewsEndpoint) FlushCache(ctx context.Context, req *emptypb.Empty) (*pb.FlushCacheResult, error) {
+	res, err := e.FlushCacheEndpoint(ctx, req)
+	if err != nil {
+		return &pb.FlushCacheResult{}, err
+	}
+	return res.(*pb.FlushCacheResult), nil
+}
+
+func makeRebuildCacheEndpoint(usecase _interface.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		res, err := usecase.RebuildCache(ctx, &emptypb.Empty{})
+		return res, err
+	}
+}
+
+func (e BareksaNewsEndpoint) RebuildCache(ctx context.Context, req *emptypb.Empty) (*pb.RebuildCacheResult, error) {
+	res, err := e.RebuildCacheEndpoint(ctx, req)
+	if err != nil {
+		return &pb.RebuildCacheResult{}, err
+	}
+	return res.(*pb.RebuildCacheResult), nil
+}
@@ -68,3 +68,33 @@ func (e BareksaNewsEndpoint) GetNewses(ctx context.Context, req *pb.Filters) (*p
 	}
 	return res.(*pb.Newses), nil
 }
+
+// ExportNewsRequest carry the filters together with the stream the matching
+// newses are sent to
+type ExportNewsRequest struct {
+	Filters *pb.Filters
+	Stream  pb.BareksaNewsService_ExportNewsServer
+}
+
+func makeImportNewsEndpoint(usecase _interface.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		return nil, usecase.ImportNews(request.(pb.BareksaNewsService_ImportNewsServer))
+	}
+}
+
+func (e BareksaNewsEndpoint) ImportNews(stream pb.BareksaNewsService_ImportNewsServer) error {
+	_, err := e.ImportNewsEndpoint(stream.Context(), stream)
+	return err
+}
+
+func makeExportNewsEndpoint(usecase _interface.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(ExportNewsRequest)
+		return nil, usecase.ExportNews(req.Filters, req.Stream)
+	}
+}
+
+func (e BareksaNewsEndpoint) ExportNews(req *pb.Filters, stream pb.BareksaNewsService_ExportNewsServer) error {
+	_, err := e.ExportNewsEndpoint(stream.Context(), ExportNewsRequest{Filters: req, Stream: stream})
+	return err
+}
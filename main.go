@@ -2,127 +2,193 @@ package main
 
 import (
 	"context"
+	"crypto/tls"
+	"errors"
+	"flag"
 	"fmt"
 	"log"
-	"net"
-	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	oczipkin "contrib.go.opencensus.io/exporter/zipkin"
 	"github.com/go-kit/kit/log/level"
-	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"github.com/muhammadisa/bareksanews/config"
 	"github.com/muhammadisa/bareksanews/constant"
 	ep "github.com/muhammadisa/bareksanews/endpoint"
 	"github.com/muhammadisa/bareksanews/gvars"
 	pb "github.com/muhammadisa/bareksanews/protoc/api/v1"
 	"github.com/muhammadisa/bareksanews/repository"
 	"github.com/muhammadisa/bareksanews/service"
+	svcinterface "github.com/muhammadisa/bareksanews/service/interface"
 	"github.com/muhammadisa/bareksanews/transport"
+	"github.com/muhammadisa/bareksanews/util/auth"
 	"github.com/muhammadisa/bareksanews/util/cb"
+	"github.com/muhammadisa/bareksanews/util/crt"
 	"github.com/muhammadisa/bareksanews/util/dbc"
-	"github.com/muhammadisa/bareksanews/util/hdr"
+	"github.com/muhammadisa/bareksanews/util/hlth"
 	"github.com/muhammadisa/bareksanews/util/lgr"
+	"github.com/muhammadisa/bareksanews/util/lmt"
+	"github.com/muhammadisa/bareksanews/util/mw"
 	"github.com/openzipkin/zipkin-go"
+	"github.com/openzipkin/zipkin-go/reporter"
 	httpreporter "github.com/openzipkin/zipkin-go/reporter/http"
-	"github.com/soheilhy/cmux"
+	"github.com/prometheus/client_golang/prometheus"
 	"go.opencensus.io/trace"
-	"golang.org/x/sync/errgroup"
 	"google.golang.org/grpc"
 )
 
-// ServeGRPC serving GRPC server and will be merged using MergeServer function
-func ServeGRPC(listener net.Listener, service pb.BareksaNewsServiceServer, serverOptions []grpc.ServerOption) error {
-	level.Info(gvars.Log).Log(lgr.LogInfo, "initialize grpc server")
-
-	var grpcServer *grpc.Server
-	if len(serverOptions) > 0 {
-		grpcServer = grpc.NewServer(serverOptions...)
-	} else {
-		grpcServer = grpc.NewServer()
+// warmUp preload the cache while the servers start, requests arriving first
+// are served by the database as usual
+func warmUp(ctx context.Context, usecases svcinterface.Service) {
+	res, err := usecases.WarmUp(ctx)
+	if err != nil {
+		level.Warn(gvars.Log).Log(lgr.LogWarn, fmt.Sprintf("cache warm-up failed: %v", err))
+		return
 	}
-	pb.RegisterBareksaNewsServiceServer(grpcServer, service)
-	return grpcServer.Serve(listener)
+	level.Info(gvars.Log).Log(lgr.LogInfo, fmt.Sprintf(
+		"cache warmed up with %d tags, %d topics and %d newses in %d pages in %s",
+		res.Tags, res.Topics, res.Newses, res.Pages, res.Duration.AsDuration(),
+	))
 }
 
-// ServeHTTP serving HTTP server and will be merged using MergeServer function
-func ServeHTTP(listener net.Listener, service pb.BareksaNewsServiceServer) error {
-	level.Info(gvars.Log).Log(lgr.LogInfo, "initialize rest server")
-
-	mux := runtime.NewServeMux()
-	err := pb.RegisterBareksaNewsServiceHandlerServer(context.Background(), mux, service)
+// newLimiter the rate limiter of cfg, the redis store shares the buckets
+// of every replica under the namespace of the cache, release closes its
+// connection
+func newLimiter(cfg config.Config) (limiter *lmt.Limiter, release func() error, err error) {
+	if !cfg.RateLimit.Enabled() || cfg.RateLimit.Store != constant.RateLimitStoreRedis {
+		return lmt.NewLimiter(cfg.RateLimit, lmt.NewMemoryStore()), func() error { return nil }, nil
+	}
+	prefix, err := cfg.Repository.CacheOptions.Namespace.Prefix()
+	if err != nil {
+		return nil, nil, err
+	}
+	client, err := dbc.OpenRedis(cfg.Repository.Cache)
 	if err != nil {
-		return err
+		return nil, nil, err
 	}
-	srv := &http.Server{Handler: hdr.CORS(mux)}
-	return srv.Serve(listener)
+	return lmt.NewLimiter(cfg.RateLimit, lmt.NewRedisStore(client, prefix+"rate_limit:")), client.Close, nil
 }
 
-// MergeServer start ServeGRPC and ServeHTTP concurrently
-func MergeServer(service pb.BareksaNewsServiceServer, serverOptions []grpc.ServerOption) {
-	port := fmt.Sprintf(":%s", "8010")
-	listener, err := net.Listen("tcp", port)
+func main() {
+	cfg, err := config.Load(os.Args[1:], os.LookupEnv)
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	m := cmux.New(listener)
-	grpcListener := m.MatchWithWriters(cmux.HTTP2MatchHeaderFieldSendSettings(
-		"content-type", "application/grpc",
-	))
-	httpListener := m.Match(cmux.HTTP1Fast())
-
-	g := new(errgroup.Group)
-	g.Go(func() error { return ServeGRPC(grpcListener, service, serverOptions) })
-	g.Go(func() error { return ServeHTTP(httpListener, service) })
-	g.Go(func() error { return m.Serve() })
-
-	log.Fatal(g.Wait())
-}
-
-func main() {
-	gvars.Log = lgr.Create(constant.ServiceName)
+	gvars.Log, err = lgr.Filter(lgr.Create(constant.ServiceName), cfg.Log.Level)
+	if err != nil {
+		log.Fatal(err)
+	}
 
 	level.Info(gvars.Log).Log(lgr.LogInfo, "service started")
+	level.Info(gvars.Log).Log(lgr.LogData, fmt.Sprintf("effective configuration\n%s", cfg))
 
+	// the repository outlives the signal, it serves the requests drained
+	// after it
 	ctx := context.Background()
-	defer ctx.Done()
-
-	reporter := httpreporter.NewReporter("http://localhost:9411/api/v2/spans")
-	localEndpoint, _ := zipkin.NewEndpoint(constant.ServiceName, ":0")
-	exporter := oczipkin.NewExporter(reporter, localEndpoint)
-	trace.ApplyConfig(trace.Config{DefaultSampler: trace.AlwaysSample()})
-	trace.RegisterExporter(exporter)
+	stopCtx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	var zipkinReporter reporter.Reporter
+	if cfg.Tracing.ZipkinURL != "" {
+		zipkinReporter = httpreporter.NewReporter(cfg.Tracing.ZipkinURL)
+		localEndpoint, _ := zipkin.NewEndpoint(constant.ServiceName, ":0")
+		exporter := oczipkin.NewExporter(zipkinReporter, localEndpoint)
+		trace.RegisterExporter(exporter)
+	}
+	trace.ApplyConfig(trace.Config{DefaultSampler: trace.ProbabilitySampler(cfg.Tracing.SampleRate)})
 	trcr := trace.DefaultTracer
 
-	var repoConf repository.RepoConf
-	{
-		repoConf.SQL = dbc.Config{
-			Username: "root",
-			Password: "root",
-			Host:     "localhost",
-			Port:     "3306",
-			Name:     "bareksa_news",
-		}
-		repoConf.Cache = dbc.Config{
-			Password: "root",
-			Host:     "localhost",
-			Port:     "6379",
-		}
+	err = cb.StartHystrix(int(cfg.CircuitBreaker.Timeout/time.Second), constant.ServiceName)
+	if err != nil {
+		panic(err)
 	}
-
-	err := cb.StartHystrix(constant.CircuitBreakerTimeout, constant.ServiceName)
+	err = cb.RegisterMetrics(prometheus.DefaultRegisterer, constant.ServiceName, constant.CacheCircuitBreaker)
+	if err != nil {
+		panic(err)
+	}
+	instruments, err := mw.NewInstruments(prometheus.DefaultRegisterer)
 	if err != nil {
 		panic(err)
 	}
 
-	repo, err := repository.NewRepository(ctx, repoConf, trcr)
+	repo, err := repository.NewRepository(ctx, cfg.Repository, trcr)
 	if err != nil {
 		panic(err)
 	}
 
-	bareksaNewsEp, err := ep.NewBareksaNewsEndpoint(service.NewUsecases(*repo, trcr), gvars.Log)
+	usecases := service.NewUsecases(*repo, trcr, cfg.CircuitBreaker.Timeout)
+	if cfg.WarmUpCache {
+		go warmUp(stopCtx, usecases)
+	}
+
+	authorizer, err := auth.NewAuthorizer(cfg.Auth)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if !authorizer.Enabled() {
+		level.Warn(gvars.Log).Log(lgr.LogWarn, "auth is disabled, every rpc is open to anyone")
+	}
+
+	limiter, closeLimiter, err := newLimiter(cfg)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	bareksaNewsEp, err := ep.NewBareksaNewsEndpoint(usecases, gvars.Log, instruments, authorizer, limiter)
 	if err != nil {
 		panic(err)
 	}
 
-	MergeServer(transport.NewBareksaNewsServer(bareksaNewsEp), nil)
+	checker := hlth.NewChecker(constant.HealthCheckTimeoutMillis * time.Millisecond)
+	// reads fall back to the database while redis is down, only the
+	// database makes the service unready
+	checker.Register("database", true, repo.ReadWriter.Ping)
+	checker.Register("cache", false, repo.CacheReadWriter.Ping)
+
+	var tlsConfig *tls.Config
+	if cfg.Listen.TLS.Enabled() {
+		reloader, err := crt.NewReloader(cfg.Listen.TLS)
+		if err != nil {
+			log.Fatal(err)
+		}
+		go reloader.Watch(stopCtx)
+		tlsConfig = reloader.TLSConfig()
+	}
+
+	serviceName := pb.BareksaNewsService_ServiceDesc.ServiceName
+	serverOptions := []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(auth.UnaryServerInterceptor(authorizer, serviceName)),
+		grpc.ChainStreamInterceptor(auth.StreamServerInterceptor(authorizer, serviceName)),
+	}
+	server, err := MergeServer(cfg.Listen.Addr, transport.NewBareksaNewsServer(bareksaNewsEp), serverOptions, checker, tlsConfig)
+	if err != nil {
+		log.Fatal(err)
+	}
+	err = server.Run(stopCtx, cfg.Listen.ShutdownDelay, cfg.Listen.ShutdownTimeout)
+	if err != nil {
+		level.Error(gvars.Log).Log(lgr.LogErr, fmt.Sprintf("server stopped: %v", err))
+	}
+
+	// the servers are drained, nothing uses the connections anymore once
+	// the reloads they left running are done
+	usecases.Wait()
+	if closeErr := repo.Close(); closeErr != nil {
+		level.Error(gvars.Log).Log(lgr.LogErr, fmt.Sprintf("closing the repository: %v", closeErr))
+	}
+	if closeErr := closeLimiter(); closeErr != nil {
+		level.Error(gvars.Log).Log(lgr.LogErr, fmt.Sprintf("closing the rate limit store: %v", closeErr))
+	}
+	if zipkinReporter != nil {
+		_ = zipkinReporter.Close()
+	}
+	level.Info(gvars.Log).Log(lgr.LogInfo, "service stopped")
+	if err != nil {
+		os.Exit(1)
+	}
 }
@@ -7,7 +7,9 @@ type News struct {
 	TopicID          string
 	Title            string
 	Content          string
+	Summary          string
 	Status           int32
+	Version          int64
 	CreatedAt        int64
 	UpdatedAt        int64
 	Created, Updated time.Time
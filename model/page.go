@@ -0,0 +1,9 @@
+package model
+
+// Page window of a listing, a zero Limit reads every row
+type Page struct {
+	Limit  int32
+	Offset int32
+	// SummaryOnly leave the content of the newses out of the read
+	SummaryOnly bool
+}
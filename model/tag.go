@@ -5,6 +5,7 @@ import "time"
 type Tag struct {
 	ID               string
 	Tag              string
+	Version          int64
 	CreatedAt        int64
 	UpdatedAt        int64
 	Created, Updated time.Time
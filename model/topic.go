@@ -6,6 +6,7 @@ type Topic struct {
 	ID               string
 	Title            string
 	Headline         string
+	Version          int64
 	CreatedAt        int64
 	UpdatedAt        int64
 	Created, Updated time.Time
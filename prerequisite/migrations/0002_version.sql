@@ -0,0 +1,8 @@
+--
+-- Entity versions checked by the edits, for mysql databases created before
+-- optimistic concurrency control, run once before upgrading the service
+--
+
+ALTER TABLE `topics` ADD COLUMN `version` bigint NOT NULL DEFAULT 1 AFTER `headline`;
+ALTER TABLE `news` ADD COLUMN `version` bigint NOT NULL DEFAULT 1 AFTER `status`;
+ALTER TABLE `tags` ADD COLUMN `version` bigint NOT NULL DEFAULT 1 AFTER `tag`;
@@ -0,0 +1,8 @@
+--
+-- Long-form content and its summary, for mysql databases created before
+-- summaries, run once before upgrading the service, the newses are
+-- summarized on read until they are edited
+--
+
+ALTER TABLE `news` MODIFY COLUMN `content` mediumtext NOT NULL;
+ALTER TABLE `news` ADD COLUMN `summary` varchar(1024) NOT NULL DEFAULT '' AFTER `content`;
@@ -10,6 +10,7 @@ CREATE TABLE `topics`
     `id`         varchar(36)  NOT NULL,
     `title`      varchar(255) NOT NULL,
     `headline`   varchar(255) NOT NULL,
+    `version`    bigint       NOT NULL DEFAULT 1,
     `created_at` timestamp NULL DEFAULT CURRENT_TIMESTAMP,
     `updated_at` timestamp NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
     PRIMARY KEY (`id`)
@@ -28,8 +29,10 @@ CREATE TABLE `news`
     `id`         varchar(36)  NOT NULL,
     `topic_id`   varchar(36)  NOT NULL,
     `title`      varchar(255) NOT NULL,
-    `content`    varchar(255) NOT NULL,
+    `content`    mediumtext   NOT NULL,
+    `summary`    varchar(1024) NOT NULL DEFAULT '',
     `status`      int          not null,
+    `version`    bigint       NOT NULL DEFAULT 1,
     `created_at` timestamp NULL DEFAULT CURRENT_TIMESTAMP,
     `updated_at` timestamp NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
     PRIMARY KEY (`id`),
@@ -49,6 +52,7 @@ CREATE TABLE `tags`
 (
     `id`         varchar(36)  NOT NULL,
     `tag`        varchar(125) NOT NULL,
+    `version`    bigint       NOT NULL DEFAULT 1,
     `created_at` timestamp NULL DEFAULT CURRENT_TIMESTAMP,
     `updated_at` timestamp NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
     PRIMARY KEY (`id`)
@@ -0,0 +1,11 @@
+package v1
+
+import (
+	_ "embed"
+)
+
+// OpenAPI the OpenAPI v2 document of the rest gateway, generated from
+// tag.proto and the http rules of tag.yaml
+//
+//go:embed tag.swagger.json
+var OpenAPI []byte
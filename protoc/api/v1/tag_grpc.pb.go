@@ -31,6 +31,11 @@ type BareksaNewsServiceClient interface {
 	EditNews(ctx context.Context, in *News, opts ...grpc.CallOption) (*emptypb.Empty, error)
 	DeleteNews(ctx context.Context, in *Select, opts ...grpc.CallOption) (*emptypb.Empty, error)
 	GetNewses(ctx context.Context, in *Filters, opts ...grpc.CallOption) (*Newses, error)
+	ImportNews(ctx context.Context, opts ...grpc.CallOption) (BareksaNewsService_ImportNewsClient, error)
+	ExportNews(ctx context.Context, in *Filters, opts ...grpc.CallOption) (BareksaNewsService_ExportNewsClient, error)
+	ListCacheKeys(ctx context.Context, in *CacheKeysRequest, opts ...grpc.CallOption) (*CacheKeys, error)
+	FlushCache(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*FlushCacheResult, error)
+	RebuildCache(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*RebuildCacheResult, error)
 }
 
 type bareksaNewsServiceClient struct {
@@ -149,6 +154,99 @@ func (c *bareksaNewsServiceClient) GetNewses(ctx context.Context, in *Filters, o
 	return out, nil
 }
 
+func (c *bareksaNewsServiceClient) ImportNews(ctx context.Context, opts ...grpc.CallOption) (BareksaNewsService_ImportNewsClient, error) {
+	stream, err := c.cc.NewStream(ctx, &BareksaNewsService_ServiceDesc.Streams[0], "/api.v1.BareksaNewsService/ImportNews", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &bareksaNewsServiceImportNewsClient{stream}
+	return x, nil
+}
+
+type BareksaNewsService_ImportNewsClient interface {
+	Send(*ImportNewsRecord) error
+	CloseAndRecv() (*ImportNewsResult, error)
+	grpc.ClientStream
+}
+
+type bareksaNewsServiceImportNewsClient struct {
+	grpc.ClientStream
+}
+
+func (x *bareksaNewsServiceImportNewsClient) Send(m *ImportNewsRecord) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *bareksaNewsServiceImportNewsClient) CloseAndRecv() (*ImportNewsResult, error) {
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	m := new(ImportNewsResult)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *bareksaNewsServiceClient) ExportNews(ctx context.Context, in *Filters, opts ...grpc.CallOption) (BareksaNewsService_ExportNewsClient, error) {
+	stream, err := c.cc.NewStream(ctx, &BareksaNewsService_ServiceDesc.Streams[1], "/api.v1.BareksaNewsService/ExportNews", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &bareksaNewsServiceExportNewsClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type BareksaNewsService_ExportNewsClient interface {
+	Recv() (*News, error)
+	grpc.ClientStream
+}
+
+type bareksaNewsServiceExportNewsClient struct {
+	grpc.ClientStream
+}
+
+func (x *bareksaNewsServiceExportNewsClient) Recv() (*News, error) {
+	m := new(News)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *bareksaNewsServiceClient) ListCacheKeys(ctx context.Context, in *CacheKeysRequest, opts ...grpc.CallOption) (*CacheKeys, error) {
+	out := new(CacheKeys)
+	err := c.cc.Invoke(ctx, "/api.v1.BareksaNewsService/ListCacheKeys", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *bareksaNewsServiceClient) FlushCache(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*FlushCacheResult, error) {
+	out := new(FlushCacheResult)
+	err := c.cc.Invoke(ctx, "/api.v1.BareksaNewsService/FlushCache", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *bareksaNewsServiceClient) RebuildCache(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*RebuildCacheResult, error) {
+	out := new(RebuildCacheResult)
+	err := c.cc.Invoke(ctx, "/api.v1.BareksaNewsService/RebuildCache", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // BareksaNewsServiceServer is the server API for BareksaNewsService service.
 // All implementations should embed UnimplementedBareksaNewsServiceServer
 // for forward compatibility
@@ -165,6 +263,11 @@ type BareksaNewsServiceServer interface {
 	EditNews(context.Context, *News) (*emptypb.Empty, error)
 	DeleteNews(context.Context, *Select) (*emptypb.Empty, error)
 	GetNewses(context.Context, *Filters) (*Newses, error)
+	ImportNews(BareksaNewsService_ImportNewsServer) error
+	ExportNews(*Filters, BareksaNewsService_ExportNewsServer) error
+	ListCacheKeys(context.Context, *CacheKeysRequest) (*CacheKeys, error)
+	FlushCache(context.Context, *emptypb.Empty) (*FlushCacheResult, error)
+	RebuildCache(context.Context, *emptypb.Empty) (*RebuildCacheResult, error)
 }
 
 // UnimplementedBareksaNewsServiceServer should be embedded to have forward compatible implementations.
@@ -207,6 +310,21 @@ func (UnimplementedBareksaNewsServiceServer) DeleteNews(context.Context, *Select
 func (UnimplementedBareksaNewsServiceServer) GetNewses(context.Context, *Filters) (*Newses, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetNewses not implemented")
 }
+func (UnimplementedBareksaNewsServiceServer) ImportNews(BareksaNewsService_ImportNewsServer) error {
+	return status.Errorf(codes.Unimplemented, "method ImportNews not implemented")
+}
+func (UnimplementedBareksaNewsServiceServer) ExportNews(*Filters, BareksaNewsService_ExportNewsServer) error {
+	return status.Errorf(codes.Unimplemented, "method ExportNews not implemented")
+}
+func (UnimplementedBareksaNewsServiceServer) ListCacheKeys(context.Context, *CacheKeysRequest) (*CacheKeys, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListCacheKeys not implemented")
+}
+func (UnimplementedBareksaNewsServiceServer) FlushCache(context.Context, *emptypb.Empty) (*FlushCacheResult, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method FlushCache not implemented")
+}
+func (UnimplementedBareksaNewsServiceServer) RebuildCache(context.Context, *emptypb.Empty) (*RebuildCacheResult, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RebuildCache not implemented")
+}
 
 // UnsafeBareksaNewsServiceServer may be embedded to opt out of forward compatibility for this service.
 // Use of this interface is not recommended, as added methods to BareksaNewsServiceServer will
@@ -435,6 +553,107 @@ func _BareksaNewsService_GetNewses_Handler(srv interface{}, ctx context.Context,
 	return interceptor(ctx, in, info, handler)
 }
 
+func _BareksaNewsService_ImportNews_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(BareksaNewsServiceServer).ImportNews(&bareksaNewsServiceImportNewsServer{stream})
+}
+
+type BareksaNewsService_ImportNewsServer interface {
+	SendAndClose(*ImportNewsResult) error
+	Recv() (*ImportNewsRecord, error)
+	grpc.ServerStream
+}
+
+type bareksaNewsServiceImportNewsServer struct {
+	grpc.ServerStream
+}
+
+func (x *bareksaNewsServiceImportNewsServer) SendAndClose(m *ImportNewsResult) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *bareksaNewsServiceImportNewsServer) Recv() (*ImportNewsRecord, error) {
+	m := new(ImportNewsRecord)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func _BareksaNewsService_ExportNews_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(Filters)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(BareksaNewsServiceServer).ExportNews(m, &bareksaNewsServiceExportNewsServer{stream})
+}
+
+type BareksaNewsService_ExportNewsServer interface {
+	Send(*News) error
+	grpc.ServerStream
+}
+
+type bareksaNewsServiceExportNewsServer struct {
+	grpc.ServerStream
+}
+
+func (x *bareksaNewsServiceExportNewsServer) Send(m *News) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _BareksaNewsService_ListCacheKeys_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CacheKeysRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BareksaNewsServiceServer).ListCacheKeys(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/api.v1.BareksaNewsService/ListCacheKeys",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BareksaNewsServiceServer).ListCacheKeys(ctx, req.(*CacheKeysRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BareksaNewsService_FlushCache_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(emptypb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BareksaNewsServiceServer).FlushCache(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/api.v1.BareksaNewsService/FlushCache",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BareksaNewsServiceServer).FlushCache(ctx, req.(*emptypb.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BareksaNewsService_RebuildCache_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(emptypb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BareksaNewsServiceServer).RebuildCache(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/api.v1.BareksaNewsService/RebuildCache",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BareksaNewsServiceServer).RebuildCache(ctx, req.(*emptypb.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // BareksaNewsService_ServiceDesc is the grpc.ServiceDesc for BareksaNewsService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -490,7 +709,30 @@ var BareksaNewsService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "GetNewses",
 			Handler:    _BareksaNewsService_GetNewses_Handler,
 		},
+		{
+			MethodName: "ListCacheKeys",
+			Handler:    _BareksaNewsService_ListCacheKeys_Handler,
+		},
+		{
+			MethodName: "FlushCache",
+			Handler:    _BareksaNewsService_FlushCache_Handler,
+		},
+		{
+			MethodName: "RebuildCache",
+			Handler:    _BareksaNewsService_RebuildCache_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "ImportNews",
+			Handler:       _BareksaNewsService_ImportNews_Handler,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "ExportNews",
+			Handler:       _BareksaNewsService_ExportNews_Handler,
+			ServerStreams: true,
+		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "tag.proto",
 }
@@ -0,0 +1,250 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/afex/hystrix-go/hystrix"
+	"github.com/muhammadisa/bareksanews/constant"
+	pb "github.com/muhammadisa/bareksanews/protoc/api/v1"
+	"github.com/muhammadisa/bareksanews/repository/errs"
+)
+
+// configureBreaker set up the hystrix command guarding redis
+func configureBreaker(command string) {
+	hystrix.ConfigureCommand(command, hystrix.CommandConfig{
+		Timeout:               constant.CacheCircuitBreakerTimeoutMillis,
+		MaxConcurrentRequests: constant.CacheCircuitBreakerMaxConcurrent,
+		SleepWindow:           constant.CacheCircuitBreakerSleepMillis,
+	})
+}
+
+// breakerCache guard redis with its own circuit breaker, while redis fails
+// reads report errs.ErrCacheUnavailable for the service to use the database
+// and writes are dropped, the first call after redis recovers drops every
+// value the dropped writes may have left stale
+type breakerCache struct {
+	next    *cache
+	command string
+	// dropped counts the failed calls since the last resync
+	dropped int64
+}
+
+func newBreakerCache(next *cache, command string) *breakerCache {
+	return &breakerCache{next: next, command: command}
+}
+
+// outcome the value and error of a call that kept the circuit closed
+type outcome struct {
+	value interface{}
+	err   error
+}
+
+// do run call through the circuit breaker, cache misses and cancelled
+// requests are not redis failures and keep the circuit closed
+//
+// hystrix runs call in its own goroutine and stops waiting for it at the
+// timeout, its outcome is handed over a buffered channel so a call that
+// finishes late writes nothing the caller still reads
+func (c *breakerCache) do(ctx context.Context, call func(ctx context.Context) (interface{}, error)) (interface{}, error) {
+	done := make(chan outcome, 1)
+	err := hystrix.Do(c.command, func() error {
+		if err := c.resync(ctx); err != nil {
+			return err
+		}
+		value, err := call(ctx)
+		if err == nil || errors.Is(err, errs.ErrCacheMiss) || ctx.Err() != nil {
+			done <- outcome{value: value, err: err}
+			return nil
+		}
+		return err
+	}, nil)
+	if err != nil {
+		atomic.AddInt64(&c.dropped, 1)
+		degradedGauge.Set(1)
+		degradedCalls.Inc()
+		return nil, fmt.Errorf("%w : %v", errs.ErrCacheUnavailable, err)
+	}
+	res := <-done
+	return res.value, res.err
+}
+
+// write run call like do, a failed write is dropped for resync to clean up
+func (c *breakerCache) write(ctx context.Context, call func(ctx context.Context) error) error {
+	_, err := c.do(ctx, func(ctx context.Context) (interface{}, error) {
+		return nil, call(ctx)
+	})
+	if errors.Is(err, errs.ErrCacheUnavailable) {
+		return nil
+	}
+	return err
+}
+
+// resync clean up after the calls failed since the last resync, a failure
+// counted meanwhile leaves the count for the next call to resync again
+func (c *breakerCache) resync(ctx context.Context) error {
+	dropped := atomic.LoadInt64(&c.dropped)
+	if dropped == 0 {
+		return nil
+	}
+	if err := c.next.resync(ctx); err != nil {
+		return err
+	}
+	if atomic.CompareAndSwapInt64(&c.dropped, dropped, 0) {
+		degradedGauge.Set(0)
+	}
+	return nil
+}
+
+func (c *breakerCache) SetTag(ctx context.Context, tag *pb.Tag) error {
+	return c.write(ctx, func(ctx context.Context) error {
+		return c.next.SetTag(ctx, tag)
+	})
+}
+
+func (c *breakerCache) UnsetTag(ctx context.Context, id string) error {
+	return c.write(ctx, func(ctx context.Context) error {
+		return c.next.UnsetTag(ctx, id)
+	})
+}
+
+func (c *breakerCache) GetTags(ctx context.Context) (*pb.Tags, error) {
+	value, err := c.do(ctx, func(ctx context.Context) (interface{}, error) {
+		return c.next.GetTags(ctx)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return value.(*pb.Tags), nil
+}
+
+func (c *breakerCache) ReloadTags(ctx context.Context, tags *pb.Tags) error {
+	return c.write(ctx, func(ctx context.Context) error {
+		return c.next.ReloadTags(ctx, tags)
+	})
+}
+
+func (c *breakerCache) SetTopic(ctx context.Context, topic *pb.Topic) error {
+	return c.write(ctx, func(ctx context.Context) error {
+		return c.next.SetTopic(ctx, topic)
+	})
+}
+
+func (c *breakerCache) UnsetTopic(ctx context.Context, id string) error {
+	return c.write(ctx, func(ctx context.Context) error {
+		return c.next.UnsetTopic(ctx, id)
+	})
+}
+
+func (c *breakerCache) GetTopics(ctx context.Context) (*pb.Topics, error) {
+	value, err := c.do(ctx, func(ctx context.Context) (interface{}, error) {
+		return c.next.GetTopics(ctx)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return value.(*pb.Topics), nil
+}
+
+func (c *breakerCache) ReloadTopics(ctx context.Context, topics *pb.Topics) error {
+	return c.write(ctx, func(ctx context.Context) error {
+		return c.next.ReloadTopics(ctx, topics)
+	})
+}
+
+func (c *breakerCache) NewsesKey(ctx context.Context, filter string) (string, error) {
+	value, err := c.do(ctx, func(ctx context.Context) (interface{}, error) {
+		return c.next.NewsesKey(ctx, filter)
+	})
+	if err != nil {
+		return "", err
+	}
+	return value.(string), nil
+}
+
+func (c *breakerCache) GetNewses(ctx context.Context, key string) (*pb.Newses, error) {
+	value, err := c.do(ctx, func(ctx context.Context) (interface{}, error) {
+		return c.next.GetNewses(ctx, key)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return value.(*pb.Newses), nil
+}
+
+func (c *breakerCache) SetNewses(ctx context.Context, key string, newses *pb.Newses) error {
+	return c.write(ctx, func(ctx context.Context) error {
+		return c.next.SetNewses(ctx, key, newses)
+	})
+}
+
+func (c *breakerCache) InvalidateNewses(ctx context.Context) error {
+	return c.write(ctx, func(ctx context.Context) error {
+		return c.next.InvalidateNewses(ctx)
+	})
+}
+
+func (c *breakerCache) GetStaleNewses(ctx context.Context, filter string) (*pb.Newses, string, error) {
+	type stale struct {
+		newses *pb.Newses
+		key    string
+	}
+	value, err := c.do(ctx, func(ctx context.Context) (interface{}, error) {
+		newses, key, err := c.next.GetStaleNewses(ctx, filter)
+		return stale{newses: newses, key: key}, err
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	return value.(stale).newses, value.(stale).key, nil
+}
+
+func (c *breakerCache) SetStaleNewses(ctx context.Context, filter, key string, newses *pb.Newses) error {
+	return c.write(ctx, func(ctx context.Context) error {
+		return c.next.SetStaleNewses(ctx, filter, key, newses)
+	})
+}
+
+func (c *breakerCache) Lock(ctx context.Context, key string, ttl time.Duration) (string, bool, error) {
+	type lock struct {
+		token  string
+		locked bool
+	}
+	value, err := c.do(ctx, func(ctx context.Context) (interface{}, error) {
+		token, locked, err := c.next.Lock(ctx, key, ttl)
+		return lock{token: token, locked: locked}, err
+	})
+	if err != nil {
+		return "", false, err
+	}
+	return value.(lock).token, value.(lock).locked, nil
+}
+
+func (c *breakerCache) Unlock(ctx context.Context, key, token string) error {
+	return c.write(ctx, func(ctx context.Context) error {
+		return c.next.Unlock(ctx, key, token)
+	})
+}
+
+// ListKeys walks the whole namespace and outlasts the breaker timeout, the
+// admin calls go to redis directly and report its errors as they are
+func (c *breakerCache) ListKeys(ctx context.Context, limit int) ([]string, bool, error) {
+	return c.next.ListKeys(ctx, limit)
+}
+
+func (c *breakerCache) FlushKeys(ctx context.Context) (int64, error) {
+	return c.next.FlushKeys(ctx)
+}
+
+// Ping reach redis even while the circuit is open, health checks report
+// whether redis is back before the circuit probes it
+func (c *breakerCache) Ping(ctx context.Context) error {
+	return c.next.Ping(ctx)
+}
+
+func (c *breakerCache) Close() error {
+	return c.next.Close()
+}
@@ -0,0 +1,95 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/afex/hystrix-go/hystrix"
+	"github.com/go-redis/redismock/v8"
+	"github.com/muhammadisa/bareksanews/constant"
+	pb "github.com/muhammadisa/bareksanews/protoc/api/v1"
+	"github.com/muhammadisa/bareksanews/repository/errs"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/suite"
+	"go.opencensus.io/trace"
+)
+
+type breakerCacheTestSuite struct {
+	suite.Suite
+}
+
+func TestBreakerCacheTestSuite(t *testing.T) {
+	suite.Run(t, new(breakerCacheTestSuite))
+}
+
+// newBreaker guard a mocked redis with a circuit opening on the first
+// failure and probing again after 50ms
+func (ts *breakerCacheTestSuite) newBreaker(command string) (*breakerCache, redismock.ClientMock) {
+	hystrix.ConfigureCommand(command, hystrix.CommandConfig{
+		Timeout:                constant.CacheCircuitBreakerTimeoutMillis,
+		MaxConcurrentRequests:  constant.CacheCircuitBreakerMaxConcurrent,
+		RequestVolumeThreshold: 1,
+		ErrorPercentThreshold:  1,
+		SleepWindow:            50,
+	})
+	db, mock := redismock.NewClientMock()
+	return newBreakerCache(&cache{redis: db, tracer: trace.DefaultTracer}, command), mock
+}
+
+func (ts *breakerCacheTestSuite) TestMissKeepsCircuitClosed() {
+	breaker, mock := ts.newBreaker("test_cache_miss")
+	ctx := context.Background()
+
+	mock.ExpectGet(newsesKey(1, "none")).RedisNil()
+
+	_, err := breaker.GetNewses(ctx, newsesKey(1, "none"))
+	ts.Assert().True(errors.Is(err, errs.ErrCacheMiss))
+	ts.Assert().Zero(testutil.ToFloat64(degradedGauge))
+
+	circuit, _, err := hystrix.GetCircuit("test_cache_miss")
+	ts.Require().NoError(err)
+	ts.Assert().False(circuit.IsOpen())
+
+	err = mock.ExpectationsWereMet()
+	ts.Assert().NoError(err)
+}
+
+func (ts *breakerCacheTestSuite) TestDegradeAndRecover() {
+	breaker, mock := ts.newBreaker("test_cache_degrade")
+	ctx := context.Background()
+
+	mock.ExpectHGetAll(versioned(constant.Tags)).SetErr(errors.New("connection refused"))
+
+	_, err := breaker.GetTags(ctx)
+	ts.Assert().True(errors.Is(err, errs.ErrCacheUnavailable))
+	ts.Assert().Equal(float64(1), testutil.ToFloat64(degradedGauge))
+
+	circuit, _, err := hystrix.GetCircuit("test_cache_degrade")
+	ts.Require().NoError(err)
+	// hystrix collects its metrics asynchronously
+	ts.Eventually(circuit.IsOpen, time.Second, 5*time.Millisecond)
+
+	// while the circuit is open redis is not called at all, reads fail
+	// fast and writes are dropped
+	_, err = breaker.GetTopics(ctx)
+	ts.Assert().True(errors.Is(err, errs.ErrCacheUnavailable))
+	err = breaker.SetTag(ctx, &pb.Tag{Id: "c63b17cc-e227-4947-a01f-74f429ce99be", Tag: "tech"})
+	ts.Assert().NoError(err)
+
+	// the probe after the sleep window resyncs before it is served
+	time.Sleep(60 * time.Millisecond)
+	mock.ExpectDel(versioned(constant.Tags)).SetVal(1)
+	mock.ExpectDel(versioned(constant.Topics)).SetVal(1)
+	mock.ExpectIncr(versioned(constant.NewsesGeneration)).SetVal(4)
+	mock.ExpectHGetAll(versioned(constant.Tags)).SetVal(map[string]string{})
+
+	tags, err := breaker.GetTags(ctx)
+	ts.Require().NoError(err)
+	ts.Assert().Empty(tags.Tags)
+	ts.Assert().Zero(testutil.ToFloat64(degradedGauge))
+
+	err = mock.ExpectationsWereMet()
+	ts.Assert().NoError(err)
+}
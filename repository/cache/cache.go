@@ -1,24 +1,84 @@
 package cache
 
 import (
+	"context"
+	"io"
+
 	"github.com/go-redis/redis/v8"
+	"github.com/muhammadisa/bareksanews/constant"
 	_interface "github.com/muhammadisa/bareksanews/repository/interface"
 	"github.com/muhammadisa/bareksanews/util/dbc"
 	"go.opencensus.io/trace"
 )
 
 type cache struct {
-	tracer trace.Tracer
-	redis  redis.Cmdable
+	tracer        trace.Tracer
+	redis         redis.Cmdable
+	compressAbove int
+	// prefix the namespace of every key, see Namespace
+	prefix string
+}
+
+// Options tune the cache kept in redis and the local tier in front of it
+type Options struct {
+	Namespace Namespace   `yaml:"namespace"`
+	Local     LocalConfig `yaml:"local"`
+	// CompressAbove compress the values of at least this many bytes, zero
+	// stores every value uncompressed
+	CompressAbove int `yaml:"compress_above"`
 }
 
-func NewCache(config dbc.Config, tracer trace.Tracer) (_interface.Cache, error) {
-	redisDB, err := dbc.OpenRedis(config)
+// NewCache open redis behind its circuit breaker, the local tier is put in front of it unless its size
+// is zero, invalidations are received until ctx is done
+func NewCache(ctx context.Context, config dbc.RedisConfig, options Options, tracer trace.Tracer) (_interface.Cache, error) {
+	prefix, err := options.Namespace.Prefix()
+	if err != nil {
+		return nil, err
+	}
+	client, err := dbc.OpenRedis(config)
+	if err != nil {
+		return nil, err
+	}
+	redisCache := &cache{
+		redis:         client,
+		tracer:        tracer,
+		compressAbove: options.CompressAbove,
+		prefix:        prefix,
+	}
+	configureBreaker(constant.CacheCircuitBreaker)
+	breakerCache := newBreakerCache(redisCache, constant.CacheCircuitBreaker)
+	if options.Local.Size <= 0 {
+		return breakerCache, nil
+	}
+	localCache, err := newLocalCache(ctx, breakerCache, options.Local, redisInvalidations{client: client, channel: redisCache.key(constant.CacheInvalidations)})
 	if err != nil {
+		_ = client.Close()
 		return nil, err
 	}
-	return &cache{
-		redis:  redisDB,
-		tracer: tracer,
-	}, nil
+	return localCache, nil
+}
+
+func (c *cache) Ping(ctx context.Context) error {
+	return c.redis.Ping(ctx).Err()
+}
+
+// Close close the redis client unless the caller owns it
+func (c *cache) Close() error {
+	if closer, ok := c.redis.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+// resync drop the values writes may have left stale while redis was
+// unreachable, tags and topics are reloaded and every newses listing left
+// behind, the keys live in separate cluster slots and are sent one by one
+func (c *cache) resync(ctx context.Context) error {
+	_, err := c.redis.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Del(ctx, c.key(versioned(constant.Tags)))
+		pipe.Del(ctx, c.key(versioned(constant.Topics)))
+		pipe.Incr(ctx, c.key(versioned(constant.NewsesGeneration)))
+		return nil
+	})
+	return err
 }
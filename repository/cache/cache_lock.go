@@ -0,0 +1,45 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/muhammadisa/bareksanews/constant"
+	uuid "github.com/satori/go.uuid"
+)
+
+// unlockScript delete the lock only while it still holds the token, a lock
+// expired and taken by another holder is left alone
+var unlockScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+func lockKey(key string) string {
+	return fmt.Sprintf("%s:%s", constant.Lock, key)
+}
+
+func (c *cache) Lock(ctx context.Context, key string, ttl time.Duration) (token string, locked bool, err error) {
+	const funcName = `Lock`
+	_, span := c.tracer.StartSpan(ctx, funcName)
+	defer span.End()
+
+	token = uuid.NewV4().String()
+	locked, err = c.redis.SetNX(ctx, c.key(lockKey(key)), token, ttl).Result()
+	if err != nil || !locked {
+		return "", false, err
+	}
+	return token, true, nil
+}
+
+func (c *cache) Unlock(ctx context.Context, key, token string) error {
+	const funcName = `Unlock`
+	_, span := c.tracer.StartSpan(ctx, funcName)
+	defer span.End()
+
+	return unlockScript.Run(ctx, c.redis, []string{c.key(lockKey(key))}, token).Err()
+}
@@ -0,0 +1,68 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redismock/v8"
+	"github.com/stretchr/testify/suite"
+	"go.opencensus.io/trace"
+)
+
+type cacheLockTestSuite struct {
+	suite.Suite
+}
+
+func TestCacheLockTestSuite(t *testing.T) {
+	suite.Run(t, new(cacheLockTestSuite))
+}
+
+func (ts *cacheLockTestSuite) TestLock() {
+	ctx := context.Background()
+
+	tests := []struct {
+		Name   string
+		Locked bool
+	}{
+		{
+			Name:   "lock acquired",
+			Locked: true,
+		},
+		{
+			Name:   "lock held by another replica",
+			Locked: false,
+		},
+	}
+
+	for _, test := range tests {
+		ts.Run(test.Name, func() {
+			db, mock := redismock.NewClientMock()
+			redisCache := &cache{redis: db, tracer: trace.DefaultTracer}
+
+			mock.Regexp().ExpectSetNX("lock:tags", `^[0-9a-f-]{36}$`, 5*time.Second).SetVal(test.Locked)
+
+			token, locked, err := redisCache.Lock(ctx, "tags", 5*time.Second)
+			ts.Assert().NoError(err)
+			ts.Assert().Equal(test.Locked, locked)
+			ts.Assert().Equal(test.Locked, token != "")
+
+			err = mock.ExpectationsWereMet()
+			ts.Assert().NoError(err)
+		})
+	}
+}
+
+func (ts *cacheLockTestSuite) TestUnlock() {
+	db, mock := redismock.NewClientMock()
+	ctx := context.Background()
+	redisCache := &cache{redis: db, tracer: trace.DefaultTracer}
+
+	mock.ExpectEvalSha(unlockScript.Hash(), []string{"lock:newses:1:none"}, "token_1").SetVal(int64(1))
+
+	err := redisCache.Unlock(ctx, "newses:1:none", "token_1")
+	ts.Assert().NoError(err)
+
+	err = mock.ExpectationsWereMet()
+	ts.Assert().NoError(err)
+}
@@ -2,53 +2,65 @@ package cache
 
 import (
 	"context"
-	"encoding/json"
+	"time"
 
+	"github.com/go-redis/redis/v8"
 	"github.com/muhammadisa/bareksanews/constant"
 	pb "github.com/muhammadisa/bareksanews/protoc/api/v1"
+	"github.com/muhammadisa/bareksanews/repository/errs"
 )
 
-func (c *cache) GetNewses(ctx context.Context) (res *pb.Newses, err error) {
-	const funcName = `GetNewses`
+// NewsesKey embed the current generation in the key, bumping the generation
+// leaves every cached listing behind to expire with its ttl
+func (c *cache) NewsesKey(ctx context.Context, filter string) (string, error) {
+	const funcName = `NewsesKey`
 	_, span := c.tracer.StartSpan(ctx, funcName)
 	defer span.End()
 
-	var newses pb.Newses
-	tagsMap := c.redis.HGetAll(ctx, constant.News).Val()
-	for _, v := range tagsMap {
-		var news pb.News
-		err = json.Unmarshal([]byte(v), &news)
-		if err != nil {
-			return res, err
-		}
-		newses.Newses = append(newses.Newses, &news)
+	generation, err := c.redis.Get(ctx, c.key(versioned(constant.NewsesGeneration))).Int64()
+	if err != nil && err != redis.Nil {
+		return "", err
 	}
-	c.redis.Set(ctx, constant.ReloadNewses, false, 0)
-	return &newses, nil
+	return newsesKey(generation, filter), nil
 }
 
-func (c *cache) GetFilter(ctx context.Context) string {
-	const funcName = `GetFilter`
+func (c *cache) GetNewses(ctx context.Context, key string) (res *pb.Newses, err error) {
+	const funcName = `GetNewses`
 	_, span := c.tracer.StartSpan(ctx, funcName)
 	defer span.End()
 
-	return c.redis.Get(ctx, constant.FilterNewses).Val()
+	res, err = c.getNewses(ctx, key)
+	if err == nil || err == errs.ErrCacheMiss {
+		count(tierRedis, err == nil)
+	}
+	return res, err
 }
 
-func (c *cache) SetFilter(ctx context.Context, filter string) error {
-	const funcName = `SetFilter`
-	_, span := c.tracer.StartSpan(ctx, funcName)
-	defer span.End()
-
-	return c.redis.Set(ctx, constant.FilterNewses, filter, 0).Err()
+func (c *cache) getNewses(ctx context.Context, key string) (res *pb.Newses, err error) {
+	value, err := c.redis.Get(ctx, c.key(key)).Bytes()
+	if err == redis.Nil {
+		return res, errs.ErrCacheMiss
+	}
+	if err != nil {
+		return res, err
+	}
+	var newses pb.Newses
+	if err = decode(value, &newses); err != nil {
+		return res, err
+	}
+	return &newses, nil
 }
 
-func (c *cache) UnsetNews(ctx context.Context, id string) error {
-	const funcName = `UnsetNews`
+func (c *cache) SetNewses(ctx context.Context, key string, newses *pb.Newses) error {
+	const funcName = `SetNewses`
 	_, span := c.tracer.StartSpan(ctx, funcName)
 	defer span.End()
 
-	return c.redis.HDel(ctx, constant.News, id).Err()
+	newsesValue, err := c.encode(newses)
+	if err != nil {
+		return err
+	}
+	return c.redis.Set(ctx, c.key(key), newsesValue, constant.NewsesCacheSeconds*time.Second).Err()
 }
 
 func (c *cache) InvalidateNewses(ctx context.Context) error {
@@ -56,33 +68,44 @@ func (c *cache) InvalidateNewses(ctx context.Context) error {
 	_, span := c.tracer.StartSpan(ctx, funcName)
 	defer span.End()
 
-	return c.redis.Set(ctx, constant.ReloadNewses, true, 0).Err()
+	return c.redis.Incr(ctx, c.key(versioned(constant.NewsesGeneration))).Err()
 }
 
-func (c *cache) ReloadRequired(ctx context.Context, filter string) bool {
-	const funcName = `ReloadRequired`
+func (c *cache) GetStaleNewses(ctx context.Context, filter string) (res *pb.Newses, key string, err error) {
+	const funcName = `GetStaleNewses`
 	_, span := c.tracer.StartSpan(ctx, funcName)
 	defer span.End()
 
-	fil := c.redis.Get(ctx, constant.FilterNewses).Val()
-	state := c.redis.Get(ctx, constant.ReloadNewses).Val()
-	return state != "0" || fil != filter
+	values, err := c.redis.HMGet(ctx, c.key(staleNewsesKey(filter)), staleKeyField, staleNewsesField).Result()
+	if err != nil {
+		return res, key, err
+	}
+	key, _ = values[0].(string)
+	newsesValue, ok := values[1].(string)
+	if !ok {
+		return res, key, errs.ErrCacheMiss
+	}
+	var newses pb.Newses
+	if err = decode([]byte(newsesValue), &newses); err != nil {
+		return res, key, err
+	}
+	return &newses, key, nil
 }
 
-func (c *cache) ReloadNewses(ctx context.Context, newses *pb.Newses) (err error) {
-	const funcName = `ReloadNewses`
+func (c *cache) SetStaleNewses(ctx context.Context, filter, key string, newses *pb.Newses) error {
+	const funcName = `SetStaleNewses`
 	_, span := c.tracer.StartSpan(ctx, funcName)
 	defer span.End()
 
-	c.redis.Del(ctx, constant.News)
-	data := make(map[string]interface{})
-	for _, news := range newses.Newses {
-		newsByte, err := json.Marshal(news)
-		if err != nil {
-			return err
-		}
-		data[news.Id] = string(newsByte)
+	newsesValue, err := c.encode(newses)
+	if err != nil {
+		return err
 	}
-	c.redis.Set(ctx, constant.ReloadNewses, false, 0)
-	return c.redis.HMSet(ctx, constant.News, data).Err()
+	staleKey := c.key(staleNewsesKey(filter))
+	_, err = c.redis.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(ctx, staleKey, staleKeyField, key, staleNewsesField, newsesValue)
+		pipe.Expire(ctx, staleKey, constant.StaleNewsesCacheSeconds*time.Second)
+		return nil
+	})
+	return err
 }
@@ -2,12 +2,16 @@ package cache
 
 import (
 	"context"
+	"errors"
+	"testing"
+	"time"
+
 	"github.com/go-redis/redismock/v8"
 	"github.com/muhammadisa/bareksanews/constant"
 	pb "github.com/muhammadisa/bareksanews/protoc/api/v1"
+	"github.com/muhammadisa/bareksanews/repository/errs"
 	"github.com/stretchr/testify/suite"
 	"go.opencensus.io/trace"
-	"testing"
 )
 
 type cacheNewsTestSuite struct {
@@ -18,191 +22,213 @@ func TestCacheNewsTestSuite(t *testing.T) {
 	suite.Run(t, new(cacheNewsTestSuite))
 }
 
-func (ts *cacheNewsTestSuite) TestGetFilter() {
-	db, mock := redismock.NewClientMock()
+func newsesFixture() *pb.Newses {
+	return &pb.Newses{
+		Newses: []*pb.News{
+			{
+				Id:           "9366c83d-4c1e-40ab-93ca-30b9548aebf7",
+				TopicId:      "d95cb090-0906-471a-80ef-3714c6451920",
+				Title:        "title news number 1",
+				Content:      "content news 1",
+				Status:       1,
+				NewsTagIds:   []string{"0f4e1e74-9238-4afb-87c2-108e569ff866", "4e358682-e2b7-4ecf-9e1f-4373bffd661a"},
+				NewsTagNames: []string{"health", "game"},
+				CreatedAt:    1634323641,
+				UpdatedAt:    1634338479,
+			},
+			{
+				Id:        "55e6a5a4-29a6-4d6c-a8c2-4f3d0b0ac3a9",
+				TopicId:   "d95cb090-0906-471a-80ef-3714c6451920",
+				Title:     "title news number 2",
+				Content:   "content news 2",
+				Status:    1,
+				CreatedAt: 1634323600,
+				UpdatedAt: 1634323600,
+			},
+		},
+	}
+}
+
+func (ts *cacheNewsTestSuite) TestNewsesKey() {
 	ctx := context.Background()
-	redisCache := &cache{redis: db, tracer: trace.DefaultTracer}
 
-	mock.ExpectGet(constant.FilterNewses).SetVal("ok")
+	tests := []struct {
+		Name       string
+		Generation string
+		Missing    bool
+		Filter     string
+		Want       string
+	}{
+		{
+			Name:    "generation not set yet",
+			Missing: true,
+			Filter:  "none",
+			Want:    newsesKey(0, "none"),
+		},
+		{
+			Name:       "current generation",
+			Generation: "3",
+			Filter:     "topic_id_status_topic_1_1_page_10_20",
+			Want:       newsesKey(3, "topic_id_status_topic_1_1_page_10_20"),
+		},
+	}
+
+	for _, test := range tests {
+		ts.Run(test.Name, func() {
+			db, mock := redismock.NewClientMock()
+			redisCache := &cache{redis: db, tracer: trace.DefaultTracer}
+
+			if test.Missing {
+				mock.ExpectGet(versioned(constant.NewsesGeneration)).RedisNil()
+			} else {
+				mock.ExpectGet(versioned(constant.NewsesGeneration)).SetVal(test.Generation)
+			}
 
-	data := redisCache.GetFilter(ctx)
-	ts.Assert().Equal("ok", data)
+			key, err := redisCache.NewsesKey(ctx, test.Filter)
+			ts.Assert().NoError(err)
+			ts.Assert().Equal(test.Want, key)
 
-	err := mock.ExpectationsWereMet()
-	ts.Assert().NoError(err)
+			err = mock.ExpectationsWereMet()
+			ts.Assert().NoError(err)
+		})
+	}
 }
 
-func (ts *cacheNewsTestSuite) TestSetFilter() {
+func (ts *cacheNewsTestSuite) TestNewsesKeyFailed() {
 	db, mock := redismock.NewClientMock()
 	ctx := context.Background()
 	redisCache := &cache{redis: db, tracer: trace.DefaultTracer}
 
-	mock.ExpectSet(constant.FilterNewses, "filter_1", 0).SetVal("filter_1")
+	mock.ExpectGet(versioned(constant.NewsesGeneration)).SetErr(errors.New("connection refused"))
 
-	err := redisCache.SetFilter(ctx, "filter_1")
-	ts.Assert().NoError(err)
+	key, err := redisCache.NewsesKey(ctx, "none")
+	ts.Assert().Error(err)
+	ts.Assert().Empty(key)
 
 	err = mock.ExpectationsWereMet()
 	ts.Assert().NoError(err)
 }
 
-func (ts *cacheNewsTestSuite) TestUnsetNews() {
-	db, mock := redismock.NewClientMock()
+func (ts *cacheNewsTestSuite) TestGetNewses() {
 	ctx := context.Background()
-	redisCache := &cache{redis: db, tracer: trace.DefaultTracer}
+	newses := newsesFixture()
+	newsesValue := encoded(newses)
 
-	mock.ExpectHDel(constant.News, "field_1").SetVal(1)
+	tests := []struct {
+		Name      string
+		Value     string
+		Missing   bool
+		WantError error
+	}{
+		{
+			Name:  "get newses hit",
+			Value: newsesValue,
+		},
+		{
+			Name:      "get newses miss",
+			Missing:   true,
+			WantError: errs.ErrCacheMiss,
+		},
+		{
+			Name:      "get newses json left by an older deployment",
+			Value:     `{"newses":[{"id":"9366c83d-4c1e-40ab-93ca-30b9548aebf7"}]}`,
+			WantError: errs.ErrCacheMiss,
+		},
+	}
 
-	err := redisCache.UnsetNews(ctx, "field_1")
-	ts.Assert().NoError(err)
+	for _, test := range tests {
+		ts.Run(test.Name, func() {
+			db, mock := redismock.NewClientMock()
+			redisCache := &cache{redis: db, tracer: trace.DefaultTracer}
 
-	err = mock.ExpectationsWereMet()
-	ts.Assert().NoError(err)
+			if test.Missing {
+				mock.ExpectGet(newsesKey(1, "none")).RedisNil()
+			} else {
+				mock.ExpectGet(newsesKey(1, "none")).SetVal(test.Value)
+			}
+
+			newsesData, err := redisCache.GetNewses(ctx, newsesKey(1, "none"))
+			if test.WantError != nil {
+				ts.Assert().True(errors.Is(err, test.WantError))
+				ts.Assert().Nil(newsesData)
+			} else {
+				ts.Require().NoError(err)
+				ts.Require().Len(newsesData.Newses, 2)
+				// the listing keeps the order it was read from the database
+				ts.Assert().Equal(newses.Newses[0].Id, newsesData.Newses[0].Id)
+				ts.Assert().Equal(newses.Newses[1].Id, newsesData.Newses[1].Id)
+				ts.Assert().Equal(newses.Newses[0].NewsTagNames, newsesData.Newses[0].NewsTagNames)
+			}
+
+			err = mock.ExpectationsWereMet()
+			ts.Assert().NoError(err)
+		})
+	}
 }
 
-func (ts *cacheNewsTestSuite) TestInvalidateNewses() {
+func (ts *cacheNewsTestSuite) TestSetNewses() {
 	db, mock := redismock.NewClientMock()
 	ctx := context.Background()
 	redisCache := &cache{redis: db, tracer: trace.DefaultTracer}
 
-	mock.ExpectSet(constant.ReloadNewses, true, 0).SetVal("true")
+	newses := newsesFixture()
+	newsesValue := encoded(newses)
 
-	err := redisCache.InvalidateNewses(ctx)
+	mock.ExpectSet(newsesKey(1, "status_1"), newsesValue, constant.NewsesCacheSeconds*time.Second).SetVal("OK")
+
+	err := redisCache.SetNewses(ctx, newsesKey(1, "status_1"), newses)
 	ts.Assert().NoError(err)
 
 	err = mock.ExpectationsWereMet()
 	ts.Assert().NoError(err)
 }
 
-func (ts *cacheNewsTestSuite) TestReloadRequired() {
+func (ts *cacheNewsTestSuite) TestInvalidateNewses() {
 	db, mock := redismock.NewClientMock()
 	ctx := context.Background()
 	redisCache := &cache{redis: db, tracer: trace.DefaultTracer}
 
-	mock.ExpectGet(constant.FilterNewses).SetVal("filter_1")
-	mock.ExpectGet(constant.ReloadNewses).SetVal("0")
-
-	reload := redisCache.ReloadRequired(ctx, "filter_1_not_same")
-	ts.Assert().True(reload)
+	mock.ExpectIncr(versioned(constant.NewsesGeneration)).SetVal(2)
+	mock.ExpectGet(versioned(constant.NewsesGeneration)).SetVal("2")
 
-	err := mock.ExpectationsWereMet()
+	err := redisCache.InvalidateNewses(ctx)
 	ts.Assert().NoError(err)
-}
-
-func (ts *cacheTagTestSuite) TestGetNewses() {
-	db, mock := redismock.NewClientMock()
-	ctx := context.Background()
-	redisCache := &cache{redis: db, tracer: trace.DefaultTracer}
 
-	tests := []struct {
-		Name      string
-		Newses    map[string]string
-		WantError bool
-	}{
-		{
-			Name: "get newses success",
-			Newses: map[string]string{
-				"9366c83d-4c1e-40ab-93ca-30b9548aebf7": "{\n  \"id\": \"9366c83d-4c1e-40ab-93ca-30b9548aebf7\",\n  \"topic_id\": \"d95cb090-0906-471a-80ef-3714c6451920\",\n  \"title\": \"title news number 1\",\n  \"content\": \"content news 1\",\n  \"news_tag_ids\": [\n    \"0f4e1e74-9238-4afb-87c2-108e569ff866\",\n    \"4e358682-e2b7-4ecf-9e1f-4373bffd661a\"\n  ],\n  \"news_tag_names\": [\n    \"health\",\n    \"game\"\n  ],\n  \"status\": 1,\n  \"created_at\": 1634323641,\n  \"updated_at\": 1634338479\n}",
-			},
-			WantError: false,
-		},
-		{
-			Name: "get newses failed",
-			Newses: map[string]string{
-				"9366c83d-4c1e-40ab-93ca-30b9548aebf7": "{\n  \"id\": \"9366c83d-4c1e-40ab-93ca-30b9548aebf7\",\n  \"topic_id\": \"d95cb090-0906-471a-80ef-3714c6451920\",\n  \"title\": \"title news number 1\",\n  \"content\": \"content news 1\",\n  \"news_tag_ids\": [\n    \"0f4e1e74-9238-4afb-87c2-108e569ff866\",\n    \"4e358682-e2b7-4ecf-9e1f-4373bffd661a\"\n  ],\n  \"news_tag_names\": [\n    \"health\",\n    \"game\"\n  ],\n  \"status\": 1,\n  \"created_at\": 1634323641,\n  \"updated_at\": 1634338479\n}",
-			},
-			WantError: false,
-		},
-	}
-
-	for _, test := range tests {
-		ts.Run(test.Name, func() {
-			if !test.WantError {
-				mock.ExpectHGetAll(constant.News).SetVal(test.Newses)
-
-				newsesData, err := redisCache.GetNewses(ctx)
-				ts.Assert().NoError(err)
-
-				for _, news := range newsesData.Newses {
-					ts.Assert().Equal(news.Id, "9366c83d-4c1e-40ab-93ca-30b9548aebf7")
-					ts.Assert().Equal(news.TopicId, "d95cb090-0906-471a-80ef-3714c6451920")
-					ts.Assert().Equal(news.Title, "title news number 1")
-					ts.Assert().Equal(news.Content, "content news 1")
-					ts.Assert().Equal(news.Status, int32(1))
-					ts.Assert().Equal(news.CreatedAt, int64(1634323641))
-					ts.Assert().Equal(news.UpdatedAt, int64(1634338479))
-				}
-
-				err = mock.ExpectationsWereMet()
-				ts.Assert().NoError(err)
-			} else {
-				mock.ExpectHGetAll(constant.News).SetVal(test.Newses)
-
-				tagsData, err := redisCache.GetNewses(ctx)
-				ts.Assert().Error(err)
-				ts.Assert().Nil(tagsData)
+	// every listing cached under the previous generation is left behind
+	key, err := redisCache.NewsesKey(ctx, "none")
+	ts.Assert().NoError(err)
+	ts.Assert().Equal(newsesKey(2, "none"), key)
 
-				err = mock.ExpectationsWereMet()
-				ts.Assert().Error(err)
-			}
-		})
-	}
+	err = mock.ExpectationsWereMet()
+	ts.Assert().NoError(err)
 }
 
-func (ts *cacheTagTestSuite) TestReloadNewses() {
+func (ts *cacheNewsTestSuite) TestStaleNewses() {
 	db, mock := redismock.NewClientMock()
 	ctx := context.Background()
 	redisCache := &cache{redis: db, tracer: trace.DefaultTracer}
 
-	tests := []struct {
-		Name      string
-		Newses    *pb.Newses
-		MapNewses map[string]string
-		WantError bool
-	}{
-		{
-			Name: "reload newses success",
-			MapNewses: map[string]string{
-				"9366c83d-4c1e-40ab-93ca-30b9548aebf7": "{\"id\":\"9366c83d-4c1e-40ab-93ca-30b9548aebf7\",\"topic_id\":\"d95cb090-0906-471a-80ef-3714c6451920\",\"title\":\"title news number 1\",\"content\":\"content news 1\",\"news_tag_ids\":[\"0f4e1e74-9238-4afb-87c2-108e569ff866\",\"4e358682-e2b7-4ecf-9e1f-4373bffd661a\"],\"news_tag_names\":[\"health\",\"game\"],\"status\":1,\"created_at\":1634323641,\"updated_at\":1634338479}",
-			},
-			Newses: &pb.Newses{
-				Newses: []*pb.News{
-					{
-						Id:           "9366c83d-4c1e-40ab-93ca-30b9548aebf7",
-						TopicId:      "d95cb090-0906-471a-80ef-3714c6451920",
-						Title:        "title news number 1",
-						Content:      "content news 1",
-						Status:       1,
-						NewsTagIds:   []string{"0f4e1e74-9238-4afb-87c2-108e569ff866", "4e358682-e2b7-4ecf-9e1f-4373bffd661a"},
-						NewsTagNames: []string{"health", "game"},
-						CreatedAt:    1634323641,
-						UpdatedAt:    1634338479,
-					},
-				},
-			},
-			WantError: false,
-		},
-	}
+	newses := newsesFixture()
+	newsesValue := encoded(newses)
 
-	for _, test := range tests {
-		ts.Run(test.Name, func() {
-			if !test.WantError {
-				mock.ExpectHMSet(constant.News, test.MapNewses).SetVal(true)
+	mock.ExpectTxPipeline()
+	mock.ExpectHSet(staleNewsesKey("none"), staleKeyField, newsesKey(1, "none"), staleNewsesField, newsesValue).SetVal(2)
+	mock.ExpectExpire(staleNewsesKey("none"), constant.StaleNewsesCacheSeconds*time.Second).SetVal(true)
+	mock.ExpectTxPipelineExec()
+	mock.ExpectHMGet(staleNewsesKey("none"), staleKeyField, staleNewsesField).SetVal([]interface{}{newsesKey(1, "none"), newsesValue})
+	mock.ExpectHMGet(staleNewsesKey("status_1"), staleKeyField, staleNewsesField).SetVal([]interface{}{nil, nil})
 
-				err := redisCache.ReloadNewses(ctx, test.Newses)
-				ts.Assert().NoError(err)
+	err := redisCache.SetStaleNewses(ctx, "none", newsesKey(1, "none"), newses)
+	ts.Assert().NoError(err)
 
-				err = mock.ExpectationsWereMet()
-				ts.Assert().NoError(err)
-			} else {
-				mock.ExpectHMSet(constant.News, test.MapNewses).RedisNil()
+	stale, key, err := redisCache.GetStaleNewses(ctx, "none")
+	ts.Require().NoError(err)
+	ts.Assert().Len(stale.Newses, 2)
+	ts.Assert().Equal(newsesKey(1, "none"), key)
 
-				err := redisCache.ReloadNewses(ctx, test.Newses)
-				ts.Assert().Error(err)
+	_, _, err = redisCache.GetStaleNewses(ctx, "status_1")
+	ts.Assert().True(errors.Is(err, errs.ErrCacheMiss))
 
-				err = mock.ExpectationsWereMet()
-				ts.Assert().Error(err)
-			}
-		})
-	}
+	err = mock.ExpectationsWereMet()
+	ts.Assert().NoError(err)
 }
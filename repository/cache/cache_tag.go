@@ -2,7 +2,6 @@ package cache
 
 import (
 	"context"
-	"encoding/json"
 
 	"github.com/muhammadisa/bareksanews/constant"
 	pb "github.com/muhammadisa/bareksanews/protoc/api/v1"
@@ -15,13 +14,14 @@ func (c *cache) ReloadTags(ctx context.Context, tags *pb.Tags) (err error) {
 
 	data := make(map[string]interface{})
 	for _, tag := range tags.Tags {
-		tagByte, err := json.Marshal(tag)
+		tagValue, err := c.encode(tag)
 		if err != nil {
 			return err
 		}
-		data[tag.Id] = string(tagByte)
+		data[tag.Id] = tagValue
 	}
-	return c.redis.HMSet(ctx, constant.Tags, data).Err()
+	data[completeField] = "1"
+	return c.redis.HMSet(ctx, c.key(versioned(constant.Tags)), data).Err()
 }
 
 func (c *cache) UnsetTag(ctx context.Context, id string) (err error) {
@@ -29,7 +29,7 @@ func (c *cache) UnsetTag(ctx context.Context, id string) (err error) {
 	_, span := c.tracer.StartSpan(ctx, funcName)
 	defer span.End()
 
-	return c.redis.HDel(ctx, constant.Tags, id).Err()
+	return c.redis.HDel(ctx, c.key(versioned(constant.Tags)), id).Err()
 }
 
 func (c *cache) SetTag(ctx context.Context, tag *pb.Tag) (err error) {
@@ -37,11 +37,11 @@ func (c *cache) SetTag(ctx context.Context, tag *pb.Tag) (err error) {
 	_, span := c.tracer.StartSpan(ctx, funcName)
 	defer span.End()
 
-	tagByte, err := json.Marshal(tag)
+	tagValue, err := c.encode(tag)
 	if err != nil {
 		return err
 	}
-	return c.redis.HSetNX(ctx, constant.Tags, tag.Id, string(tagByte)).Err()
+	return c.redis.HSetNX(ctx, c.key(versioned(constant.Tags)), tag.Id, tagValue).Err()
 }
 
 func (c *cache) GetTags(ctx context.Context) (res *pb.Tags, err error) {
@@ -50,14 +50,24 @@ func (c *cache) GetTags(ctx context.Context) (res *pb.Tags, err error) {
 	defer span.End()
 
 	var tags pb.Tags
-	tagsMap := c.redis.HGetAll(ctx, constant.Tags).Val()
+	tagsMap, err := c.redis.HGetAll(ctx, c.key(versioned(constant.Tags))).Result()
+	if err != nil {
+		return res, err
+	}
+	if _, ok := tagsMap[completeField]; !ok {
+		count(tierRedis, false)
+		return &tags, nil
+	}
+	delete(tagsMap, completeField)
 	for _, v := range tagsMap {
 		var tag pb.Tag
-		err = json.Unmarshal([]byte(v), &tag)
-		if err != nil {
-			return res, err
+		if err = decode([]byte(v), &tag); err != nil {
+			// a value left by an older deployment reloads the whole hash
+			count(tierRedis, false)
+			return &pb.Tags{}, nil
 		}
 		tags.Tags = append(tags.Tags, &tag)
 	}
+	count(tierRedis, len(tags.Tags) > 0)
 	return &tags, nil
 }
@@ -2,7 +2,6 @@ package cache
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
 	"github.com/go-redis/redismock/v8"
 	"github.com/muhammadisa/bareksanews/constant"
@@ -36,7 +35,8 @@ func (ts *cacheTagTestSuite) TestReloadTags() {
 		{
 			Name: "reload tags success",
 			MapTags: map[string]string{
-				"c63b17cc-e227-4947-a01f-74f429ce99be": "{\"id\":\"c63b17cc-e227-4947-a01f-74f429ce99be\",\"tag\":\"tech\",\"created_at\":1634304927,\"updated_at\":1634304948}",
+				"c63b17cc-e227-4947-a01f-74f429ce99be": encoded(&pb.Tag{Id: "c63b17cc-e227-4947-a01f-74f429ce99be", Tag: "tech", CreatedAt: 1634304927, UpdatedAt: 1634304948}),
+				completeField:                          "1",
 			},
 			Tags: &pb.Tags{
 				Tags: []*pb.Tag{
@@ -55,7 +55,7 @@ func (ts *cacheTagTestSuite) TestReloadTags() {
 	for _, test := range tests {
 		ts.Run(test.Name, func() {
 			if !test.WantError {
-				mock.ExpectHMSet(constant.Tags, test.MapTags).SetVal(true)
+				mock.ExpectHMSet(versioned(constant.Tags), test.MapTags).SetVal(true)
 
 				err := redisCache.ReloadTags(ctx, test.Tags)
 				ts.Assert().NoError(err)
@@ -63,7 +63,7 @@ func (ts *cacheTagTestSuite) TestReloadTags() {
 				err = mock.ExpectationsWereMet()
 				ts.Assert().NoError(err)
 			} else {
-				mock.ExpectHMSet(constant.Tags, test.MapTags).RedisNil()
+				mock.ExpectHMSet(versioned(constant.Tags), test.MapTags).RedisNil()
 
 				err := redisCache.ReloadTags(ctx, test.Tags)
 				ts.Assert().Error(err)
@@ -83,19 +83,30 @@ func (ts *cacheTagTestSuite) TestGetTags() {
 	tests := []struct {
 		Name      string
 		Tags      map[string]string
+		Len       int
 		WantError bool
 	}{
 		{
 			Name: "get tags success",
 			Tags: map[string]string{
-				"c63b17cc-e227-4947-a01f-74f429ce99be": "{\n  \"id\": \"c63b17cc-e227-4947-a01f-74f429ce99be\",\n  \"tag\": \"tech\",\n  \"created_at\": 1634304927,\n  \"updated_at\": 1634304948\n}",
+				"c63b17cc-e227-4947-a01f-74f429ce99be": encoded(&pb.Tag{Id: "c63b17cc-e227-4947-a01f-74f429ce99be", Tag: "tech", CreatedAt: 1634304927, UpdatedAt: 1634304948}),
+				completeField:                          "1",
+			},
+			Len:       1,
+			WantError: false,
+		},
+		{
+			Name: "get tags recreated by a single write",
+			Tags: map[string]string{
+				"c63b17cc-e227-4947-a01f-74f429ce99be": encoded(&pb.Tag{Id: "c63b17cc-e227-4947-a01f-74f429ce99be", Tag: "tech"}),
 			},
 			WantError: false,
 		},
 		{
-			Name: "get tags failed",
+			Name: "get tags left by an older deployment",
 			Tags: map[string]string{
 				"c63b17cc-e227-4947-a01f-74f429ce99be": "{\n  \"id\": \"c63b17cc-e227-4947-a01f-74f429ce99be\",\n  \"tag\": \"tech\",\n  \"created_at\": 1634304927,\n  \"updated_at\": 1634304948\n}",
+				completeField:                          "1",
 			},
 			WantError: false,
 		},
@@ -104,10 +115,13 @@ func (ts *cacheTagTestSuite) TestGetTags() {
 	for _, test := range tests {
 		ts.Run(test.Name, func() {
 			if !test.WantError {
-				mock.ExpectHGetAll(constant.Tags).SetVal(test.Tags)
+				mock.ExpectHGetAll(versioned(constant.Tags)).SetVal(test.Tags)
 
 				tagsData, err := redisCache.GetTags(ctx)
 				ts.Assert().NoError(err)
+				// a partial hash or a value left by an older deployment reads as an
+				// empty hash
+				ts.Assert().Len(tagsData.Tags, test.Len)
 
 				for _, tag := range tagsData.Tags {
 					ts.Assert().Equal(tag.Id, "c63b17cc-e227-4947-a01f-74f429ce99be")
@@ -117,7 +131,7 @@ func (ts *cacheTagTestSuite) TestGetTags() {
 				err = mock.ExpectationsWereMet()
 				ts.Assert().NoError(err)
 			} else {
-				mock.ExpectHGetAll(constant.Tags).SetVal(test.Tags)
+				mock.ExpectHGetAll(versioned(constant.Tags)).SetVal(test.Tags)
 
 				tagsData, err := redisCache.GetTags(ctx)
 				ts.Assert().Error(err)
@@ -155,7 +169,7 @@ func (ts *cacheTagTestSuite) TestUnsetTag() {
 	for _, test := range tests {
 		ts.Run(test.Name, func() {
 			if !test.WantError {
-				mock.ExpectHDel(constant.Tags, test.Id).SetVal(1)
+				mock.ExpectHDel(versioned(constant.Tags), test.Id).SetVal(1)
 
 				err := redisCache.UnsetTag(ctx, test.Id)
 				ts.Assert().NoError(err)
@@ -163,7 +177,7 @@ func (ts *cacheTagTestSuite) TestUnsetTag() {
 				err = mock.ExpectationsWereMet()
 				ts.Assert().NoError(err)
 			} else {
-				mock.ExpectHDel(constant.Tags, test.Id).SetErr(errors.New("dummy"))
+				mock.ExpectHDel(versioned(constant.Tags), test.Id).SetErr(errors.New("dummy"))
 
 				err := redisCache.UnsetTag(ctx, test.Id)
 				ts.Assert().Error(err)
@@ -214,10 +228,10 @@ func (ts *cacheTagTestSuite) TestSetTag() {
 	for _, test := range tests {
 		ts.Run(test.Name, func() {
 			if !test.WantError {
-				tagByte, err := json.Marshal(test.Tag)
+				tagValue, err := redisCache.encode(test.Tag)
 				ts.Assert().NoError(err)
 
-				mock.ExpectHSetNX(constant.Tags, test.Tag.Id, string(tagByte)).SetVal(true)
+				mock.ExpectHSetNX(versioned(constant.Tags), test.Tag.Id, tagValue).SetVal(true)
 
 				err = redisCache.SetTag(ctx, test.Tag)
 				ts.Assert().NoError(err)
@@ -225,13 +239,13 @@ func (ts *cacheTagTestSuite) TestSetTag() {
 				err = mock.ExpectationsWereMet()
 				ts.Assert().NoError(err)
 			} else {
-				tagByte, err := json.Marshal(test.Tag)
+				tagValue, err := redisCache.encode(test.Tag)
 				ts.Assert().NoError(err)
 
 				if !test.MarshalError {
-					mock.ExpectHSetNX(constant.Tags, test.Tag.Id, string(tagByte)).SetVal(false)
+					mock.ExpectHSetNX(versioned(constant.Tags), test.Tag.Id, tagValue).SetVal(false)
 				} else {
-					mock.ExpectHSetNX(constant.Tags, test.Tag.Id, "").SetVal(false)
+					mock.ExpectHSetNX(versioned(constant.Tags), test.Tag.Id, "").SetVal(false)
 				}
 
 				err = redisCache.SetTag(ctx, test.Tag)
@@ -2,7 +2,6 @@ package cache
 
 import (
 	"context"
-	"encoding/json"
 
 	"github.com/muhammadisa/bareksanews/constant"
 	pb "github.com/muhammadisa/bareksanews/protoc/api/v1"
@@ -15,13 +14,14 @@ func (c *cache) ReloadTopics(ctx context.Context, topics *pb.Topics) (err error)
 
 	data := make(map[string]interface{})
 	for _, topic := range topics.Topics {
-		topicByte, err := json.Marshal(topic)
+		topicValue, err := c.encode(topic)
 		if err != nil {
 			return err
 		}
-		data[topic.Id] = string(topicByte)
+		data[topic.Id] = topicValue
 	}
-	return c.redis.HMSet(ctx, constant.Topics, data).Err()
+	data[completeField] = "1"
+	return c.redis.HMSet(ctx, c.key(versioned(constant.Topics)), data).Err()
 }
 
 func (c *cache) GetTopics(ctx context.Context) (res *pb.Topics, err error) {
@@ -30,15 +30,25 @@ func (c *cache) GetTopics(ctx context.Context) (res *pb.Topics, err error) {
 	defer span.End()
 
 	var topics pb.Topics
-	topicsMap := c.redis.HGetAll(ctx, constant.Topics).Val()
+	topicsMap, err := c.redis.HGetAll(ctx, c.key(versioned(constant.Topics))).Result()
+	if err != nil {
+		return res, err
+	}
+	if _, ok := topicsMap[completeField]; !ok {
+		count(tierRedis, false)
+		return &topics, nil
+	}
+	delete(topicsMap, completeField)
 	for _, v := range topicsMap {
 		var topic pb.Topic
-		err = json.Unmarshal([]byte(v), &topic)
-		if err != nil {
-			return res, err
+		if err = decode([]byte(v), &topic); err != nil {
+			// a value left by an older deployment reloads the whole hash
+			count(tierRedis, false)
+			return &pb.Topics{}, nil
 		}
 		topics.Topics = append(topics.Topics, &topic)
 	}
+	count(tierRedis, len(topics.Topics) > 0)
 	return &topics, nil
 }
 
@@ -47,7 +57,7 @@ func (c *cache) UnsetTopic(ctx context.Context, id string) (err error) {
 	_, span := c.tracer.StartSpan(ctx, funcName)
 	defer span.End()
 
-	return c.redis.HDel(ctx, constant.Topics, id).Err()
+	return c.redis.HDel(ctx, c.key(versioned(constant.Topics)), id).Err()
 }
 
 func (c *cache) SetTopic(ctx context.Context, topic *pb.Topic) (err error) {
@@ -55,9 +65,9 @@ func (c *cache) SetTopic(ctx context.Context, topic *pb.Topic) (err error) {
 	_, span := c.tracer.StartSpan(ctx, funcName)
 	defer span.End()
 
-	topicByte, err := json.Marshal(topic)
+	topicValue, err := c.encode(topic)
 	if err != nil {
 		return err
 	}
-	return c.redis.HSetNX(ctx, constant.Topics, topic.Id, string(topicByte)).Err()
+	return c.redis.HSetNX(ctx, c.key(versioned(constant.Topics)), topic.Id, topicValue).Err()
 }
@@ -2,7 +2,6 @@ package cache
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
 	"github.com/go-redis/redismock/v8"
 	"github.com/muhammadisa/bareksanews/constant"
@@ -36,7 +35,8 @@ func (ts *cacheTopicTestSuite) TestReloadTopics() {
 		{
 			Name: "reload topics success",
 			MapTopics: map[string]string{
-				"c63b17cc-e227-4947-a01f-74f429ce99be": "{\"id\":\"c63b17cc-e227-4947-a01f-74f429ce99be\",\"title\":\"health\",\"headline\":\"this is headline\",\"created_at\":1634304927,\"updated_at\":1634304948}",
+				"c63b17cc-e227-4947-a01f-74f429ce99be": encoded(&pb.Topic{Id: "c63b17cc-e227-4947-a01f-74f429ce99be", Title: "health", Headline: "this is headline", CreatedAt: 1634304927, UpdatedAt: 1634304948}),
+				completeField:                          "1",
 			},
 			Topics: &pb.Topics{
 				Topics: []*pb.Topic{
@@ -56,7 +56,7 @@ func (ts *cacheTopicTestSuite) TestReloadTopics() {
 	for _, test := range tests {
 		ts.Run(test.Name, func() {
 			if !test.WantError {
-				mock.ExpectHMSet(constant.Topics, test.MapTopics).SetVal(true)
+				mock.ExpectHMSet(versioned(constant.Topics), test.MapTopics).SetVal(true)
 
 				err := redisCache.ReloadTopics(ctx, test.Topics)
 				ts.Assert().NoError(err)
@@ -64,7 +64,7 @@ func (ts *cacheTopicTestSuite) TestReloadTopics() {
 				err = mock.ExpectationsWereMet()
 				ts.Assert().NoError(err)
 			} else {
-				mock.ExpectHMSet(constant.Topics, test.MapTopics).RedisNil()
+				mock.ExpectHMSet(versioned(constant.Topics), test.MapTopics).RedisNil()
 
 				err := redisCache.ReloadTopics(ctx, test.Topics)
 				ts.Assert().Error(err)
@@ -84,19 +84,30 @@ func (ts *cacheTopicTestSuite) TestGetTopics() {
 	tests := []struct {
 		Name      string
 		Topics    map[string]string
+		Len       int
 		WantError bool
 	}{
 		{
 			Name: "get topics success",
 			Topics: map[string]string{
-				"c63b17cc-e227-4947-a01f-74f429ce99be": "{\n  \"id\": \"c63b17cc-e227-4947-a01f-74f429ce99be\",\n  \"title\": \"tech\",\n \"headline\": \"this is headline\",\n  \"created_at\": 1634304927,\n  \"updated_at\": 1634304948\n}",
+				"c63b17cc-e227-4947-a01f-74f429ce99be": encoded(&pb.Topic{Id: "c63b17cc-e227-4947-a01f-74f429ce99be", Title: "tech", Headline: "this is headline", CreatedAt: 1634304927, UpdatedAt: 1634304948}),
+				completeField:                          "1",
+			},
+			Len:       1,
+			WantError: false,
+		},
+		{
+			Name: "get topics recreated by a single write",
+			Topics: map[string]string{
+				"c63b17cc-e227-4947-a01f-74f429ce99be": encoded(&pb.Topic{Id: "c63b17cc-e227-4947-a01f-74f429ce99be", Title: "tech", Headline: "this is headline"}),
 			},
 			WantError: false,
 		},
 		{
-			Name: "get topics failed",
+			Name: "get topics left by an older deployment",
 			Topics: map[string]string{
 				"c63b17cc-e227-4947-a01f-74f429ce99be": "{\n  \"id\": \"c63b17cc-e227-4947-a01f-74f429ce99be\",\n  \"title\": \"tech\",\n \"headline\": \"this is headline\",\n  \"created_at\": 1634304927,\n  \"updated_at\": 1634304948\n}",
+				completeField:                          "1",
 			},
 			WantError: false,
 		},
@@ -105,10 +116,13 @@ func (ts *cacheTopicTestSuite) TestGetTopics() {
 	for _, test := range tests {
 		ts.Run(test.Name, func() {
 			if !test.WantError {
-				mock.ExpectHGetAll(constant.Topics).SetVal(test.Topics)
+				mock.ExpectHGetAll(versioned(constant.Topics)).SetVal(test.Topics)
 
 				topicsData, err := redisCache.GetTopics(ctx)
 				ts.Assert().NoError(err)
+				// a partial hash or a value left by an older deployment reads as an
+				// empty hash
+				ts.Assert().Len(topicsData.Topics, test.Len)
 
 				for _, topic := range topicsData.Topics {
 					ts.Assert().Equal(topic.Id, "c63b17cc-e227-4947-a01f-74f429ce99be")
@@ -119,7 +133,7 @@ func (ts *cacheTopicTestSuite) TestGetTopics() {
 				err = mock.ExpectationsWereMet()
 				ts.Assert().NoError(err)
 			} else {
-				mock.ExpectHGetAll(constant.Topics).SetVal(test.Topics)
+				mock.ExpectHGetAll(versioned(constant.Topics)).SetVal(test.Topics)
 
 				topicsData, err := redisCache.GetTopics(ctx)
 				ts.Assert().Error(err)
@@ -157,7 +171,7 @@ func (ts *cacheTopicTestSuite) TestUnsetTopic() {
 	for _, test := range tests {
 		ts.Run(test.Name, func() {
 			if !test.WantError {
-				mock.ExpectHDel(constant.Topics, test.Id).SetVal(1)
+				mock.ExpectHDel(versioned(constant.Topics), test.Id).SetVal(1)
 
 				err := redisCache.UnsetTopic(ctx, test.Id)
 				ts.Assert().NoError(err)
@@ -165,7 +179,7 @@ func (ts *cacheTopicTestSuite) TestUnsetTopic() {
 				err = mock.ExpectationsWereMet()
 				ts.Assert().NoError(err)
 			} else {
-				mock.ExpectHDel(constant.Topics, test.Id).SetErr(errors.New("dummy"))
+				mock.ExpectHDel(versioned(constant.Topics), test.Id).SetErr(errors.New("dummy"))
 
 				err := redisCache.UnsetTopic(ctx, test.Id)
 				ts.Assert().Error(err)
@@ -218,10 +232,10 @@ func (ts *cacheTopicTestSuite) TestSetTopic() {
 	for _, test := range tests {
 		ts.Run(test.Name, func() {
 			if !test.WantError {
-				topicByte, err := json.Marshal(test.Topic)
+				topicValue, err := redisCache.encode(test.Topic)
 				ts.Assert().NoError(err)
 
-				mock.ExpectHSetNX(constant.Topics, test.Topic.Id, string(topicByte)).SetVal(true)
+				mock.ExpectHSetNX(versioned(constant.Topics), test.Topic.Id, topicValue).SetVal(true)
 
 				err = redisCache.SetTopic(ctx, test.Topic)
 				ts.Assert().NoError(err)
@@ -229,13 +243,13 @@ func (ts *cacheTopicTestSuite) TestSetTopic() {
 				err = mock.ExpectationsWereMet()
 				ts.Assert().NoError(err)
 			} else {
-				topicByte, err := json.Marshal(test.Topic)
+				topicValue, err := redisCache.encode(test.Topic)
 				ts.Assert().NoError(err)
 
 				if !test.MarshalError {
-					mock.ExpectHSetNX(constant.Topics, test.Topic.Id, string(topicByte)).SetVal(false)
+					mock.ExpectHSetNX(versioned(constant.Topics), test.Topic.Id, topicValue).SetVal(false)
 				} else {
-					mock.ExpectHSetNX(constant.Topics, test.Topic.Id, "").SetVal(false)
+					mock.ExpectHSetNX(versioned(constant.Topics), test.Topic.Id, "").SetVal(false)
 				}
 
 				err = redisCache.SetTopic(ctx, test.Topic)
@@ -0,0 +1,90 @@
+package cache
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/golang/snappy"
+	"github.com/muhammadisa/bareksanews/constant"
+	"github.com/muhammadisa/bareksanews/repository/errs"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	// formatProto value holding a marshaled proto message
+	formatProto byte = 1
+
+	// formatSnappy value holding a snappy compressed proto message
+	formatSnappy byte = 2
+)
+
+// versioned join parts into a redis key under constant.CacheSchemaVersion, a
+// deploy bumping the version never reads the values of the previous one
+func versioned(parts ...string) string {
+	return strings.Join(append([]string{constant.CacheSchemaVersion}, parts...), ":")
+}
+
+// hashTag keep the keys holding the same tag in one redis cluster slot
+func hashTag(s string) string {
+	return "{" + s + "}"
+}
+
+// newsesKey the listing of filter under generation, the listing, the stale
+// listing of the same filter and their locks share one cluster slot
+func newsesKey(generation int64, filter string) string {
+	return versioned(constant.Newses, strconv.FormatInt(generation, 10), hashTag(filter))
+}
+
+func staleNewsesKey(filter string) string {
+	return versioned(constant.StaleNewses, hashTag(filter))
+}
+
+const (
+	// staleKeyField the field of the stale listing holding its newses key
+	staleKeyField = "key"
+
+	// staleNewsesField the field of the stale listing holding the newses
+	staleNewsesField = "newses"
+
+	// completeField the field only a reload writes into the tags and topics
+	// hashes, a hash recreated by a single write after it was dropped lacks
+	// it and is read as a miss
+	completeField = "complete"
+)
+
+// encode marshal msg behind a format byte, values of at least compressAbove
+// bytes are compressed unless compressAbove is zero
+func (c *cache) encode(msg proto.Message) (string, error) {
+	raw, err := proto.Marshal(msg)
+	if err != nil {
+		return "", err
+	}
+	if c.compressAbove > 0 && len(raw) >= c.compressAbove {
+		return string(append([]byte{formatSnappy}, snappy.Encode(nil, raw)...)), nil
+	}
+	return string(append([]byte{formatProto}, raw...)), nil
+}
+
+// decode report errs.ErrCacheMiss for a value it can not read, such as the
+// json written by older deployments, the caller reloads it
+func decode(value []byte, msg proto.Message) error {
+	if len(value) == 0 {
+		return errs.ErrCacheMiss
+	}
+	raw := value[1:]
+	switch value[0] {
+	case formatProto:
+	case formatSnappy:
+		var err error
+		raw, err = snappy.Decode(nil, raw)
+		if err != nil {
+			return errs.ErrCacheMiss
+		}
+	default:
+		return errs.ErrCacheMiss
+	}
+	if err := proto.Unmarshal(raw, msg); err != nil {
+		return errs.ErrCacheMiss
+	}
+	return nil
+}
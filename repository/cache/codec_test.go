@@ -0,0 +1,122 @@
+package cache
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/muhammadisa/bareksanews/constant"
+	pb "github.com/muhammadisa/bareksanews/protoc/api/v1"
+	"github.com/muhammadisa/bareksanews/repository/errs"
+	"github.com/stretchr/testify/suite"
+	"google.golang.org/protobuf/proto"
+)
+
+// encoded msg as it is stored without compression
+func encoded(msg proto.Message) string {
+	value, err := new(cache).encode(msg)
+	if err != nil {
+		panic(err)
+	}
+	return value
+}
+
+type codecTestSuite struct {
+	suite.Suite
+}
+
+func TestCodecTestSuite(t *testing.T) {
+	suite.Run(t, new(codecTestSuite))
+}
+
+func (ts *codecTestSuite) TestRoundTrip() {
+	article := newsesFixture()
+	article.Newses[0].Content = strings.Repeat("a long article content. ", 200)
+
+	tests := []struct {
+		Name          string
+		CompressAbove int
+		Format        byte
+	}{
+		{
+			Name:   "compression disabled",
+			Format: formatProto,
+		},
+		{
+			Name:          "small value left uncompressed",
+			CompressAbove: 1 << 20,
+			Format:        formatProto,
+		},
+		{
+			Name:          "large value compressed",
+			CompressAbove: 1024,
+			Format:        formatSnappy,
+		},
+	}
+
+	for _, test := range tests {
+		ts.Run(test.Name, func() {
+			value, err := (&cache{compressAbove: test.CompressAbove}).encode(article)
+			ts.Require().NoError(err)
+			ts.Assert().Equal(test.Format, value[0])
+
+			var newses pb.Newses
+			err = decode([]byte(value), &newses)
+			ts.Require().NoError(err)
+			ts.Assert().True(proto.Equal(article, &newses))
+		})
+	}
+}
+
+func (ts *codecTestSuite) TestCompressedSmaller() {
+	article := newsesFixture()
+	article.Newses[0].Content = strings.Repeat("a long article content. ", 200)
+
+	plain, err := new(cache).encode(article)
+	ts.Require().NoError(err)
+	compressed, err := (&cache{compressAbove: 1024}).encode(article)
+	ts.Require().NoError(err)
+	ts.Assert().Less(len(compressed), len(plain))
+}
+
+func (ts *codecTestSuite) TestDecodeUnreadable() {
+	tests := []struct {
+		Name  string
+		Value []byte
+	}{
+		{
+			Name:  "empty value",
+			Value: nil,
+		},
+		{
+			Name:  "json of an older deployment",
+			Value: []byte(`{"newses":[{"id":"9366c83d-4c1e-40ab-93ca-30b9548aebf7"}]}`),
+		},
+		{
+			Name:  "corrupted compressed value",
+			Value: append([]byte{formatSnappy}, bytes.Repeat([]byte{0xff}, 8)...),
+		},
+		{
+			Name:  "corrupted proto value",
+			Value: append([]byte{formatProto}, 0xff, 0xff),
+		},
+	}
+
+	for _, test := range tests {
+		ts.Run(test.Name, func() {
+			var newses pb.Newses
+			err := decode(test.Value, &newses)
+			ts.Assert().True(errors.Is(err, errs.ErrCacheMiss))
+		})
+	}
+}
+
+func (ts *codecTestSuite) TestKeys() {
+	// the braces are the redis cluster hash tag, keys of one filter share
+	// a slot and every filter gets its own
+	ts.Assert().Equal("v1:newses:3:{status_1}", newsesKey(3, "status_1"))
+	ts.Assert().Equal("v1:stale_listing:{status_1}", staleNewsesKey("status_1"))
+	ts.Assert().Equal("lock:v1:newses:3:{status_1}", lockKey(newsesKey(3, "status_1")))
+	ts.Assert().Equal("v1:tags", versioned(constant.Tags))
+}
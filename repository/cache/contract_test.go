@@ -0,0 +1,308 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+	"github.com/muhammadisa/bareksanews/constant"
+	pb "github.com/muhammadisa/bareksanews/protoc/api/v1"
+	"github.com/muhammadisa/bareksanews/repository/errs"
+	_interface "github.com/muhammadisa/bareksanews/repository/interface"
+	"github.com/stretchr/testify/suite"
+	"go.opencensus.io/trace"
+	"google.golang.org/protobuf/proto"
+)
+
+// cacheContractTestSuite the behaviour every _interface.Cache implementation
+// shares, open returns a fresh cache and moves its clock forward
+type cacheContractTestSuite struct {
+	suite.Suite
+	open func() (cache _interface.Cache, forward func(time.Duration), close func())
+
+	cache   _interface.Cache
+	forward func(time.Duration)
+	close   func()
+}
+
+func TestRedisCacheContract(t *testing.T) {
+	suite.Run(t, &cacheContractTestSuite{open: func() (_interface.Cache, func(time.Duration), func()) {
+		server, err := miniredis.Run()
+		if err != nil {
+			t.Fatal(err)
+		}
+		client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+		return &cache{redis: client, tracer: trace.DefaultTracer, prefix: "bareksa_news:test:"}, server.FastForward, func() {
+			_ = client.Close()
+			server.Close()
+		}
+	}})
+}
+
+func TestMemoryCacheContract(t *testing.T) {
+	suite.Run(t, &cacheContractTestSuite{open: func() (_interface.Cache, func(time.Duration), func()) {
+		var mu sync.Mutex
+		now := time.Unix(1634323641, 0)
+		memory := newMemoryCache(trace.DefaultTracer, func() time.Time {
+			mu.Lock()
+			defer mu.Unlock()
+			return now
+		})
+		return memory, func(d time.Duration) {
+			mu.Lock()
+			defer mu.Unlock()
+			now = now.Add(d)
+		}, func() {}
+	}})
+}
+
+func (ts *cacheContractTestSuite) SetupTest() {
+	ts.cache, ts.forward, ts.close = ts.open()
+}
+
+func (ts *cacheContractTestSuite) TearDownTest() {
+	ts.close()
+}
+
+func (ts *cacheContractTestSuite) TestTags() {
+	ctx := context.Background()
+
+	tags, err := ts.cache.GetTags(ctx)
+	ts.Require().NoError(err)
+	ts.Assert().Empty(tags.Tags)
+
+	// a tag written before the tags are reloaded leaves them missing
+	tech := &pb.Tag{Id: "c63b17cc-e227-4947-a01f-74f429ce99be", Tag: "tech", CreatedAt: 1634304927}
+	ts.Require().NoError(ts.cache.SetTag(ctx, tech))
+	tags, err = ts.cache.GetTags(ctx)
+	ts.Require().NoError(err)
+	ts.Assert().Empty(tags.Tags)
+
+	// a cached tag is kept until it is unset
+	ts.Require().NoError(ts.cache.ReloadTags(ctx, &pb.Tags{}))
+	ts.Require().NoError(ts.cache.SetTag(ctx, &pb.Tag{Id: tech.Id, Tag: "technology"}))
+	tags, err = ts.cache.GetTags(ctx)
+	ts.Require().NoError(err)
+	ts.Require().Len(tags.Tags, 1)
+	ts.Assert().True(proto.Equal(tech, tags.Tags[0]))
+
+	// reloading overwrites the reloaded tags and keeps the others
+	health := &pb.Tag{Id: "0f4e1e74-9238-4afb-87c2-108e569ff866", Tag: "health"}
+	ts.Require().NoError(ts.cache.ReloadTags(ctx, &pb.Tags{Tags: []*pb.Tag{{Id: tech.Id, Tag: "technology"}, health}}))
+	tags, err = ts.cache.GetTags(ctx)
+	ts.Require().NoError(err)
+	names := make([]string, 0, len(tags.Tags))
+	for _, tag := range tags.Tags {
+		names = append(names, tag.Tag)
+	}
+	sort.Strings(names)
+	ts.Assert().Equal([]string{"health", "technology"}, names)
+
+	ts.Require().NoError(ts.cache.UnsetTag(ctx, tech.Id))
+	ts.Require().NoError(ts.cache.UnsetTag(ctx, "missing"))
+	tags, err = ts.cache.GetTags(ctx)
+	ts.Require().NoError(err)
+	ts.Require().Len(tags.Tags, 1)
+	ts.Assert().Equal(health.Id, tags.Tags[0].Id)
+}
+
+func (ts *cacheContractTestSuite) TestTopics() {
+	ctx := context.Background()
+
+	topics, err := ts.cache.GetTopics(ctx)
+	ts.Require().NoError(err)
+	ts.Assert().Empty(topics.Topics)
+
+	topic := &pb.Topic{Id: "d95cb090-0906-471a-80ef-3714c6451920", Title: "health", Headline: "this is headline"}
+	ts.Require().NoError(ts.cache.SetTopic(ctx, topic))
+	topics, err = ts.cache.GetTopics(ctx)
+	ts.Require().NoError(err)
+	ts.Assert().Empty(topics.Topics)
+
+	ts.Require().NoError(ts.cache.ReloadTopics(ctx, &pb.Topics{}))
+	ts.Require().NoError(ts.cache.SetTopic(ctx, &pb.Topic{Id: topic.Id, Title: "wealth"}))
+	topics, err = ts.cache.GetTopics(ctx)
+	ts.Require().NoError(err)
+	ts.Require().Len(topics.Topics, 1)
+	ts.Assert().True(proto.Equal(topic, topics.Topics[0]))
+
+	ts.Require().NoError(ts.cache.ReloadTopics(ctx, &pb.Topics{Topics: []*pb.Topic{{Id: topic.Id, Title: "wealth"}}}))
+	topics, err = ts.cache.GetTopics(ctx)
+	ts.Require().NoError(err)
+	ts.Require().Len(topics.Topics, 1)
+	ts.Assert().Equal("wealth", topics.Topics[0].Title)
+
+	ts.Require().NoError(ts.cache.UnsetTopic(ctx, topic.Id))
+	topics, err = ts.cache.GetTopics(ctx)
+	ts.Require().NoError(err)
+	ts.Assert().Empty(topics.Topics)
+}
+
+func (ts *cacheContractTestSuite) TestNewses() {
+	ctx := context.Background()
+	newses := newsesFixture()
+
+	key, err := ts.cache.NewsesKey(ctx, "status_1")
+	ts.Require().NoError(err)
+	_, err = ts.cache.GetNewses(ctx, key)
+	ts.Assert().True(errors.Is(err, errs.ErrCacheMiss))
+
+	ts.Require().NoError(ts.cache.SetNewses(ctx, key, newses))
+	cached, err := ts.cache.GetNewses(ctx, key)
+	ts.Require().NoError(err)
+	ts.Assert().True(proto.Equal(newses, cached))
+
+	// every filter has its own key
+	other, err := ts.cache.NewsesKey(ctx, "none")
+	ts.Require().NoError(err)
+	ts.Assert().NotEqual(key, other)
+	_, err = ts.cache.GetNewses(ctx, other)
+	ts.Assert().True(errors.Is(err, errs.ErrCacheMiss))
+
+	// invalidating moves every filter to a new key
+	ts.Require().NoError(ts.cache.InvalidateNewses(ctx))
+	next, err := ts.cache.NewsesKey(ctx, "status_1")
+	ts.Require().NoError(err)
+	ts.Assert().NotEqual(key, next)
+	_, err = ts.cache.GetNewses(ctx, next)
+	ts.Assert().True(errors.Is(err, errs.ErrCacheMiss))
+
+	ts.Require().NoError(ts.cache.SetNewses(ctx, next, newses))
+	ts.forward(constant.NewsesCacheSeconds * time.Second)
+	_, err = ts.cache.GetNewses(ctx, next)
+	ts.Assert().True(errors.Is(err, errs.ErrCacheMiss))
+}
+
+func (ts *cacheContractTestSuite) TestStaleNewses() {
+	ctx := context.Background()
+	newses := newsesFixture()
+
+	_, _, err := ts.cache.GetStaleNewses(ctx, "none")
+	ts.Assert().True(errors.Is(err, errs.ErrCacheMiss))
+
+	key, err := ts.cache.NewsesKey(ctx, "none")
+	ts.Require().NoError(err)
+	ts.Require().NoError(ts.cache.SetStaleNewses(ctx, "none", key, newses))
+	ts.Require().NoError(ts.cache.InvalidateNewses(ctx))
+	ts.forward(constant.NewsesCacheSeconds * time.Second)
+
+	// the stale listing outlives the generation and the listing ttl
+	stale, staleKey, err := ts.cache.GetStaleNewses(ctx, "none")
+	ts.Require().NoError(err)
+	ts.Assert().True(proto.Equal(newses, stale))
+	ts.Assert().Equal(key, staleKey)
+
+	ts.forward(constant.StaleNewsesCacheSeconds * time.Second)
+	_, _, err = ts.cache.GetStaleNewses(ctx, "none")
+	ts.Assert().True(errors.Is(err, errs.ErrCacheMiss))
+}
+
+func (ts *cacheContractTestSuite) TestLock() {
+	ctx := context.Background()
+
+	token, locked, err := ts.cache.Lock(ctx, constant.Tags, time.Second)
+	ts.Require().NoError(err)
+	ts.Require().True(locked)
+	ts.Assert().NotEmpty(token)
+
+	_, locked, err = ts.cache.Lock(ctx, constant.Tags, time.Second)
+	ts.Require().NoError(err)
+	ts.Assert().False(locked)
+
+	// other keys are locked separately
+	_, locked, err = ts.cache.Lock(ctx, constant.Topics, time.Second)
+	ts.Require().NoError(err)
+	ts.Assert().True(locked)
+
+	// only the holder releases the lock
+	ts.Require().NoError(ts.cache.Unlock(ctx, constant.Tags, "another holder"))
+	_, locked, err = ts.cache.Lock(ctx, constant.Tags, time.Second)
+	ts.Require().NoError(err)
+	ts.Assert().False(locked)
+
+	ts.Require().NoError(ts.cache.Unlock(ctx, constant.Tags, token))
+	token, locked, err = ts.cache.Lock(ctx, constant.Tags, time.Second)
+	ts.Require().NoError(err)
+	ts.Assert().True(locked)
+
+	// an expired lock is taken again, its holder can not release it anymore
+	ts.forward(time.Second)
+	_, locked, err = ts.cache.Lock(ctx, constant.Tags, time.Second)
+	ts.Require().NoError(err)
+	ts.Assert().True(locked)
+	ts.Require().NoError(ts.cache.Unlock(ctx, constant.Tags, token))
+	_, locked, err = ts.cache.Lock(ctx, constant.Tags, time.Second)
+	ts.Require().NoError(err)
+	ts.Assert().False(locked)
+}
+
+func (ts *cacheContractTestSuite) TestCopies() {
+	ctx := context.Background()
+	newses := newsesFixture()
+	key, err := ts.cache.NewsesKey(ctx, "none")
+	ts.Require().NoError(err)
+	ts.Require().NoError(ts.cache.SetNewses(ctx, key, newses))
+
+	// changing a stored or returned message leaves the cached one alone
+	newses.Newses[0].Title = "changed after caching"
+	cached, err := ts.cache.GetNewses(ctx, key)
+	ts.Require().NoError(err)
+	cached.Newses[1].Title = "changed after reading"
+
+	cached, err = ts.cache.GetNewses(ctx, key)
+	ts.Require().NoError(err)
+	ts.Assert().Equal("title news number 1", cached.Newses[0].Title)
+	ts.Assert().Equal("title news number 2", cached.Newses[1].Title)
+}
+
+func (ts *cacheContractTestSuite) TestListAndFlushKeys() {
+	ctx := context.Background()
+
+	keys, truncated, err := ts.cache.ListKeys(ctx, constant.MaxCacheKeys)
+	ts.Require().NoError(err)
+	ts.Assert().Empty(keys)
+	ts.Assert().False(truncated)
+
+	ts.Require().NoError(ts.cache.SetTag(ctx, &pb.Tag{Id: "c63b17cc-e227-4947-a01f-74f429ce99be", Tag: "tech"}))
+	ts.Require().NoError(ts.cache.SetTopic(ctx, &pb.Topic{Id: "d95cb090-0906-471a-80ef-3714c6451920", Title: "health"}))
+	ts.Require().NoError(ts.cache.SetStaleNewses(ctx, "none", newsesKey(0, "none"), newsesFixture()))
+	ts.Require().NoError(ts.cache.InvalidateNewses(ctx))
+	before, err := ts.cache.NewsesKey(ctx, "none")
+	ts.Require().NoError(err)
+
+	keys, truncated, err = ts.cache.ListKeys(ctx, constant.MaxCacheKeys)
+	ts.Require().NoError(err)
+	ts.Assert().Len(keys, 4)
+	ts.Assert().False(truncated)
+
+	keys, truncated, err = ts.cache.ListKeys(ctx, 2)
+	ts.Require().NoError(err)
+	ts.Assert().Len(keys, 2)
+	ts.Assert().True(truncated)
+
+	deleted, err := ts.cache.FlushKeys(ctx)
+	ts.Require().NoError(err)
+	ts.Assert().Equal(int64(3), deleted)
+
+	// the generation is bumped rather than started over, the keys handed
+	// out before the flush are never handed out again
+	keys, _, err = ts.cache.ListKeys(ctx, constant.MaxCacheKeys)
+	ts.Require().NoError(err)
+	ts.Require().Len(keys, 1)
+	ts.Assert().True(strings.HasSuffix(keys[0], versioned(constant.NewsesGeneration)))
+	after, err := ts.cache.NewsesKey(ctx, "none")
+	ts.Require().NoError(err)
+	ts.Assert().Equal(newsesKey(2, "none"), after)
+	ts.Assert().NotEqual(before, after)
+	tags, err := ts.cache.GetTags(ctx)
+	ts.Require().NoError(err)
+	ts.Assert().Empty(tags.Tags)
+	_, _, err = ts.cache.GetStaleNewses(ctx, "none")
+	ts.Assert().True(errors.Is(err, errs.ErrCacheMiss))
+}
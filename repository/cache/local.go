@@ -0,0 +1,224 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/muhammadisa/bareksanews/constant"
+	pb "github.com/muhammadisa/bareksanews/protoc/api/v1"
+	_interface "github.com/muhammadisa/bareksanews/repository/interface"
+	"google.golang.org/protobuf/proto"
+)
+
+// LocalConfig bound the in-process tier kept in front of redis, a zero Size
+// disables it
+type LocalConfig struct {
+	Size int           `yaml:"size"`
+	TTL  time.Duration `yaml:"ttl"`
+}
+
+// invalidations deliver the keys dropped by one replica to every replica,
+// including the one that published them
+type invalidations interface {
+	publish(ctx context.Context, key string) error
+	// listen call drop with every published key until ctx is done
+	listen(ctx context.Context, drop func(key string)) error
+}
+
+type redisInvalidations struct {
+	client redis.UniversalClient
+	// channel is namespaced like the keys, replicas of other namespaces
+	// never drop the local copies of this one
+	channel string
+}
+
+func (r redisInvalidations) publish(ctx context.Context, key string) error {
+	return r.client.Publish(ctx, r.channel, key).Err()
+}
+
+func (r redisInvalidations) listen(ctx context.Context, drop func(key string)) error {
+	pubsub := r.client.Subscribe(ctx, r.channel)
+	// wait for the subscription, the channel resubscribes by itself after
+	// a lost connection
+	if _, err := pubsub.Receive(ctx); err != nil {
+		_ = pubsub.Close()
+		return err
+	}
+	go func() {
+		defer pubsub.Close()
+		messages := pubsub.Channel()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case message, ok := <-messages:
+				if !ok {
+					return
+				}
+				drop(message.Payload)
+			}
+		}
+	}()
+	return nil
+}
+
+// flushed the invalidation sent once the namespace is flushed, no key is
+// empty
+const flushed = ""
+
+// localCache keep tags, topics and newses listings of the wrapped cache in
+// process, every write drops the local copies of all replicas through the
+// invalidations, the ttl bounds how long a lost invalidation is served
+//
+// like the memory cache the listings are cloned in and out, a caller
+// editing its copy must not change what the next caller reads
+type localCache struct {
+	_interface.Cache
+	entries *lru
+	bus     invalidations
+	// stop ends the listening of the invalidations
+	stop context.CancelFunc
+}
+
+func newLocalCache(ctx context.Context, next _interface.Cache, config LocalConfig, bus invalidations) (*localCache, error) {
+	ctx, stop := context.WithCancel(ctx)
+	c := &localCache{
+		Cache:   next,
+		entries: newLRU(config.Size, config.TTL),
+		bus:     bus,
+		stop:    stop,
+	}
+	if err := bus.listen(ctx, c.drop); err != nil {
+		stop()
+		return nil, err
+	}
+	return c, nil
+}
+
+// Close stop listening before the wrapped cache closes the connection the
+// invalidations are received on
+func (c *localCache) Close() error {
+	c.stop()
+	return c.Cache.Close()
+}
+
+// drop remove key and every key nested under it, flushed removes them all
+func (c *localCache) drop(key string) {
+	if key == flushed {
+		c.entries.removePrefix("")
+		return
+	}
+	c.entries.remove(key)
+	c.entries.removePrefix(key + ":")
+}
+
+// invalidate drop key locally before telling the other replicas, a message
+// lost while redis is unreachable is bounded by the ttl
+func (c *localCache) invalidate(ctx context.Context, key string, err error) error {
+	c.drop(key)
+	_ = c.bus.publish(ctx, key)
+	return err
+}
+
+func (c *localCache) SetTag(ctx context.Context, tag *pb.Tag) error {
+	return c.invalidate(ctx, constant.Tags, c.Cache.SetTag(ctx, tag))
+}
+
+func (c *localCache) UnsetTag(ctx context.Context, id string) error {
+	return c.invalidate(ctx, constant.Tags, c.Cache.UnsetTag(ctx, id))
+}
+
+func (c *localCache) ReloadTags(ctx context.Context, tags *pb.Tags) error {
+	return c.invalidate(ctx, constant.Tags, c.Cache.ReloadTags(ctx, tags))
+}
+
+func (c *localCache) GetTags(ctx context.Context) (*pb.Tags, error) {
+	if value, ok := c.entries.get(constant.Tags); ok {
+		count(tierLocal, true)
+		return proto.Clone(value.(*pb.Tags)).(*pb.Tags), nil
+	}
+	count(tierLocal, false)
+	since := c.entries.since()
+	tags, err := c.Cache.GetTags(ctx)
+	if err == nil && len(tags.Tags) > 0 {
+		c.entries.addUnlessRemoved(constant.Tags, proto.Clone(tags), since)
+	}
+	return tags, err
+}
+
+func (c *localCache) SetTopic(ctx context.Context, topic *pb.Topic) error {
+	return c.invalidate(ctx, constant.Topics, c.Cache.SetTopic(ctx, topic))
+}
+
+func (c *localCache) UnsetTopic(ctx context.Context, id string) error {
+	return c.invalidate(ctx, constant.Topics, c.Cache.UnsetTopic(ctx, id))
+}
+
+func (c *localCache) ReloadTopics(ctx context.Context, topics *pb.Topics) error {
+	return c.invalidate(ctx, constant.Topics, c.Cache.ReloadTopics(ctx, topics))
+}
+
+func (c *localCache) GetTopics(ctx context.Context) (*pb.Topics, error) {
+	if value, ok := c.entries.get(constant.Topics); ok {
+		count(tierLocal, true)
+		return proto.Clone(value.(*pb.Topics)).(*pb.Topics), nil
+	}
+	count(tierLocal, false)
+	since := c.entries.since()
+	topics, err := c.Cache.GetTopics(ctx)
+	if err == nil && len(topics.Topics) > 0 {
+		c.entries.addUnlessRemoved(constant.Topics, proto.Clone(topics), since)
+	}
+	return topics, err
+}
+
+// NewsesKey keep the key of every filter under the generation, bumping the
+// generation drops them all
+func (c *localCache) NewsesKey(ctx context.Context, filter string) (string, error) {
+	localKey := fmt.Sprintf("%s:%s", constant.NewsesGeneration, filter)
+	if value, ok := c.entries.get(localKey); ok {
+		return value.(string), nil
+	}
+	since := c.entries.since()
+	key, err := c.Cache.NewsesKey(ctx, filter)
+	if err == nil {
+		c.entries.addUnlessRemoved(localKey, key, since)
+	}
+	return key, err
+}
+
+// GetNewses newses keys embed the generation, the listing of one key never
+// changes and needs no invalidation
+func (c *localCache) GetNewses(ctx context.Context, key string) (*pb.Newses, error) {
+	if value, ok := c.entries.get(key); ok {
+		count(tierLocal, true)
+		return proto.Clone(value.(*pb.Newses)).(*pb.Newses), nil
+	}
+	count(tierLocal, false)
+	newses, err := c.Cache.GetNewses(ctx, key)
+	if err == nil {
+		c.entries.add(key, proto.Clone(newses))
+	}
+	return newses, err
+}
+
+func (c *localCache) SetNewses(ctx context.Context, key string, newses *pb.Newses) error {
+	err := c.Cache.SetNewses(ctx, key, newses)
+	if err == nil {
+		c.entries.add(key, proto.Clone(newses))
+	}
+	return err
+}
+
+func (c *localCache) InvalidateNewses(ctx context.Context) error {
+	return c.invalidate(ctx, constant.NewsesGeneration, c.Cache.InvalidateNewses(ctx))
+}
+
+// FlushKeys drop every local copy on all replicas once the namespace is
+// flushed
+func (c *localCache) FlushKeys(ctx context.Context) (int64, error) {
+	deleted, err := c.Cache.FlushKeys(ctx)
+	return deleted, c.invalidate(ctx, flushed, err)
+}
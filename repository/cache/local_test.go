@@ -0,0 +1,210 @@
+package cache
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redismock/v8"
+	"github.com/muhammadisa/bareksanews/constant"
+	pb "github.com/muhammadisa/bareksanews/protoc/api/v1"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/suite"
+	"go.opencensus.io/trace"
+)
+
+// memoryInvalidations deliver published keys to every listener in process
+type memoryInvalidations struct {
+	mu        sync.Mutex
+	listeners []func(key string)
+	published []string
+}
+
+func (m *memoryInvalidations) publish(_ context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.published = append(m.published, key)
+	for _, drop := range m.listeners {
+		drop(key)
+	}
+	return nil
+}
+
+func (m *memoryInvalidations) listen(_ context.Context, drop func(key string)) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.listeners = append(m.listeners, drop)
+	return nil
+}
+
+type localCacheTestSuite struct {
+	suite.Suite
+}
+
+func TestLocalCacheTestSuite(t *testing.T) {
+	suite.Run(t, new(localCacheTestSuite))
+}
+
+func (ts *localCacheTestSuite) TestGetTagsServedLocally() {
+	db, mock := redismock.NewClientMock()
+	ctx := context.Background()
+	bus := new(memoryInvalidations)
+	localCache, err := newLocalCache(ctx, &cache{redis: db, tracer: trace.DefaultTracer}, LocalConfig{Size: 8, TTL: time.Minute}, bus)
+	ts.Require().NoError(err)
+
+	tag := &pb.Tag{Id: "c63b17cc-e227-4947-a01f-74f429ce99be", Tag: "tech"}
+	tagValue := encoded(tag)
+
+	// only the first read reaches redis
+	mock.ExpectHGetAll(versioned(constant.Tags)).SetVal(map[string]string{tag.Id: tagValue, completeField: "1"})
+
+	localHits := testutil.ToFloat64(lookups.WithLabelValues(tierLocal, "hit"))
+	localMisses := testutil.ToFloat64(lookups.WithLabelValues(tierLocal, "miss"))
+	for i := 0; i < 3; i++ {
+		tags, err := localCache.GetTags(ctx)
+		ts.Require().NoError(err)
+		ts.Require().Len(tags.Tags, 1)
+		ts.Assert().Equal(tag.Tag, tags.Tags[0].Tag)
+		// the local copy is not shared with the caller
+		tags.Tags[0].Tag = "edited"
+	}
+	ts.Assert().Equal(localHits+2, testutil.ToFloat64(lookups.WithLabelValues(tierLocal, "hit")))
+	ts.Assert().Equal(localMisses+1, testutil.ToFloat64(lookups.WithLabelValues(tierLocal, "miss")))
+
+	err = mock.ExpectationsWereMet()
+	ts.Assert().NoError(err)
+}
+
+func (ts *localCacheTestSuite) TestNewsesNotShared() {
+	ctx := context.Background()
+	localCache, err := newLocalCache(ctx, NewMemoryCache(trace.DefaultTracer), LocalConfig{Size: 8, TTL: time.Minute}, new(memoryInvalidations))
+	ts.Require().NoError(err)
+
+	newses := &pb.Newses{Newses: []*pb.News{{Id: "f0b3c6d2-5d2e-4f0a-9d4c-2a1b7e8f9a10", Title: "news"}}}
+	ts.Require().NoError(localCache.SetNewses(ctx, "newses_key", newses))
+	newses.Newses[0].Title = "edited"
+
+	for i := 0; i < 2; i++ {
+		newses, err = localCache.GetNewses(ctx, "newses_key")
+		ts.Require().NoError(err)
+		ts.Require().Len(newses.Newses, 1)
+		ts.Assert().Equal("news", newses.Newses[0].Title)
+		newses.Newses[0].Title = "edited"
+	}
+}
+
+func (ts *localCacheTestSuite) TestEmptyTagsNotKept() {
+	db, mock := redismock.NewClientMock()
+	ctx := context.Background()
+	localCache, err := newLocalCache(ctx, &cache{redis: db, tracer: trace.DefaultTracer}, LocalConfig{Size: 8, TTL: time.Minute}, new(memoryInvalidations))
+	ts.Require().NoError(err)
+
+	// an empty hash asks the service to reload, it is read again every time
+	mock.ExpectHGetAll(versioned(constant.Tags)).SetVal(map[string]string{})
+	mock.ExpectHGetAll(versioned(constant.Tags)).SetVal(map[string]string{})
+
+	for i := 0; i < 2; i++ {
+		tags, err := localCache.GetTags(ctx)
+		ts.Require().NoError(err)
+		ts.Assert().Empty(tags.Tags)
+	}
+
+	err = mock.ExpectationsWereMet()
+	ts.Assert().NoError(err)
+}
+
+func (ts *localCacheTestSuite) TestInvalidateEveryReplica() {
+	ctx := context.Background()
+	bus := new(memoryInvalidations)
+
+	topic := &pb.Topic{Id: "d95cb090-0906-471a-80ef-3714c6451920", Title: "health"}
+	topicValue := encoded(topic)
+
+	dbA, mockA := redismock.NewClientMock()
+	replicaA, err := newLocalCache(ctx, &cache{redis: dbA, tracer: trace.DefaultTracer}, LocalConfig{Size: 8, TTL: time.Minute}, bus)
+	ts.Require().NoError(err)
+	dbB, mockB := redismock.NewClientMock()
+	replicaB, err := newLocalCache(ctx, &cache{redis: dbB, tracer: trace.DefaultTracer}, LocalConfig{Size: 8, TTL: time.Minute}, bus)
+	ts.Require().NoError(err)
+
+	mockB.ExpectHGetAll(versioned(constant.Topics)).SetVal(map[string]string{topic.Id: topicValue, completeField: "1"})
+	topics, err := replicaB.GetTopics(ctx)
+	ts.Require().NoError(err)
+	ts.Require().Len(topics.Topics, 1)
+
+	// replica a removes the topic, replica b reads redis again
+	mockA.ExpectHDel(versioned(constant.Topics), topic.Id).SetVal(1)
+	err = replicaA.UnsetTopic(ctx, topic.Id)
+	ts.Require().NoError(err)
+	ts.Assert().Equal([]string{constant.Topics}, bus.published)
+
+	mockB.ExpectHGetAll(versioned(constant.Topics)).SetVal(map[string]string{})
+	topics, err = replicaB.GetTopics(ctx)
+	ts.Require().NoError(err)
+	ts.Assert().Empty(topics.Topics)
+
+	ts.Assert().NoError(mockA.ExpectationsWereMet())
+	ts.Assert().NoError(mockB.ExpectationsWereMet())
+}
+
+func (ts *localCacheTestSuite) TestInvalidateNewses() {
+	ctx := context.Background()
+	bus := new(memoryInvalidations)
+	newses := newsesFixture()
+	newsesValue := encoded(newses)
+
+	dbA, mockA := redismock.NewClientMock()
+	replicaA, err := newLocalCache(ctx, &cache{redis: dbA, tracer: trace.DefaultTracer}, LocalConfig{Size: 8, TTL: time.Minute}, bus)
+	ts.Require().NoError(err)
+	dbB, mockB := redismock.NewClientMock()
+	replicaB, err := newLocalCache(ctx, &cache{redis: dbB, tracer: trace.DefaultTracer}, LocalConfig{Size: 8, TTL: time.Minute}, bus)
+	ts.Require().NoError(err)
+
+	// the key and the listing of a generation are read from redis once
+	mockB.ExpectGet(versioned(constant.NewsesGeneration)).SetVal("1")
+	mockB.ExpectGet(newsesKey(1, "none")).SetVal(newsesValue)
+	for i := 0; i < 2; i++ {
+		key, err := replicaB.NewsesKey(ctx, "none")
+		ts.Require().NoError(err)
+		ts.Require().Equal(newsesKey(1, "none"), key)
+		listed, err := replicaB.GetNewses(ctx, key)
+		ts.Require().NoError(err)
+		ts.Assert().Len(listed.Newses, 2)
+	}
+
+	mockA.ExpectIncr(versioned(constant.NewsesGeneration)).SetVal(2)
+	err = replicaA.InvalidateNewses(ctx)
+	ts.Require().NoError(err)
+
+	mockB.ExpectGet(versioned(constant.NewsesGeneration)).SetVal("2")
+	key, err := replicaB.NewsesKey(ctx, "none")
+	ts.Require().NoError(err)
+	ts.Assert().Equal(newsesKey(2, "none"), key)
+
+	ts.Assert().NoError(mockA.ExpectationsWereMet())
+	ts.Assert().NoError(mockB.ExpectationsWereMet())
+}
+
+func (ts *localCacheTestSuite) TestFlushDropsEveryReplica() {
+	ctx := context.Background()
+	bus := new(memoryInvalidations)
+	memory := newMemoryCache(trace.DefaultTracer, time.Now)
+	first, err := newLocalCache(ctx, memory, LocalConfig{Size: 8, TTL: time.Minute}, bus)
+	ts.Require().NoError(err)
+	second, err := newLocalCache(ctx, memory, LocalConfig{Size: 8, TTL: time.Minute}, bus)
+	ts.Require().NoError(err)
+
+	ts.Require().NoError(memory.ReloadTags(ctx, &pb.Tags{Tags: []*pb.Tag{{Id: "c63b17cc-e227-4947-a01f-74f429ce99be", Tag: "tech"}}}))
+	_, err = second.GetTags(ctx)
+	ts.Require().NoError(err)
+	ts.Require().Equal(1, second.entries.len())
+
+	deleted, err := first.FlushKeys(ctx)
+	ts.Require().NoError(err)
+	ts.Assert().Equal(int64(1), deleted)
+	ts.Assert().Zero(second.entries.len())
+	ts.Assert().Equal([]string{flushed}, bus.published)
+}
@@ -0,0 +1,129 @@
+package cache
+
+import (
+	"container/list"
+	"strings"
+	"sync"
+	"time"
+)
+
+// lru bounded map dropping the least recently used entry once it is full,
+// entries older than ttl are treated as missing
+type lru struct {
+	mu      sync.Mutex
+	size    int
+	ttl     time.Duration
+	now     func() time.Time
+	order   *list.List
+	entries map[string]*list.Element
+	// removals counts remove and removePrefix calls, addUnlessRemoved
+	// compares it to skip values read before a removal
+	removals uint64
+}
+
+type lruEntry struct {
+	key     string
+	value   interface{}
+	expires time.Time
+}
+
+func newLRU(size int, ttl time.Duration) *lru {
+	return &lru{
+		size:    size,
+		ttl:     ttl,
+		now:     time.Now,
+		order:   list.New(),
+		entries: make(map[string]*list.Element),
+	}
+}
+
+func (l *lru) get(key string) (interface{}, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	element, ok := l.entries[key]
+	if !ok {
+		return nil, false
+	}
+	entry := element.Value.(*lruEntry)
+	if l.ttl > 0 && !l.now().Before(entry.expires) {
+		l.removeElement(element)
+		return nil, false
+	}
+	l.order.MoveToFront(element)
+	return entry.value, true
+}
+
+func (l *lru) add(key string, value interface{}) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.put(key, value)
+}
+
+// addUnlessRemoved add value unless anything was removed since removals
+// returned since, the value may predate that removal
+func (l *lru) addUnlessRemoved(key string, value interface{}, since uint64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.removals == since {
+		l.put(key, value)
+	}
+}
+
+func (l *lru) put(key string, value interface{}) {
+	expires := l.now().Add(l.ttl)
+	if element, ok := l.entries[key]; ok {
+		entry := element.Value.(*lruEntry)
+		entry.value, entry.expires = value, expires
+		l.order.MoveToFront(element)
+		return
+	}
+	l.entries[key] = l.order.PushFront(&lruEntry{key: key, value: value, expires: expires})
+	for l.order.Len() > l.size {
+		l.removeElement(l.order.Back())
+	}
+}
+
+func (l *lru) since() uint64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.removals
+}
+
+func (l *lru) remove(key string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.removals++
+	if element, ok := l.entries[key]; ok {
+		l.removeElement(element)
+	}
+}
+
+// removePrefix drop every entry with a key starting with prefix
+func (l *lru) removePrefix(prefix string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.removals++
+	for key, element := range l.entries {
+		if strings.HasPrefix(key, prefix) {
+			l.removeElement(element)
+		}
+	}
+}
+
+func (l *lru) len() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.order.Len()
+}
+
+func (l *lru) removeElement(element *list.Element) {
+	l.order.Remove(element)
+	delete(l.entries, element.Value.(*lruEntry).key)
+}
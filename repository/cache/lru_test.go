@@ -0,0 +1,85 @@
+package cache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type lruTestSuite struct {
+	suite.Suite
+}
+
+func TestLRUTestSuite(t *testing.T) {
+	suite.Run(t, new(lruTestSuite))
+}
+
+func (ts *lruTestSuite) TestEvictLeastRecentlyUsed() {
+	entries := newLRU(2, time.Minute)
+	entries.add("a", 1)
+	entries.add("b", 2)
+
+	// reading a makes b the least recently used entry
+	_, ok := entries.get("a")
+	ts.Require().True(ok)
+	entries.add("c", 3)
+
+	ts.Assert().Equal(2, entries.len())
+	_, ok = entries.get("b")
+	ts.Assert().False(ok)
+	value, ok := entries.get("a")
+	ts.Assert().True(ok)
+	ts.Assert().Equal(1, value)
+	value, ok = entries.get("c")
+	ts.Assert().True(ok)
+	ts.Assert().Equal(3, value)
+}
+
+func (ts *lruTestSuite) TestExpire() {
+	now := time.Unix(1634323641, 0)
+	entries := newLRU(2, time.Minute)
+	entries.now = func() time.Time { return now }
+	entries.add("a", 1)
+
+	now = now.Add(59 * time.Second)
+	_, ok := entries.get("a")
+	ts.Assert().True(ok)
+
+	now = now.Add(time.Second)
+	_, ok = entries.get("a")
+	ts.Assert().False(ok)
+	ts.Assert().Equal(0, entries.len())
+}
+
+func (ts *lruTestSuite) TestRemovePrefix() {
+	entries := newLRU(4, time.Minute)
+	entries.add("newses_generation", 1)
+	entries.add("newses_generation:none", "newses:1:none")
+	entries.add("newses_generation:status_1", "newses:1:status_1")
+	entries.add("newses:1:none", 2)
+
+	entries.removePrefix("newses_generation:")
+
+	ts.Assert().Equal(2, entries.len())
+	_, ok := entries.get("newses_generation")
+	ts.Assert().True(ok)
+	_, ok = entries.get("newses:1:none")
+	ts.Assert().True(ok)
+}
+
+func (ts *lruTestSuite) TestAddUnlessRemoved() {
+	entries := newLRU(2, time.Minute)
+
+	since := entries.since()
+	entries.remove("tags")
+	entries.addUnlessRemoved("tags", 1, since)
+	_, ok := entries.get("tags")
+	ts.Assert().False(ok, "a value read before the removal is dropped")
+
+	since = entries.since()
+	entries.addUnlessRemoved("tags", 2, since)
+	value, ok := entries.get("tags")
+	ts.Assert().True(ok)
+	ts.Assert().Equal(2, value)
+}
@@ -0,0 +1,384 @@
+package cache
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/muhammadisa/bareksanews/constant"
+	pb "github.com/muhammadisa/bareksanews/protoc/api/v1"
+	"github.com/muhammadisa/bareksanews/repository/errs"
+	_interface "github.com/muhammadisa/bareksanews/repository/interface"
+	uuid "github.com/satori/go.uuid"
+	"go.opencensus.io/trace"
+	"google.golang.org/protobuf/proto"
+)
+
+// memorySweepSeconds interval between sweeps of the expired values
+const memorySweepSeconds = 1
+
+// memoryCache keep everything the redis cache keeps in process with the
+// same semantics, for tests and local runs without redis, values are
+// cloned in and out so callers never share them
+type memoryCache struct {
+	tracer trace.Tracer
+	now    func() time.Time
+
+	mu     sync.Mutex
+	tags   map[string]*pb.Tag
+	topics map[string]*pb.Topic
+	// tagsComplete and topicsComplete are set by a reload, like the complete
+	// field of the redis hashes
+	tagsComplete   bool
+	topicsComplete bool
+	generation     int64
+	values         map[string]memoryValue
+	swept          time.Time
+}
+
+type memoryValue struct {
+	value   interface{}
+	expires time.Time
+}
+
+// staleValue the stale listing of a filter and the key it was listed under
+type staleValue struct {
+	key    string
+	newses *pb.Newses
+}
+
+func NewMemoryCache(tracer trace.Tracer) _interface.Cache {
+	return newMemoryCache(tracer, time.Now)
+}
+
+func newMemoryCache(tracer trace.Tracer, now func() time.Time) *memoryCache {
+	return &memoryCache{
+		tracer: tracer,
+		now:    now,
+		tags:   make(map[string]*pb.Tag),
+		topics: make(map[string]*pb.Topic),
+		values: make(map[string]memoryValue),
+	}
+}
+
+// get the value of key unless it expired, the caller holds mu
+func (c *memoryCache) get(key string) (interface{}, bool) {
+	value, ok := c.values[key]
+	if !ok {
+		return nil, false
+	}
+	if !c.now().Before(value.expires) {
+		delete(c.values, key)
+		return nil, false
+	}
+	return value.value, true
+}
+
+// set value of key for ttl, the caller holds mu
+func (c *memoryCache) set(key string, value interface{}, ttl time.Duration) {
+	now := c.now()
+	c.values[key] = memoryValue{value: value, expires: now.Add(ttl)}
+	if now.Sub(c.swept) < memorySweepSeconds*time.Second {
+		return
+	}
+	c.swept = now
+	for key, value := range c.values {
+		if !now.Before(value.expires) {
+			delete(c.values, key)
+		}
+	}
+}
+
+func (c *memoryCache) SetTag(ctx context.Context, tag *pb.Tag) error {
+	const funcName = `SetTag`
+	_, span := c.tracer.StartSpan(ctx, funcName)
+	defer span.End()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// like HSETNX a cached tag is only replaced after UnsetTag
+	if _, ok := c.tags[tag.Id]; !ok {
+		c.tags[tag.Id] = proto.Clone(tag).(*pb.Tag)
+	}
+	return nil
+}
+
+func (c *memoryCache) UnsetTag(ctx context.Context, id string) error {
+	const funcName = `UnsetTag`
+	_, span := c.tracer.StartSpan(ctx, funcName)
+	defer span.End()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.tags, id)
+	return nil
+}
+
+func (c *memoryCache) GetTags(ctx context.Context) (*pb.Tags, error) {
+	const funcName = `GetTags`
+	_, span := c.tracer.StartSpan(ctx, funcName)
+	defer span.End()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var tags pb.Tags
+	if !c.tagsComplete {
+		return &tags, nil
+	}
+	for _, tag := range c.tags {
+		tags.Tags = append(tags.Tags, proto.Clone(tag).(*pb.Tag))
+	}
+	return &tags, nil
+}
+
+func (c *memoryCache) ReloadTags(ctx context.Context, tags *pb.Tags) error {
+	const funcName = `ReloadTags`
+	_, span := c.tracer.StartSpan(ctx, funcName)
+	defer span.End()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, tag := range tags.Tags {
+		c.tags[tag.Id] = proto.Clone(tag).(*pb.Tag)
+	}
+	c.tagsComplete = true
+	return nil
+}
+
+func (c *memoryCache) SetTopic(ctx context.Context, topic *pb.Topic) error {
+	const funcName = `SetTopic`
+	_, span := c.tracer.StartSpan(ctx, funcName)
+	defer span.End()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.topics[topic.Id]; !ok {
+		c.topics[topic.Id] = proto.Clone(topic).(*pb.Topic)
+	}
+	return nil
+}
+
+func (c *memoryCache) UnsetTopic(ctx context.Context, id string) error {
+	const funcName = `UnsetTopic`
+	_, span := c.tracer.StartSpan(ctx, funcName)
+	defer span.End()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.topics, id)
+	return nil
+}
+
+func (c *memoryCache) GetTopics(ctx context.Context) (*pb.Topics, error) {
+	const funcName = `GetTopics`
+	_, span := c.tracer.StartSpan(ctx, funcName)
+	defer span.End()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var topics pb.Topics
+	if !c.topicsComplete {
+		return &topics, nil
+	}
+	for _, topic := range c.topics {
+		topics.Topics = append(topics.Topics, proto.Clone(topic).(*pb.Topic))
+	}
+	return &topics, nil
+}
+
+func (c *memoryCache) ReloadTopics(ctx context.Context, topics *pb.Topics) error {
+	const funcName = `ReloadTopics`
+	_, span := c.tracer.StartSpan(ctx, funcName)
+	defer span.End()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, topic := range topics.Topics {
+		c.topics[topic.Id] = proto.Clone(topic).(*pb.Topic)
+	}
+	c.topicsComplete = true
+	return nil
+}
+
+func (c *memoryCache) NewsesKey(ctx context.Context, filter string) (string, error) {
+	const funcName = `NewsesKey`
+	_, span := c.tracer.StartSpan(ctx, funcName)
+	defer span.End()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return newsesKey(c.generation, filter), nil
+}
+
+func (c *memoryCache) GetNewses(ctx context.Context, key string) (*pb.Newses, error) {
+	const funcName = `GetNewses`
+	_, span := c.tracer.StartSpan(ctx, funcName)
+	defer span.End()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	value, ok := c.get(key)
+	if !ok {
+		return nil, errs.ErrCacheMiss
+	}
+	return proto.Clone(value.(*pb.Newses)).(*pb.Newses), nil
+}
+
+func (c *memoryCache) SetNewses(ctx context.Context, key string, newses *pb.Newses) error {
+	const funcName = `SetNewses`
+	_, span := c.tracer.StartSpan(ctx, funcName)
+	defer span.End()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.set(key, proto.Clone(newses), constant.NewsesCacheSeconds*time.Second)
+	return nil
+}
+
+func (c *memoryCache) InvalidateNewses(ctx context.Context) error {
+	const funcName = `InvalidateNewses`
+	_, span := c.tracer.StartSpan(ctx, funcName)
+	defer span.End()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.generation++
+	return nil
+}
+
+func (c *memoryCache) GetStaleNewses(ctx context.Context, filter string) (*pb.Newses, string, error) {
+	const funcName = `GetStaleNewses`
+	_, span := c.tracer.StartSpan(ctx, funcName)
+	defer span.End()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	value, ok := c.get(staleNewsesKey(filter))
+	if !ok {
+		return nil, "", errs.ErrCacheMiss
+	}
+	stale := value.(staleValue)
+	return proto.Clone(stale.newses).(*pb.Newses), stale.key, nil
+}
+
+func (c *memoryCache) SetStaleNewses(ctx context.Context, filter, key string, newses *pb.Newses) error {
+	const funcName = `SetStaleNewses`
+	_, span := c.tracer.StartSpan(ctx, funcName)
+	defer span.End()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.set(staleNewsesKey(filter), staleValue{key: key, newses: proto.Clone(newses).(*pb.Newses)}, constant.StaleNewsesCacheSeconds*time.Second)
+	return nil
+}
+
+func (c *memoryCache) Lock(ctx context.Context, key string, ttl time.Duration) (string, bool, error) {
+	const funcName = `Lock`
+	_, span := c.tracer.StartSpan(ctx, funcName)
+	defer span.End()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, held := c.get(lockKey(key)); held {
+		return "", false, nil
+	}
+	token := uuid.NewV4().String()
+	c.set(lockKey(key), token, ttl)
+	return token, true, nil
+}
+
+func (c *memoryCache) Unlock(ctx context.Context, key, token string) error {
+	const funcName = `Unlock`
+	_, span := c.tracer.StartSpan(ctx, funcName)
+	defer span.End()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if value, held := c.get(lockKey(key)); held && value.(string) == token {
+		delete(c.values, lockKey(key))
+	}
+	return nil
+}
+
+// ListKeys the keys redis would hold for the same values, without namespace
+// since the values never leave the process
+func (c *memoryCache) ListKeys(ctx context.Context, limit int) ([]string, bool, error) {
+	const funcName = `ListKeys`
+	_, span := c.tracer.StartSpan(ctx, funcName)
+	defer span.End()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	keys := c.keys()
+	if len(keys) > limit {
+		return keys[:limit], true, nil
+	}
+	return keys, false, nil
+}
+
+func (c *memoryCache) FlushKeys(ctx context.Context) (int64, error) {
+	const funcName = `FlushKeys`
+	_, span := c.tracer.StartSpan(ctx, funcName)
+	defer span.End()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	deleted := int64(len(c.keys()))
+	if c.generation > 0 {
+		deleted--
+	}
+	c.tags = make(map[string]*pb.Tag)
+	c.topics = make(map[string]*pb.Topic)
+	c.tagsComplete, c.topicsComplete = false, false
+	c.generation++
+	c.values = make(map[string]memoryValue)
+	return deleted, nil
+}
+
+func (c *memoryCache) Ping(ctx context.Context) error {
+	return nil
+}
+
+func (c *memoryCache) Close() error {
+	return nil
+}
+
+// keys sorted keys of the values not expired yet, the caller holds mu
+func (c *memoryCache) keys() []string {
+	var keys []string
+	if len(c.tags) > 0 || c.tagsComplete {
+		keys = append(keys, versioned(constant.Tags))
+	}
+	if len(c.topics) > 0 || c.topicsComplete {
+		keys = append(keys, versioned(constant.Topics))
+	}
+	if c.generation > 0 {
+		keys = append(keys, versioned(constant.NewsesGeneration))
+	}
+	for key := range c.values {
+		if _, ok := c.get(key); ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
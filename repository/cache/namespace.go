@@ -0,0 +1,156 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/muhammadisa/bareksanews/constant"
+)
+
+// namespaceForbidden characters breaking the key layout or the SCAN pattern
+// of the namespace
+const namespaceForbidden = ":{}*?[]\\ "
+
+// Namespace scope every key of one deployment under
+// service:environment[:tenant]: so deployments can share a redis
+type Namespace struct {
+	// Service defaults to constant.ServiceName
+	Service     string `yaml:"service"`
+	Environment string `yaml:"environment"`
+	// Tenant is optional
+	Tenant string `yaml:"tenant"`
+}
+
+// Prefix the namespace prepended to every key
+func (n Namespace) Prefix() (string, error) {
+	if n.Service == "" {
+		n.Service = constant.ServiceName
+	}
+	if n.Environment == "" {
+		return "", fmt.Errorf("cache namespace of %s needs an environment", n.Service)
+	}
+	parts := []string{n.Service, n.Environment}
+	if n.Tenant != "" {
+		parts = append(parts, n.Tenant)
+	}
+	for _, part := range parts {
+		if strings.ContainsAny(part, namespaceForbidden) {
+			return "", fmt.Errorf("cache namespace part %q contains one of %q", part, namespaceForbidden)
+		}
+	}
+	return strings.Join(parts, ":") + ":", nil
+}
+
+// Validate report a namespace NewCache refuses
+func (n Namespace) Validate() error {
+	_, err := n.Prefix()
+	return err
+}
+
+// key put k under the namespace of the cache
+func (c *cache) key(k string) string {
+	return c.prefix + k
+}
+
+// eachNode call fn with every node holding keys, the masters of a cluster
+// concurrently or the single client otherwise
+func (c *cache) eachNode(ctx context.Context, fn func(ctx context.Context, node redis.Cmdable) error) error {
+	if cluster, ok := c.redis.(*redis.ClusterClient); ok {
+		return cluster.ForEachMaster(ctx, func(ctx context.Context, node *redis.Client) error {
+			return fn(ctx, node)
+		})
+	}
+	return fn(ctx, c.redis)
+}
+
+// scan call fn with every batch of namespaced keys of node until fn
+// reports it needs no more
+func (c *cache) scan(ctx context.Context, node redis.Cmdable, fn func(keys []string) (bool, error)) error {
+	var cursor uint64
+	for {
+		keys, next, err := node.Scan(ctx, cursor, c.prefix+"*", constant.CacheScanCount).Result()
+		if err != nil {
+			return err
+		}
+		more, err := fn(keys)
+		if err != nil || !more || next == 0 {
+			return err
+		}
+		cursor = next
+	}
+}
+
+// ListKeys walk the namespace with SCAN, the keys of other namespaces
+// sharing the redis are never returned
+func (c *cache) ListKeys(ctx context.Context, limit int) (keys []string, truncated bool, err error) {
+	const funcName = `ListKeys`
+	_, span := c.tracer.StartSpan(ctx, funcName)
+	defer span.End()
+
+	var mu sync.Mutex
+	err = c.eachNode(ctx, func(ctx context.Context, node redis.Cmdable) error {
+		return c.scan(ctx, node, func(batch []string) (bool, error) {
+			mu.Lock()
+			defer mu.Unlock()
+
+			keys = append(keys, batch...)
+			return len(keys) <= limit, nil
+		})
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	sort.Strings(keys)
+	if len(keys) > limit {
+		return keys[:limit], true, nil
+	}
+	return keys, false, nil
+}
+
+// FlushKeys delete the namespace with SCAN, the keys are deleted one by one
+// since a batch spans cluster slots
+//
+// the newses generation is bumped instead of deleted, starting over from
+// zero would hand out again the keys of listings a replica reloading during
+// the flush caches afterwards
+func (c *cache) FlushKeys(ctx context.Context) (int64, error) {
+	const funcName = `FlushKeys`
+	_, span := c.tracer.StartSpan(ctx, funcName)
+	defer span.End()
+
+	generation := c.key(versioned(constant.NewsesGeneration))
+	var mu sync.Mutex
+	var deleted int64
+	err := c.eachNode(ctx, func(ctx context.Context, node redis.Cmdable) error {
+		return c.scan(ctx, node, func(batch []string) (bool, error) {
+			if len(batch) == 0 {
+				return true, nil
+			}
+			cmds, err := node.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+				for _, key := range batch {
+					if key != generation {
+						pipe.Unlink(ctx, key)
+					}
+				}
+				return nil
+			})
+			if err != nil {
+				return false, err
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			for _, cmd := range cmds {
+				deleted += cmd.(*redis.IntCmd).Val()
+			}
+			return true, nil
+		})
+	})
+	if err != nil {
+		return deleted, err
+	}
+	return deleted, c.redis.Incr(ctx, generation).Err()
+}
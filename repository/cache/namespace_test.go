@@ -0,0 +1,105 @@
+package cache
+
+import (
+	"context"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+	"github.com/muhammadisa/bareksanews/constant"
+	pb "github.com/muhammadisa/bareksanews/protoc/api/v1"
+	"github.com/stretchr/testify/suite"
+	"go.opencensus.io/trace"
+)
+
+type namespaceTestSuite struct {
+	suite.Suite
+}
+
+func TestNamespaceTestSuite(t *testing.T) {
+	suite.Run(t, new(namespaceTestSuite))
+}
+
+func (ts *namespaceTestSuite) TestPrefix() {
+	tests := []struct {
+		Name      string
+		Namespace Namespace
+		Want      string
+		WantError bool
+	}{
+		{
+			Name:      "default service",
+			Namespace: Namespace{Environment: "staging"},
+			Want:      "bareksa_news:staging:",
+		},
+		{
+			Name:      "with tenant",
+			Namespace: Namespace{Service: "news", Environment: "production", Tenant: "acme"},
+			Want:      "news:production:acme:",
+		},
+		{
+			Name:      "without environment",
+			Namespace: Namespace{Service: "news"},
+			WantError: true,
+		},
+		{
+			Name:      "separator in a part",
+			Namespace: Namespace{Environment: "prod:eu"},
+			WantError: true,
+		},
+		{
+			Name:      "pattern in a part",
+			Namespace: Namespace{Environment: "production", Tenant: "*"},
+			WantError: true,
+		},
+	}
+
+	for _, test := range tests {
+		ts.Run(test.Name, func() {
+			prefix, err := test.Namespace.Prefix()
+			if test.WantError {
+				ts.Assert().Error(err)
+				return
+			}
+			ts.Require().NoError(err)
+			ts.Assert().Equal(test.Want, prefix)
+		})
+	}
+}
+
+func (ts *namespaceTestSuite) TestIsolation() {
+	server, err := miniredis.Run()
+	ts.Require().NoError(err)
+	defer server.Close()
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	defer client.Close()
+	ctx := context.Background()
+
+	acme := &cache{redis: client, tracer: trace.DefaultTracer, prefix: "bareksa_news:production:acme:"}
+	globex := &cache{redis: client, tracer: trace.DefaultTracer, prefix: "bareksa_news:production:globex:"}
+	ts.Require().NoError(server.Set("unrelated", "kept"))
+
+	ts.Require().NoError(acme.ReloadTags(ctx, &pb.Tags{Tags: []*pb.Tag{{Id: "c63b17cc-e227-4947-a01f-74f429ce99be", Tag: "tech"}}}))
+	ts.Require().NoError(globex.ReloadTags(ctx, &pb.Tags{Tags: []*pb.Tag{{Id: "0f4e1e74-9238-4afb-87c2-108e569ff866", Tag: "health"}}}))
+	ts.Assert().True(server.Exists("bareksa_news:production:acme:" + versioned(constant.Tags)))
+
+	tags, err := acme.GetTags(ctx)
+	ts.Require().NoError(err)
+	ts.Require().Len(tags.Tags, 1)
+	ts.Assert().Equal("tech", tags.Tags[0].Tag)
+
+	keys, truncated, err := acme.ListKeys(ctx, constant.MaxCacheKeys)
+	ts.Require().NoError(err)
+	ts.Assert().False(truncated)
+	ts.Assert().Equal([]string{"bareksa_news:production:acme:" + versioned(constant.Tags)}, keys)
+
+	deleted, err := acme.FlushKeys(ctx)
+	ts.Require().NoError(err)
+	ts.Assert().Equal(int64(1), deleted)
+
+	// the other tenant and keys outside any namespace are left alone
+	tags, err = globex.GetTags(ctx)
+	ts.Require().NoError(err)
+	ts.Assert().Len(tags.Tags, 1)
+	ts.Assert().True(server.Exists("unrelated"))
+}
@@ -0,0 +1,54 @@
+package cache
+
+import (
+	"github.com/muhammadisa/bareksanews/constant"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// tierLocal in-process lru of every replica
+	tierLocal = `local`
+
+	// tierRedis redis shared by every replica
+	tierRedis = `redis`
+)
+
+var (
+	// lookups count the hits and misses of every cache tier for prometheus,
+	// the hit ratio is hits over the sum of both
+	lookups = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: constant.ServiceName,
+		Subsystem: "cache",
+		Name:      "lookups_total",
+		Help:      "Cache lookups of every tier, by hit or miss.",
+	}, []string{"tier", "result"})
+
+	// degradedGauge is 1 while redis calls fail and the service reads the
+	// database
+	degradedGauge = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: constant.ServiceName,
+		Subsystem: "cache",
+		Name:      "degraded",
+		Help:      "1 while redis calls fail and the service reads the database.",
+	})
+
+	// degradedCalls count the calls served without redis
+	degradedCalls = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: constant.ServiceName,
+		Subsystem: "cache",
+		Name:      "degraded_calls_total",
+		Help:      "Cache calls that failed and were served by the database or dropped.",
+	})
+)
+
+func init() {
+	prometheus.MustRegister(lookups, degradedGauge, degradedCalls)
+}
+
+func count(tier string, hit bool) {
+	if hit {
+		lookups.WithLabelValues(tier, "hit").Inc()
+		return
+	}
+	lookups.WithLabelValues(tier, "miss").Inc()
+}
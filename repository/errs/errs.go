@@ -0,0 +1,189 @@
+// Package errs define the errors returned by every repository
+// implementation, each of them carries its own grpc status so the
+// gateway derives the http status from the same mapping
+//
+//	ErrNotFound            codes.NotFound           404
+//	ErrConflict            codes.AlreadyExists      409
+//	ErrForeignKeyViolation codes.FailedPrecondition 400
+//	ErrStaleVersion        codes.Aborted            409
+//	ErrInvalidArgument     codes.InvalidArgument    400
+package errs
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/muhammadisa/bareksanews/constant"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var (
+	// ErrNotFound the requested entity does not exist
+	ErrNotFound = errors.New("not found")
+
+	// ErrConflict an entity with the same key already exists
+	ErrConflict = errors.New("already exists")
+
+	// ErrForeignKeyViolation the entity references an unknown entity or
+	// is still referenced by another one
+	ErrForeignKeyViolation = errors.New("foreign key violation")
+
+	// ErrStaleVersion the entity was modified since the version sent
+	ErrStaleVersion = errors.New("stale version")
+
+	// ErrInvalidArgument a field of the entity holds a value the database
+	// cannot store
+	ErrInvalidArgument = errors.New("invalid argument")
+
+	// ErrCacheMiss the cache holds no entry for the key, the service falls
+	// back to the database so it never reaches a client
+	ErrCacheMiss = errors.New("cache miss")
+
+	// ErrCacheUnavailable the cache failed or its circuit is open, the
+	// service reads the database instead so it never reaches a client
+	ErrCacheUnavailable = errors.New("cache unavailable")
+
+	// ErrRollbackFailed a failed batch could not be undone, part of it may
+	// be stored so its records must not be retried one by one
+	ErrRollbackFailed = errors.New("rollback failed")
+)
+
+const (
+	reasonNotFound            = `NOT_FOUND`
+	reasonConflict            = `ALREADY_EXISTS`
+	reasonForeignKeyViolation = `FOREIGN_KEY_VIOLATION`
+	reasonStaleVersion        = `STALE_VERSION`
+	reasonInvalidArgument     = `INVALID_ARGUMENT`
+)
+
+// Error describe a failed repository operation on one entity, use
+// errors.Is against the sentinel errors to check its kind
+type Error struct {
+	Kind    error
+	Entity  string
+	ID      string
+	Field   string
+	Version int64
+	Cause   error
+}
+
+func (e *Error) Error() string {
+	msg := e.Entity
+	if e.ID != "" {
+		msg = fmt.Sprintf("%s %s", msg, e.ID)
+	}
+	switch e.Kind {
+	case ErrForeignKeyViolation:
+		msg = fmt.Sprintf("%s : %s on %s", msg, e.Kind, e.Field)
+	case ErrStaleVersion:
+		msg = fmt.Sprintf("%s : version %d is stale, it was modified by someone else", msg, e.Version)
+	case ErrInvalidArgument:
+		msg = fmt.Sprintf("%s : %s %s, %s", msg, e.Kind, e.Field, e.Cause)
+	default:
+		msg = fmt.Sprintf("%s : %s", msg, e.Kind)
+	}
+	return msg
+}
+
+func (e *Error) Unwrap() error {
+	return e.Kind
+}
+
+// GRPCStatus map the error to its grpc status with error details attached
+func (e *Error) GRPCStatus() *status.Status {
+	code, reason := codes.Unknown, ""
+	switch e.Kind {
+	case ErrNotFound:
+		code, reason = codes.NotFound, reasonNotFound
+	case ErrConflict:
+		code, reason = codes.AlreadyExists, reasonConflict
+	case ErrForeignKeyViolation:
+		code, reason = codes.FailedPrecondition, reasonForeignKeyViolation
+	case ErrStaleVersion:
+		code, reason = codes.Aborted, reasonStaleVersion
+	case ErrInvalidArgument:
+		code, reason = codes.InvalidArgument, reasonInvalidArgument
+	}
+	st := status.New(code, e.Error())
+
+	metadata := map[string]string{"entity": e.Entity}
+	if e.ID != "" {
+		metadata["id"] = e.ID
+	}
+	if e.Field != "" {
+		metadata["field"] = e.Field
+	}
+	if e.Kind == ErrStaleVersion {
+		metadata["version"] = fmt.Sprint(e.Version)
+	}
+	withInfo, err := st.WithDetails(&errdetails.ErrorInfo{
+		Reason:   reason,
+		Domain:   constant.ServiceName,
+		Metadata: metadata,
+	})
+	if err != nil {
+		return st
+	}
+	if e.Kind == ErrForeignKeyViolation {
+		withDetails, err := withInfo.WithDetails(&errdetails.PreconditionFailure{
+			Violations: []*errdetails.PreconditionFailure_Violation{{
+				Type:        reason,
+				Subject:     e.Field,
+				Description: e.Error(),
+			}},
+		})
+		if err != nil {
+			return withInfo
+		}
+		return withDetails
+	}
+	if e.Kind == ErrInvalidArgument {
+		withDetails, err := withInfo.WithDetails(&errdetails.BadRequest{
+			FieldViolations: []*errdetails.BadRequest_FieldViolation{{
+				Field:       e.Field,
+				Description: e.Cause.Error(),
+			}},
+		})
+		if err != nil {
+			return withInfo
+		}
+		return withDetails
+	}
+	withDetails, err := withInfo.WithDetails(&errdetails.ResourceInfo{
+		ResourceType: e.Entity,
+		ResourceName: e.ID,
+		Description:  e.Error(),
+	})
+	if err != nil {
+		return withInfo
+	}
+	return withDetails
+}
+
+// NotFound the entity identified by id does not exist
+func NotFound(entity, id string) error {
+	return &Error{Kind: ErrNotFound, Entity: entity, ID: id}
+}
+
+// Conflict an entity identified by id already exists
+func Conflict(entity, id string, cause error) error {
+	return &Error{Kind: ErrConflict, Entity: entity, ID: id, Cause: cause}
+}
+
+// ForeignKeyViolation the column named by field, written as table.column,
+// references an unknown entity or still references the removed entity
+func ForeignKeyViolation(entity, id, field string, cause error) error {
+	return &Error{Kind: ErrForeignKeyViolation, Entity: entity, ID: id, Field: field, Cause: cause}
+}
+
+// StaleVersion the entity was edited with an outdated version
+func StaleVersion(entity, id string, version int64) error {
+	return &Error{Kind: ErrStaleVersion, Entity: entity, ID: id, Version: version}
+}
+
+// InvalidArgument the value of field can not be stored, cause tells why
+func InvalidArgument(entity, id, field string, cause error) error {
+	return &Error{Kind: ErrInvalidArgument, Entity: entity, ID: id, Field: field, Cause: cause}
+}
@@ -0,0 +1,102 @@
+package errs
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type errsTestSuite struct {
+	suite.Suite
+}
+
+func TestErrsTestSuite(t *testing.T) {
+	suite.Run(t, new(errsTestSuite))
+}
+
+func (ts *errsTestSuite) TestGRPCStatus() {
+	cause := errors.New("driver error")
+
+	// test case
+	tests := []struct {
+		Name         string
+		Err          error
+		Kind         error
+		Code         codes.Code
+		Reason       string
+		Precondition bool
+		BadRequest   bool
+	}{
+		{
+			Name:   "not found",
+			Err:    NotFound("news", "id_1"),
+			Kind:   ErrNotFound,
+			Code:   codes.NotFound,
+			Reason: reasonNotFound,
+		},
+		{
+			Name:   "conflict",
+			Err:    Conflict("tag", "id_1", cause),
+			Kind:   ErrConflict,
+			Code:   codes.AlreadyExists,
+			Reason: reasonConflict,
+		},
+		{
+			Name:         "foreign key violation",
+			Err:          ForeignKeyViolation("news", "id_1", "news.topic_id", cause),
+			Kind:         ErrForeignKeyViolation,
+			Code:         codes.FailedPrecondition,
+			Reason:       reasonForeignKeyViolation,
+			Precondition: true,
+		},
+		{
+			Name:   "stale version",
+			Err:    StaleVersion("topic", "id_1", 3),
+			Kind:   ErrStaleVersion,
+			Code:   codes.Aborted,
+			Reason: reasonStaleVersion,
+		},
+		{
+			Name:       "invalid argument",
+			Err:        InvalidArgument("news", "id_1", "summary", errors.New("longer than 1024 runes")),
+			Kind:       ErrInvalidArgument,
+			Code:       codes.InvalidArgument,
+			Reason:     reasonInvalidArgument,
+			BadRequest: true,
+		},
+	}
+
+	for _, test := range tests {
+		ts.Run(test.Name, func() {
+			ts.Assert().True(errors.Is(test.Err, test.Kind))
+
+			st, ok := status.FromError(test.Err)
+			ts.Require().True(ok)
+			ts.Assert().Equal(test.Code, st.Code())
+
+			details := st.Details()
+			ts.Require().Len(details, 2)
+			info, ok := details[0].(*errdetails.ErrorInfo)
+			ts.Require().True(ok)
+			ts.Assert().Equal(test.Reason, info.Reason)
+			ts.Assert().Equal("id_1", info.Metadata["id"])
+			if test.Precondition {
+				violation, ok := details[1].(*errdetails.PreconditionFailure)
+				ts.Require().True(ok)
+				ts.Assert().Equal("news.topic_id", violation.Violations[0].Subject)
+			} else if test.BadRequest {
+				badRequest, ok := details[1].(*errdetails.BadRequest)
+				ts.Require().True(ok)
+				ts.Assert().Equal("summary", badRequest.FieldViolations[0].Field)
+			} else {
+				resource, ok := details[1].(*errdetails.ResourceInfo)
+				ts.Require().True(ok)
+				ts.Assert().Equal("id_1", resource.ResourceName)
+			}
+		})
+	}
+}
@@ -2,10 +2,14 @@ package _interface
 
 import (
 	"context"
+	"time"
 
+	"github.com/muhammadisa/bareksanews/model"
 	pb "github.com/muhammadisa/bareksanews/protoc/api/v1"
 )
 
+// ReadWrite persist tags, topics and newses, failures with a known meaning
+// are reported using the errors of repository/errs package
 type ReadWrite interface {
 	WriteTag(ctx context.Context, req *pb.Tag) (*pb.Tag, error)
 	ModifyTag(ctx context.Context, req *pb.Tag) (*pb.Tag, error)
@@ -18,16 +22,22 @@ type ReadWrite interface {
 	ReadTopics(ctx context.Context) (*pb.Topics, error)
 
 	WriteNews(ctx context.Context, req *pb.News) (*pb.News, error)
+	WriteNewsBatch(ctx context.Context, newses []*pb.News) error
 	ModifyNews(ctx context.Context, req *pb.News) (*pb.News, error)
 	RemoveNews(ctx context.Context, req *pb.Select) error
-	ReadNewses(ctx context.Context) (*pb.Newses, error)
-	ReadNewsesByStatus(ctx context.Context, status int32) (*pb.Newses, error)
-	ReadNewsesByTopicID(ctx context.Context, topicID string) (*pb.Newses, error)
-	ReadNewsesByStatusAndTopicID(ctx context.Context, status int32, topicID string) (*pb.Newses, error)
+	ReadNewses(ctx context.Context, page model.Page) (*pb.Newses, error)
+	ReadNewsesByStatus(ctx context.Context, status int32, page model.Page) (*pb.Newses, error)
+	ReadNewsesByTopicID(ctx context.Context, topicID string, page model.Page) (*pb.Newses, error)
+	ReadNewsesByStatusAndTopicID(ctx context.Context, status int32, topicID string, page model.Page) (*pb.Newses, error)
 
 	RemoveNewsTagsByNewsID(ctx context.Context, req *pb.Select) error
 	WriteNewsTags(ctx context.Context, newsID string, tagIDs []string, new bool) error
 	ReadNewsTagsTagIDAndTagByNewsID(ctx context.Context, newsID string, all bool) (res []string)
+
+	// Ping report whether the database answers
+	Ping(ctx context.Context) error
+	// Close release the connections once every call returned
+	Close() error
 }
 
 type Cache interface {
@@ -41,12 +51,33 @@ type Cache interface {
 	GetTopics(ctx context.Context) (*pb.Topics, error)
 	ReloadTopics(ctx context.Context, topics *pb.Topics) error
 
+	// NewsesKey the key of the newses listed with filter, GetNewses
+	// reports errs.ErrCacheMiss for a key without newses
+	NewsesKey(ctx context.Context, filter string) (string, error)
+	GetNewses(ctx context.Context, key string) (*pb.Newses, error)
+	SetNewses(ctx context.Context, key string, newses *pb.Newses) error
+	// InvalidateNewses drop the newses of every filter at once
 	InvalidateNewses(ctx context.Context) error
-	ReloadRequired(ctx context.Context, filter string) bool
-	UnsetNews(ctx context.Context, id string) error
-	GetNewses(ctx context.Context) (res *pb.Newses, err error)
-	ReloadNewses(ctx context.Context, newses *pb.Newses) error
+	// GetStaleNewses the last newses listed with filter and the key they
+	// were listed under, they outlive InvalidateNewses to be served while
+	// the listing of a newer key is reloaded
+	GetStaleNewses(ctx context.Context, filter string) (newses *pb.Newses, key string, err error)
+	SetStaleNewses(ctx context.Context, filter, key string, newses *pb.Newses) error
+
+	// Lock take the lock of key for ttl unless another holder has it, the
+	// returned token releases it through Unlock
+	Lock(ctx context.Context, key string, ttl time.Duration) (token string, locked bool, err error)
+	Unlock(ctx context.Context, key, token string) error
+
+	// ListKeys the first limit keys of the cache namespace, truncated
+	// reports more keys were left out
+	ListKeys(ctx context.Context, limit int) (keys []string, truncated bool, err error)
+	// FlushKeys delete every key of the cache namespace and nothing else,
+	// the newses generation is bumped rather than deleted
+	FlushKeys(ctx context.Context) (deleted int64, err error)
 
-	GetFilter(ctx context.Context) string
-	SetFilter(ctx context.Context, filter string) error
+	// Ping report whether the cache answers
+	Ping(ctx context.Context) error
+	// Close release the connections once every call returned
+	Close() error
 }
@@ -0,0 +1,155 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/muhammadisa/bareksanews/constant"
+	"github.com/muhammadisa/bareksanews/model"
+	pb "github.com/muhammadisa/bareksanews/protoc/api/v1"
+	_interface "github.com/muhammadisa/bareksanews/repository/interface"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// queryDuration time every call of the ReadWriter took, a call may run more
+// than one query
+var queryDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+	Namespace: constant.ServiceName,
+	Subsystem: "repository",
+	Name:      "query_duration_seconds",
+	Help:      "Time every database call took, by driver, operation and result.",
+	Buckets:   prometheus.DefBuckets,
+}, []string{"driver", "operation", "result"})
+
+func init() {
+	prometheus.MustRegister(queryDuration)
+}
+
+// timedReadWriter observe the duration of every call of next, Ping and
+// Close are left out
+type timedReadWriter struct {
+	next   _interface.ReadWrite
+	driver string
+}
+
+func newTimedReadWriter(next _interface.ReadWrite, driver string) *timedReadWriter {
+	if driver == "" {
+		driver = constant.DriverMySQL
+	}
+	return &timedReadWriter{next: next, driver: driver}
+}
+
+// observe record the call of operation started at begin, to be deferred
+// with the named error of the call
+func (r *timedReadWriter) observe(operation string, begin time.Time, err *error) {
+	result := "ok"
+	if *err != nil {
+		result = "error"
+	}
+	queryDuration.WithLabelValues(r.driver, operation, result).Observe(time.Since(begin).Seconds())
+}
+
+func (r *timedReadWriter) WriteTag(ctx context.Context, req *pb.Tag) (res *pb.Tag, err error) {
+	defer r.observe("WriteTag", time.Now(), &err)
+	return r.next.WriteTag(ctx, req)
+}
+
+func (r *timedReadWriter) ModifyTag(ctx context.Context, req *pb.Tag) (res *pb.Tag, err error) {
+	defer r.observe("ModifyTag", time.Now(), &err)
+	return r.next.ModifyTag(ctx, req)
+}
+
+func (r *timedReadWriter) RemoveTag(ctx context.Context, req *pb.Select) (err error) {
+	defer r.observe("RemoveTag", time.Now(), &err)
+	return r.next.RemoveTag(ctx, req)
+}
+
+func (r *timedReadWriter) ReadTags(ctx context.Context) (res *pb.Tags, err error) {
+	defer r.observe("ReadTags", time.Now(), &err)
+	return r.next.ReadTags(ctx)
+}
+
+func (r *timedReadWriter) WriteTopic(ctx context.Context, req *pb.Topic) (res *pb.Topic, err error) {
+	defer r.observe("WriteTopic", time.Now(), &err)
+	return r.next.WriteTopic(ctx, req)
+}
+
+func (r *timedReadWriter) ModifyTopic(ctx context.Context, req *pb.Topic) (res *pb.Topic, err error) {
+	defer r.observe("ModifyTopic", time.Now(), &err)
+	return r.next.ModifyTopic(ctx, req)
+}
+
+func (r *timedReadWriter) RemoveTopic(ctx context.Context, req *pb.Select) (err error) {
+	defer r.observe("RemoveTopic", time.Now(), &err)
+	return r.next.RemoveTopic(ctx, req)
+}
+
+func (r *timedReadWriter) ReadTopics(ctx context.Context) (res *pb.Topics, err error) {
+	defer r.observe("ReadTopics", time.Now(), &err)
+	return r.next.ReadTopics(ctx)
+}
+
+func (r *timedReadWriter) WriteNews(ctx context.Context, req *pb.News) (res *pb.News, err error) {
+	defer r.observe("WriteNews", time.Now(), &err)
+	return r.next.WriteNews(ctx, req)
+}
+
+func (r *timedReadWriter) WriteNewsBatch(ctx context.Context, newses []*pb.News) (err error) {
+	defer r.observe("WriteNewsBatch", time.Now(), &err)
+	return r.next.WriteNewsBatch(ctx, newses)
+}
+
+func (r *timedReadWriter) ModifyNews(ctx context.Context, req *pb.News) (res *pb.News, err error) {
+	defer r.observe("ModifyNews", time.Now(), &err)
+	return r.next.ModifyNews(ctx, req)
+}
+
+func (r *timedReadWriter) RemoveNews(ctx context.Context, req *pb.Select) (err error) {
+	defer r.observe("RemoveNews", time.Now(), &err)
+	return r.next.RemoveNews(ctx, req)
+}
+
+func (r *timedReadWriter) ReadNewses(ctx context.Context, page model.Page) (res *pb.Newses, err error) {
+	defer r.observe("ReadNewses", time.Now(), &err)
+	return r.next.ReadNewses(ctx, page)
+}
+
+func (r *timedReadWriter) ReadNewsesByStatus(ctx context.Context, status int32, page model.Page) (res *pb.Newses, err error) {
+	defer r.observe("ReadNewsesByStatus", time.Now(), &err)
+	return r.next.ReadNewsesByStatus(ctx, status, page)
+}
+
+func (r *timedReadWriter) ReadNewsesByTopicID(ctx context.Context, topicID string, page model.Page) (res *pb.Newses, err error) {
+	defer r.observe("ReadNewsesByTopicID", time.Now(), &err)
+	return r.next.ReadNewsesByTopicID(ctx, topicID, page)
+}
+
+func (r *timedReadWriter) ReadNewsesByStatusAndTopicID(ctx context.Context, status int32, topicID string, page model.Page) (res *pb.Newses, err error) {
+	defer r.observe("ReadNewsesByStatusAndTopicID", time.Now(), &err)
+	return r.next.ReadNewsesByStatusAndTopicID(ctx, status, topicID, page)
+}
+
+func (r *timedReadWriter) RemoveNewsTagsByNewsID(ctx context.Context, req *pb.Select) (err error) {
+	defer r.observe("RemoveNewsTagsByNewsID", time.Now(), &err)
+	return r.next.RemoveNewsTagsByNewsID(ctx, req)
+}
+
+func (r *timedReadWriter) WriteNewsTags(ctx context.Context, newsID string, tagIDs []string, new bool) (err error) {
+	defer r.observe("WriteNewsTags", time.Now(), &err)
+	return r.next.WriteNewsTags(ctx, newsID, tagIDs, new)
+}
+
+// ReadNewsTagsTagIDAndTagByNewsID reports no error, every call counts as ok
+func (r *timedReadWriter) ReadNewsTagsTagIDAndTagByNewsID(ctx context.Context, newsID string, all bool) []string {
+	var err error
+	defer r.observe("ReadNewsTagsTagIDAndTagByNewsID", time.Now(), &err)
+	return r.next.ReadNewsTagsTagIDAndTagByNewsID(ctx, newsID, all)
+}
+
+func (r *timedReadWriter) Ping(ctx context.Context) error {
+	return r.next.Ping(ctx)
+}
+
+func (r *timedReadWriter) Close() error {
+	return r.next.Close()
+}
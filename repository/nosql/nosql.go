@@ -0,0 +1,142 @@
+package nosql
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/muhammadisa/bareksanews/constant"
+	pb "github.com/muhammadisa/bareksanews/protoc/api/v1"
+	"github.com/muhammadisa/bareksanews/repository/errs"
+	_interface "github.com/muhammadisa/bareksanews/repository/interface"
+	"github.com/muhammadisa/bareksanews/util/dbc"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.opencensus.io/trace"
+)
+
+type readWrite struct {
+	tracer trace.Tracer
+	db     *mongo.Database
+}
+
+// NewNoSQL mongodb ReadWrite, the tags of a news are embedded in the news
+// document instead of a news_tags collection
+func NewNoSQL(config dbc.Config, tracer trace.Tracer) (_interface.ReadWrite, error) {
+	db, err := dbc.OpenNoSQL(config)
+	if err != nil {
+		return nil, err
+	}
+	return &readWrite{tracer: tracer, db: db}, nil
+}
+
+func (r *readWrite) Ping(ctx context.Context) error {
+	return r.db.Client().Ping(ctx, nil)
+}
+
+// Close disconnect the client, the operations in progress are waited for
+// at most constant.NoSQLDisconnectSeconds
+func (r *readWrite) Close() error {
+	ctx, cancel := context.WithTimeout(context.Background(), constant.NoSQLDisconnectSeconds*time.Second)
+	defer cancel()
+	return r.db.Client().Disconnect(ctx)
+}
+
+func (r *readWrite) tags() *mongo.Collection {
+	return r.db.Collection(constant.TagsCollection)
+}
+
+func (r *readWrite) topics() *mongo.Collection {
+	return r.db.Collection(constant.TopicsCollection)
+}
+
+func (r *readWrite) newses() *mongo.Collection {
+	return r.db.Collection(constant.NewsCollection)
+}
+
+// translate map mongodb failures into the errors of repository/errs package
+func translate(err error, entity, id string) error {
+	switch {
+	case errors.Is(err, mongo.ErrNoDocuments):
+		return errs.NotFound(entity, id)
+	case mongo.IsDuplicateKeyError(err):
+		return errs.Conflict(entity, id, err)
+	}
+	return err
+}
+
+type tagDocument struct {
+	ID      string    `bson:"_id"`
+	Tag     string    `bson:"tag"`
+	Version int64     `bson:"version"`
+	Created time.Time `bson:"created_at"`
+	Updated time.Time `bson:"updated_at"`
+}
+
+func (doc tagDocument) proto() *pb.Tag {
+	return &pb.Tag{
+		Id:        doc.ID,
+		Tag:       doc.Tag,
+		Version:   doc.Version,
+		CreatedAt: doc.Created.Unix(),
+		UpdatedAt: doc.Updated.Unix(),
+	}
+}
+
+type topicDocument struct {
+	ID       string    `bson:"_id"`
+	Title    string    `bson:"title"`
+	Headline string    `bson:"headline"`
+	Version  int64     `bson:"version"`
+	Created  time.Time `bson:"created_at"`
+	Updated  time.Time `bson:"updated_at"`
+}
+
+func (doc topicDocument) proto() *pb.Topic {
+	return &pb.Topic{
+		Id:        doc.ID,
+		Title:     doc.Title,
+		Headline:  doc.Headline,
+		Version:   doc.Version,
+		CreatedAt: doc.Created.Unix(),
+		UpdatedAt: doc.Updated.Unix(),
+	}
+}
+
+// newsTagDocument tag embedded in a news, the name is copied so reading a
+// news does not need to look the tags up
+type newsTagDocument struct {
+	ID  string `bson:"id"`
+	Tag string `bson:"tag"`
+}
+
+type newsDocument struct {
+	ID      string            `bson:"_id"`
+	TopicID string            `bson:"topic_id"`
+	Title   string            `bson:"title"`
+	Content string            `bson:"content"`
+	Summary string            `bson:"summary"`
+	Status  int32             `bson:"status"`
+	Tags    []newsTagDocument `bson:"tags"`
+	Version int64             `bson:"version"`
+	Created time.Time         `bson:"created_at"`
+	Updated time.Time         `bson:"updated_at"`
+}
+
+func (doc newsDocument) proto() *pb.News {
+	news := &pb.News{
+		Id:        doc.ID,
+		TopicId:   doc.TopicID,
+		Title:     doc.Title,
+		Content:   doc.Content,
+		Summary:   doc.Summary,
+		Status:    doc.Status,
+		Version:   doc.Version,
+		CreatedAt: doc.Created.Unix(),
+		UpdatedAt: doc.Updated.Unix(),
+	}
+	for _, tag := range doc.Tags {
+		news.NewsTagIds = append(news.NewsTagIds, tag.ID)
+		news.NewsTagNames = append(news.NewsTagNames, tag.Tag)
+	}
+	return news
+}
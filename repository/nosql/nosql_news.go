@@ -0,0 +1,260 @@
+package nosql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/muhammadisa/bareksanews/model"
+	pb "github.com/muhammadisa/bareksanews/protoc/api/v1"
+	"github.com/muhammadisa/bareksanews/repository/errs"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// topicExists stand in for the news.topic_id foreign key
+func (r *readWrite) topicExists(ctx context.Context, newsID, topicID string) error {
+	count, err := r.topics().CountDocuments(ctx, bson.M{"_id": topicID}, options.Count().SetLimit(1))
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return errs.ForeignKeyViolation("news", newsID, "news.topic_id", nil)
+	}
+	return nil
+}
+
+func (r *readWrite) WriteNews(ctx context.Context, req *pb.News) (res *pb.News, err error) {
+	const funcName = `WriteNews`
+	_, span := r.tracer.StartSpan(ctx, funcName)
+	defer span.End()
+
+	if err = r.topicExists(ctx, req.Id, req.TopicId); err != nil {
+		return res, err
+	}
+	currentTime := time.Now()
+	_, err = r.newses().InsertOne(ctx, newsDocument{
+		ID:      req.Id,
+		TopicID: req.TopicId,
+		Title:   req.Title,
+		Content: req.Content,
+		Summary: req.Summary,
+		Status:  req.Status,
+		Tags:    []newsTagDocument{},
+		Version: 1,
+		Created: currentTime,
+		Updated: currentTime,
+	})
+	if err != nil {
+		return res, translate(err, "news", req.Id)
+	}
+	req.CreatedAt = currentTime.Unix()
+	req.UpdatedAt = currentTime.Unix()
+	req.Version = 1
+	return req, nil
+}
+
+// WriteNewsBatch insert the newses with their tags embedded, mongodb
+// without a replica set has no transaction so the inserted newses are
+// removed again when the batch fails
+func (r *readWrite) WriteNewsBatch(ctx context.Context, newses []*pb.News) error {
+	const funcName = `WriteNewsBatch`
+	_, span := r.tracer.StartSpan(ctx, funcName)
+	defer span.End()
+
+	if len(newses) == 0 {
+		return nil
+	}
+	currentTime := time.Now()
+	docs := make([]interface{}, 0, len(newses))
+	ids := make([]string, 0, len(newses))
+	for _, news := range newses {
+		if err := r.topicExists(ctx, news.Id, news.TopicId); err != nil {
+			return err
+		}
+		tags, err := r.lookupNewsTags(ctx, news.Id, news.NewsTagIds)
+		if err != nil {
+			return err
+		}
+		docs = append(docs, newsDocument{
+			ID:      news.Id,
+			TopicID: news.TopicId,
+			Title:   news.Title,
+			Content: news.Content,
+			Summary: news.Summary,
+			Status:  news.Status,
+			Tags:    tags,
+			Version: 1,
+			Created: currentTime,
+			Updated: currentTime,
+		})
+		ids = append(ids, news.Id)
+	}
+	_, err := r.newses().InsertMany(ctx, docs, options.InsertMany().SetOrdered(true))
+	if err != nil {
+		if rollbackErr := r.removeInserted(ctx, insertedIDs(err, ids), currentTime); rollbackErr != nil {
+			return fmt.Errorf("%w: %v after %v", errs.ErrRollbackFailed, rollbackErr, err)
+		}
+		return translate(err, "news", "")
+	}
+	for _, news := range newses {
+		news.CreatedAt = currentTime.Unix()
+		news.UpdatedAt = currentTime.Unix()
+		news.Version = 1
+	}
+	return nil
+}
+
+// insertedIDs the ids an ordered InsertMany wrote before failing with err,
+// the documents before the first write error, every id when err does not
+// tell
+func insertedIDs(err error, ids []string) []string {
+	var bulk mongo.BulkWriteException
+	if !errors.As(err, &bulk) || len(bulk.WriteErrors) == 0 {
+		return ids
+	}
+	first := len(ids)
+	for _, writeErr := range bulk.WriteErrors {
+		if writeErr.Index < first {
+			first = writeErr.Index
+		}
+	}
+	return ids[:first]
+}
+
+// removeInserted undo a failed batch, created_at keeps the documents of
+// the same ids stored before the batch
+func (r *readWrite) removeInserted(ctx context.Context, ids []string, created time.Time) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	_, err := r.newses().DeleteMany(ctx, bson.M{"_id": bson.M{"$in": ids}, "created_at": created})
+	return err
+}
+
+func (r *readWrite) ModifyNews(ctx context.Context, req *pb.News) (res *pb.News, err error) {
+	const funcName = `ModifyNews`
+	_, span := r.tracer.StartSpan(ctx, funcName)
+	defer span.End()
+
+	var oldNews newsDocument
+	err = r.newses().FindOne(ctx, bson.M{"_id": req.Id}).Decode(&oldNews)
+	if err != nil {
+		return res, translate(err, "news", req.Id)
+	}
+	if req.Version != 0 && req.Version != oldNews.Version {
+		return res, errs.StaleVersion("news", req.Id, req.Version)
+	}
+	if err = r.topicExists(ctx, req.Id, req.TopicId); err != nil {
+		return res, err
+	}
+
+	currentTime := time.Now()
+	result, err := r.newses().UpdateOne(ctx,
+		bson.M{"_id": req.Id, "version": oldNews.Version},
+		bson.M{
+			"$set": bson.M{
+				"topic_id":   req.TopicId,
+				"title":      req.Title,
+				"content":    req.Content,
+				"summary":    req.Summary,
+				"status":     req.Status,
+				"updated_at": currentTime,
+			},
+			"$inc": bson.M{"version": 1},
+		},
+	)
+	if err != nil {
+		return res, translate(err, "news", req.Id)
+	}
+	if result.MatchedCount == 0 {
+		return res, errs.StaleVersion("news", req.Id, oldNews.Version)
+	}
+	req.CreatedAt = oldNews.Created.Unix()
+	req.UpdatedAt = currentTime.Unix()
+	req.Version = oldNews.Version + 1
+	return req, nil
+}
+
+func (r *readWrite) RemoveNews(ctx context.Context, req *pb.Select) error {
+	const funcName = `RemoveNews`
+	_, span := r.tracer.StartSpan(ctx, funcName)
+	defer span.End()
+
+	result, err := r.newses().DeleteOne(ctx, bson.M{"_id": req.Id})
+	if err != nil {
+		return translate(err, "news", req.Id)
+	}
+	if result.DeletedCount == 0 {
+		return errs.NotFound("news", req.Id)
+	}
+	return nil
+}
+
+func (r *readWrite) findNewses(ctx context.Context, filter bson.M, page model.Page) (res *pb.Newses, err error) {
+	const funcName = `findNewses`
+	_, span := r.tracer.StartSpan(ctx, funcName)
+	defer span.End()
+
+	findOptions := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}})
+	if page.Limit > 0 {
+		findOptions.SetLimit(int64(page.Limit)).SetSkip(int64(page.Offset))
+	}
+	if page.SummaryOnly {
+		// only the newses still to be summarized carry their content
+		findOptions.SetProjection(bson.M{
+			"topic_id": 1, "title": 1, "summary": 1, "status": 1, "tags": 1,
+			"version": 1, "created_at": 1, "updated_at": 1,
+			"content": bson.M{"$cond": bson.A{
+				bson.M{"$eq": bson.A{bson.M{"$ifNull": bson.A{"$summary", ""}}, ""}}, "$content", "$$REMOVE",
+			}},
+		})
+	}
+	cursor, err := r.newses().Find(ctx, filter, findOptions)
+	if err != nil {
+		return res, err
+	}
+	var docs []newsDocument
+	if err = cursor.All(ctx, &docs); err != nil {
+		return res, err
+	}
+	var newses pb.Newses
+	for _, doc := range docs {
+		newses.Newses = append(newses.Newses, doc.proto())
+	}
+	return &newses, nil
+}
+
+func (r *readWrite) ReadNewsesByStatusAndTopicID(ctx context.Context, status int32, topicID string, page model.Page) (res *pb.Newses, err error) {
+	const funcName = `ReadNewsesByStatusAndTopicID`
+	_, span := r.tracer.StartSpan(ctx, funcName)
+	defer span.End()
+
+	return r.findNewses(ctx, bson.M{"topic_id": topicID, "status": status}, page)
+}
+
+func (r *readWrite) ReadNewsesByTopicID(ctx context.Context, topicID string, page model.Page) (res *pb.Newses, err error) {
+	const funcName = `ReadNewsesByTopicID`
+	_, span := r.tracer.StartSpan(ctx, funcName)
+	defer span.End()
+
+	return r.findNewses(ctx, bson.M{"topic_id": topicID, "status": 1}, page)
+}
+
+func (r *readWrite) ReadNewsesByStatus(ctx context.Context, status int32, page model.Page) (res *pb.Newses, err error) {
+	const funcName = `ReadNewsesByStatus`
+	_, span := r.tracer.StartSpan(ctx, funcName)
+	defer span.End()
+
+	return r.findNewses(ctx, bson.M{"status": status}, page)
+}
+
+func (r *readWrite) ReadNewses(ctx context.Context, page model.Page) (res *pb.Newses, err error) {
+	const funcName = `ReadNewses`
+	_, span := r.tracer.StartSpan(ctx, funcName)
+	defer span.End()
+
+	return r.findNewses(ctx, bson.M{}, page)
+}
@@ -0,0 +1,107 @@
+package nosql
+
+import (
+	"context"
+
+	pb "github.com/muhammadisa/bareksanews/protoc/api/v1"
+	"github.com/muhammadisa/bareksanews/repository/errs"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// lookupNewsTags the tags to embed in a news, in the order of tagIDs, an
+// unknown tag is reported like the news_tags.tag_id foreign key
+func (r *readWrite) lookupNewsTags(ctx context.Context, newsID string, tagIDs []string) ([]newsTagDocument, error) {
+	res := []newsTagDocument{}
+	if len(tagIDs) == 0 {
+		return res, nil
+	}
+	cursor, err := r.tags().Find(ctx, bson.M{"_id": bson.M{"$in": tagIDs}})
+	if err != nil {
+		return nil, err
+	}
+	var docs []tagDocument
+	if err = cursor.All(ctx, &docs); err != nil {
+		return nil, err
+	}
+	names := make(map[string]string, len(docs))
+	for _, doc := range docs {
+		names[doc.ID] = doc.Tag
+	}
+	for _, tagID := range tagIDs {
+		name, ok := names[tagID]
+		if !ok {
+			return nil, errs.ForeignKeyViolation("news", newsID, "news_tags.tag_id", nil)
+		}
+		res = append(res, newsTagDocument{ID: tagID, Tag: name})
+	}
+	return res, nil
+}
+
+func (r *readWrite) RemoveNewsTagsByNewsID(ctx context.Context, req *pb.Select) error {
+	const funcName = `RemoveNewsTagsByNewsID`
+	_, span := r.tracer.StartSpan(ctx, funcName)
+	defer span.End()
+
+	// a news without any tag has nothing to delete, it is not an error
+	_, err := r.newses().UpdateOne(ctx,
+		bson.M{"_id": req.Id},
+		bson.M{"$set": bson.M{"tags": []newsTagDocument{}}},
+	)
+	return err
+}
+
+func (r *readWrite) ReadNewsTagsTagIDAndTagByNewsID(ctx context.Context, newsID string, all bool) (res []string) {
+	const funcName = `ReadNewsTagsTagIDAndTagByNewsID`
+	_, span := r.tracer.StartSpan(ctx, funcName)
+	defer span.End()
+
+	var news newsDocument
+	err := r.newses().FindOne(ctx,
+		bson.M{"_id": newsID},
+		options.FindOne().SetProjection(bson.M{"tags": 1}),
+	).Decode(&news)
+	if err != nil {
+		return nil
+	}
+	for _, tag := range news.Tags {
+		if all {
+			res = append(res, tag.Tag)
+		} else {
+			res = append(res, tag.ID)
+		}
+	}
+	return res
+}
+
+func (r *readWrite) WriteNewsTags(ctx context.Context, newsID string, tagIDs []string, new bool) error {
+	const funcName = `WriteNewsTags`
+	_, span := r.tracer.StartSpan(ctx, funcName)
+	defer span.End()
+
+	if !new {
+		err := r.RemoveNewsTagsByNewsID(ctx, &pb.Select{Id: newsID})
+		if err != nil {
+			return err
+		}
+	}
+	if len(tagIDs) == 0 {
+		return nil
+	}
+
+	tags, err := r.lookupNewsTags(ctx, newsID, tagIDs)
+	if err != nil {
+		return err
+	}
+	result, err := r.newses().UpdateOne(ctx,
+		bson.M{"_id": newsID},
+		bson.M{"$push": bson.M{"tags": bson.M{"$each": tags}}},
+	)
+	if err != nil {
+		return translate(err, "news", newsID)
+	}
+	if result.MatchedCount == 0 {
+		return errs.NotFound("news", newsID)
+	}
+	return nil
+}
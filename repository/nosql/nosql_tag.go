@@ -0,0 +1,122 @@
+package nosql
+
+import (
+	"context"
+	"time"
+
+	pb "github.com/muhammadisa/bareksanews/protoc/api/v1"
+	"github.com/muhammadisa/bareksanews/repository/errs"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func (r *readWrite) WriteTag(ctx context.Context, req *pb.Tag) (res *pb.Tag, err error) {
+	const funcName = `WriteTag`
+	_, span := r.tracer.StartSpan(ctx, funcName)
+	defer span.End()
+
+	currentTime := time.Now()
+	_, err = r.tags().InsertOne(ctx, tagDocument{
+		ID:      req.Id,
+		Tag:     req.Tag,
+		Version: 1,
+		Created: currentTime,
+		Updated: currentTime,
+	})
+	if err != nil {
+		return res, translate(err, "tag", req.Id)
+	}
+	req.CreatedAt = currentTime.Unix()
+	req.UpdatedAt = currentTime.Unix()
+	req.Version = 1
+	return req, nil
+}
+
+func (r *readWrite) ModifyTag(ctx context.Context, req *pb.Tag) (res *pb.Tag, err error) {
+	const funcName = `ModifyTag`
+	_, span := r.tracer.StartSpan(ctx, funcName)
+	defer span.End()
+
+	var oldTag tagDocument
+	err = r.tags().FindOne(ctx, bson.M{"_id": req.Id}).Decode(&oldTag)
+	if err != nil {
+		return res, translate(err, "tag", req.Id)
+	}
+	if req.Version != 0 && req.Version != oldTag.Version {
+		return res, errs.StaleVersion("tag", req.Id, req.Version)
+	}
+
+	currentTime := time.Now()
+	result, err := r.tags().UpdateOne(ctx,
+		bson.M{"_id": req.Id, "version": oldTag.Version},
+		bson.M{
+			"$set": bson.M{"tag": req.Tag, "updated_at": currentTime},
+			"$inc": bson.M{"version": 1},
+		},
+	)
+	if err != nil {
+		return res, translate(err, "tag", req.Id)
+	}
+	if result.MatchedCount == 0 {
+		return res, errs.StaleVersion("tag", req.Id, oldTag.Version)
+	}
+	// keep the name embedded in the tagged newses in sync
+	_, err = r.newses().UpdateMany(ctx,
+		bson.M{"tags.id": req.Id},
+		bson.M{"$set": bson.M{"tags.$[tag].tag": req.Tag}},
+		options.Update().SetArrayFilters(options.ArrayFilters{
+			Filters: []interface{}{bson.M{"tag.id": req.Id}},
+		}),
+	)
+	if err != nil {
+		return res, err
+	}
+	req.CreatedAt = oldTag.Created.Unix()
+	req.UpdatedAt = currentTime.Unix()
+	req.Version = oldTag.Version + 1
+	return req, nil
+}
+
+func (r *readWrite) RemoveTag(ctx context.Context, req *pb.Select) error {
+	const funcName = `RemoveTag`
+	_, span := r.tracer.StartSpan(ctx, funcName)
+	defer span.End()
+
+	// a tag still used by a news can not be removed, like the news_tags
+	// foreign key of the sql repository
+	tagged, err := r.newses().CountDocuments(ctx, bson.M{"tags.id": req.Id}, options.Count().SetLimit(1))
+	if err != nil {
+		return err
+	}
+	if tagged > 0 {
+		return errs.ForeignKeyViolation("tag", req.Id, "news_tags.tag_id", nil)
+	}
+	result, err := r.tags().DeleteOne(ctx, bson.M{"_id": req.Id})
+	if err != nil {
+		return translate(err, "tag", req.Id)
+	}
+	if result.DeletedCount == 0 {
+		return errs.NotFound("tag", req.Id)
+	}
+	return nil
+}
+
+func (r *readWrite) ReadTags(ctx context.Context) (res *pb.Tags, err error) {
+	const funcName = `ReadTags`
+	_, span := r.tracer.StartSpan(ctx, funcName)
+	defer span.End()
+
+	cursor, err := r.tags().Find(ctx, bson.M{}, options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}}))
+	if err != nil {
+		return res, err
+	}
+	var docs []tagDocument
+	if err = cursor.All(ctx, &docs); err != nil {
+		return res, err
+	}
+	var tags pb.Tags
+	for _, doc := range docs {
+		tags.Tags = append(tags.Tags, doc.proto())
+	}
+	return &tags, nil
+}
@@ -0,0 +1,241 @@
+package nosql
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/muhammadisa/bareksanews/model"
+	pb "github.com/muhammadisa/bareksanews/protoc/api/v1"
+	"github.com/muhammadisa/bareksanews/repository/errs"
+	"github.com/stretchr/testify/suite"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+	"go.opencensus.io/trace"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type nosqlTestSuite struct {
+	suite.Suite
+	mt *mtest.T
+}
+
+func TestNoSQLTestSuite(t *testing.T) {
+	suite.Run(t, new(nosqlTestSuite))
+}
+
+func (ts *nosqlTestSuite) SetupTest() {
+	ts.mt = mtest.New(ts.T(), mtest.NewOptions().ClientType(mtest.Mock))
+}
+
+func (ts *nosqlTestSuite) TearDownTest() {
+	ts.mt.Close()
+}
+
+// run the test against a mocked deployment answering responses in order
+func (ts *nosqlTestSuite) run(name string, test func(repository *readWrite, mt *mtest.T)) {
+	ts.mt.Run(name, func(mt *mtest.T) {
+		test(&readWrite{tracer: trace.DefaultTracer, db: mt.DB}, mt)
+	})
+}
+
+func countResponse(mt *mtest.T, collection string, count int64) bson.D {
+	ns := mt.DB.Name() + "." + collection
+	if count == 0 {
+		return mtest.CreateCursorResponse(0, ns, mtest.FirstBatch)
+	}
+	return mtest.CreateCursorResponse(0, ns, mtest.FirstBatch, bson.D{{Key: "n", Value: count}})
+}
+
+func (ts *nosqlTestSuite) TestWriteTag() {
+	ctx := context.Background()
+	defer ctx.Done()
+
+	ts.run("write tag success", func(repository *readWrite, mt *mtest.T) {
+		mt.AddMockResponses(mtest.CreateSuccessResponse())
+		tag, err := repository.WriteTag(ctx, &pb.Tag{Id: "tag_1", Tag: "game"})
+		ts.Require().NoError(err)
+		ts.Assert().Equal(int64(1), tag.Version)
+	})
+	ts.run("write tag duplicate id", func(repository *readWrite, mt *mtest.T) {
+		mt.AddMockResponses(mtest.CreateWriteErrorsResponse(mtest.WriteError{Index: 0, Code: 11000, Message: "duplicate key"}))
+		_, err := repository.WriteTag(ctx, &pb.Tag{Id: "tag_1", Tag: "game"})
+		ts.Assert().True(errors.Is(err, errs.ErrConflict))
+	})
+}
+
+func (ts *nosqlTestSuite) TestModifyTagStaleVersion() {
+	ctx := context.Background()
+	defer ctx.Done()
+
+	ts.run("modify tag stale version", func(repository *readWrite, mt *mtest.T) {
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, mt.DB.Name()+".tags", mtest.FirstBatch, bson.D{
+			{Key: "_id", Value: "tag_1"},
+			{Key: "tag", Value: "game"},
+			{Key: "version", Value: int64(2)},
+		}))
+		_, err := repository.ModifyTag(ctx, &pb.Tag{Id: "tag_1", Tag: "games", Version: 1})
+		ts.Assert().Equal(codes.Aborted, status.Code(err))
+	})
+	ts.run("modify tag not found", func(repository *readWrite, mt *mtest.T) {
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, mt.DB.Name()+".tags", mtest.FirstBatch))
+		_, err := repository.ModifyTag(ctx, &pb.Tag{Id: "tag_1", Tag: "games"})
+		ts.Assert().True(errors.Is(err, errs.ErrNotFound))
+	})
+}
+
+func (ts *nosqlTestSuite) TestRemoveTagStillUsed() {
+	ctx := context.Background()
+	defer ctx.Done()
+
+	ts.run("remove tag used by a news", func(repository *readWrite, mt *mtest.T) {
+		mt.AddMockResponses(countResponse(mt, "news", 1))
+		err := repository.RemoveTag(ctx, &pb.Select{Id: "tag_1"})
+		ts.Assert().True(errors.Is(err, errs.ErrForeignKeyViolation))
+	})
+	ts.run("remove unknown tag", func(repository *readWrite, mt *mtest.T) {
+		mt.AddMockResponses(
+			countResponse(mt, "news", 0),
+			mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 0}),
+		)
+		err := repository.RemoveTag(ctx, &pb.Select{Id: "tag_1"})
+		ts.Assert().True(errors.Is(err, errs.ErrNotFound))
+	})
+}
+
+func (ts *nosqlTestSuite) TestWriteNewsUnknownTopic() {
+	ctx := context.Background()
+	defer ctx.Done()
+
+	ts.run("write news unknown topic", func(repository *readWrite, mt *mtest.T) {
+		mt.AddMockResponses(countResponse(mt, "topics", 0))
+		_, err := repository.WriteNews(ctx, &pb.News{Id: "news_1", TopicId: "topic_1", Title: "news 1"})
+		ts.Assert().Equal(codes.FailedPrecondition, status.Code(err))
+	})
+}
+
+func (ts *nosqlTestSuite) TestWriteNewsTagsUnknownTag() {
+	ctx := context.Background()
+	defer ctx.Done()
+
+	ts.run("write news tags unknown tag", func(repository *readWrite, mt *mtest.T) {
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, mt.DB.Name()+".tags", mtest.FirstBatch, bson.D{
+			{Key: "_id", Value: "tag_1"},
+			{Key: "tag", Value: "game"},
+		}))
+		err := repository.WriteNewsTags(ctx, "news_1", []string{"tag_1", "tag_2"}, true)
+		ts.Assert().True(errors.Is(err, errs.ErrForeignKeyViolation))
+	})
+}
+
+func (ts *nosqlTestSuite) TestReadNewses() {
+	ctx := context.Background()
+	defer ctx.Done()
+
+	now := time.Now().Truncate(time.Millisecond)
+	ts.run("read newses with embedded tags", func(repository *readWrite, mt *mtest.T) {
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, mt.DB.Name()+".news", mtest.FirstBatch,
+			bson.D{
+				{Key: "_id", Value: "news_1"},
+				{Key: "topic_id", Value: "topic_1"},
+				{Key: "title", Value: "news 1"},
+				{Key: "status", Value: int32(1)},
+				{Key: "tags", Value: bson.A{
+					bson.D{{Key: "id", Value: "tag_1"}, {Key: "tag", Value: "game"}},
+					bson.D{{Key: "id", Value: "tag_2"}, {Key: "tag", Value: "sport"}},
+				}},
+				{Key: "version", Value: int64(3)},
+				{Key: "created_at", Value: now},
+				{Key: "updated_at", Value: now},
+			},
+			bson.D{
+				{Key: "_id", Value: "news_2"},
+				{Key: "topic_id", Value: "topic_1"},
+				{Key: "title", Value: "news 2"},
+				{Key: "status", Value: int32(1)},
+				{Key: "tags", Value: bson.A{}},
+			},
+		))
+		newses, err := repository.ReadNewsesByTopicID(ctx, "topic_1", model.Page{})
+		ts.Require().NoError(err)
+		ts.Require().Len(newses.Newses, 2)
+		ts.Assert().Equal([]string{"tag_1", "tag_2"}, newses.Newses[0].NewsTagIds)
+		ts.Assert().Equal([]string{"game", "sport"}, newses.Newses[0].NewsTagNames)
+		ts.Assert().Equal(int64(3), newses.Newses[0].Version)
+		ts.Assert().Equal(now.Unix(), newses.Newses[0].CreatedAt)
+		ts.Assert().Empty(newses.Newses[1].NewsTagIds)
+	})
+	ts.run("read news summaries", func(repository *readWrite, mt *mtest.T) {
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, mt.DB.Name()+".news", mtest.FirstBatch))
+		_, err := repository.ReadNewses(ctx, model.Page{SummaryOnly: true})
+		ts.Require().NoError(err)
+		projection, ok := mt.GetStartedEvent().Command.Lookup("projection").DocumentOK()
+		ts.Require().True(ok)
+		// the content is only projected for the newses left to be summarized
+		_, err = projection.LookupErr("content", "$cond")
+		ts.Assert().NoError(err)
+		_, err = projection.LookupErr("summary")
+		ts.Assert().NoError(err)
+	})
+}
+
+func (ts *nosqlTestSuite) TestRemoveUnknownNews() {
+	ctx := context.Background()
+	defer ctx.Done()
+
+	ts.run("remove unknown news", func(repository *readWrite, mt *mtest.T) {
+		mt.AddMockResponses(mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 0}))
+		err := repository.RemoveNews(ctx, &pb.Select{Id: "news_1"})
+		ts.Assert().Equal(codes.NotFound, status.Code(err))
+	})
+}
+
+func (ts *nosqlTestSuite) TestWriteNewsBatchRollback() {
+	ctx := context.Background()
+	defer ctx.Done()
+
+	newses := []*pb.News{
+		{Id: "news_1", TopicId: "topic_1", Title: "news 1"},
+		{Id: "news_2", TopicId: "topic_1", Title: "news 2"},
+		{Id: "news_3", TopicId: "topic_1", Title: "news 3"},
+	}
+	ts.run("write news batch removes only the inserted newses", func(repository *readWrite, mt *mtest.T) {
+		mt.AddMockResponses(
+			countResponse(mt, "topics", 1),
+			countResponse(mt, "topics", 1),
+			countResponse(mt, "topics", 1),
+			mtest.CreateWriteErrorsResponse(mtest.WriteError{Index: 1, Code: 11000, Message: "duplicate key"}),
+			mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 1}),
+		)
+		err := repository.WriteNewsBatch(ctx, newses)
+		ts.Require().True(errors.Is(err, errs.ErrConflict))
+		ts.Assert().False(errors.Is(err, errs.ErrRollbackFailed))
+
+		var removed []interface{}
+		for _, event := range mt.GetAllStartedEvents() {
+			if event.CommandName != "delete" {
+				continue
+			}
+			filter := event.Command.Lookup("deletes").Array().Index(0).Value().Document().Lookup("q").Document()
+			ids, err := filter.Lookup("_id", "$in").Array().Values()
+			ts.Require().NoError(err)
+			for _, id := range ids {
+				removed = append(removed, id.StringValue())
+			}
+		}
+		ts.Assert().Equal([]interface{}{"news_1"}, removed)
+	})
+	ts.run("write news batch rollback failure", func(repository *readWrite, mt *mtest.T) {
+		mt.AddMockResponses(
+			countResponse(mt, "topics", 1),
+			countResponse(mt, "topics", 1),
+			countResponse(mt, "topics", 1),
+			mtest.CreateWriteErrorsResponse(mtest.WriteError{Index: 2, Code: 11000, Message: "duplicate key"}),
+			mtest.CreateCommandErrorResponse(mtest.CommandError{Code: 91, Message: "shutting down"}),
+		)
+		err := repository.WriteNewsBatch(ctx, newses)
+		ts.Assert().True(errors.Is(err, errs.ErrRollbackFailed))
+	})
+}
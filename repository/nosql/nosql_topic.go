@@ -0,0 +1,106 @@
+package nosql
+
+import (
+	"context"
+	"time"
+
+	pb "github.com/muhammadisa/bareksanews/protoc/api/v1"
+	"github.com/muhammadisa/bareksanews/repository/errs"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func (r *readWrite) WriteTopic(ctx context.Context, req *pb.Topic) (res *pb.Topic, err error) {
+	const funcName = `WriteTopic`
+	_, span := r.tracer.StartSpan(ctx, funcName)
+	defer span.End()
+
+	currentTime := time.Now()
+	_, err = r.topics().InsertOne(ctx, topicDocument{
+		ID:       req.Id,
+		Title:    req.Title,
+		Headline: req.Headline,
+		Version:  1,
+		Created:  currentTime,
+		Updated:  currentTime,
+	})
+	if err != nil {
+		return res, translate(err, "topic", req.Id)
+	}
+	req.CreatedAt = currentTime.Unix()
+	req.UpdatedAt = currentTime.Unix()
+	req.Version = 1
+	return req, nil
+}
+
+func (r *readWrite) ModifyTopic(ctx context.Context, req *pb.Topic) (res *pb.Topic, err error) {
+	const funcName = `ModifyTopic`
+	_, span := r.tracer.StartSpan(ctx, funcName)
+	defer span.End()
+
+	var oldTopic topicDocument
+	err = r.topics().FindOne(ctx, bson.M{"_id": req.Id}).Decode(&oldTopic)
+	if err != nil {
+		return res, translate(err, "topic", req.Id)
+	}
+	if req.Version != 0 && req.Version != oldTopic.Version {
+		return res, errs.StaleVersion("topic", req.Id, req.Version)
+	}
+
+	currentTime := time.Now()
+	result, err := r.topics().UpdateOne(ctx,
+		bson.M{"_id": req.Id, "version": oldTopic.Version},
+		bson.M{
+			"$set": bson.M{"title": req.Title, "headline": req.Headline, "updated_at": currentTime},
+			"$inc": bson.M{"version": 1},
+		},
+	)
+	if err != nil {
+		return res, translate(err, "topic", req.Id)
+	}
+	if result.MatchedCount == 0 {
+		return res, errs.StaleVersion("topic", req.Id, oldTopic.Version)
+	}
+	req.CreatedAt = oldTopic.Created.Unix()
+	req.UpdatedAt = currentTime.Unix()
+	req.Version = oldTopic.Version + 1
+	return req, nil
+}
+
+func (r *readWrite) RemoveTopic(ctx context.Context, req *pb.Select) error {
+	const funcName = `RemoveTopic`
+	_, span := r.tracer.StartSpan(ctx, funcName)
+	defer span.End()
+
+	result, err := r.topics().DeleteOne(ctx, bson.M{"_id": req.Id})
+	if err != nil {
+		return translate(err, "topic", req.Id)
+	}
+	if result.DeletedCount == 0 {
+		return errs.NotFound("topic", req.Id)
+	}
+	// newses are removed together with their topic, like the cascading
+	// foreign key of the sql repository
+	_, err = r.newses().DeleteMany(ctx, bson.M{"topic_id": req.Id})
+	return err
+}
+
+func (r *readWrite) ReadTopics(ctx context.Context) (res *pb.Topics, err error) {
+	const funcName = `ReadTopics`
+	_, span := r.tracer.StartSpan(ctx, funcName)
+	defer span.End()
+
+	cursor, err := r.topics().Find(ctx, bson.M{}, options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}}))
+	if err != nil {
+		return res, err
+	}
+	var docs []topicDocument
+	if err = cursor.All(ctx, &docs); err != nil {
+		return res, err
+	}
+	var topics pb.Topics
+	for _, doc := range docs {
+		topics.Topics = append(topics.Topics, doc.proto())
+	}
+	return &topics, nil
+}
@@ -2,9 +2,12 @@ package repository
 
 import (
 	"context"
+	"fmt"
 
+	"github.com/muhammadisa/bareksanews/constant"
 	"github.com/muhammadisa/bareksanews/repository/cache"
 	_interface "github.com/muhammadisa/bareksanews/repository/interface"
+	"github.com/muhammadisa/bareksanews/repository/nosql"
 	"github.com/muhammadisa/bareksanews/repository/sql"
 	"github.com/muhammadisa/bareksanews/util/dbc"
 	"go.opencensus.io/trace"
@@ -16,16 +19,54 @@ type Repository struct {
 }
 
 type RepoConf struct {
-	SQL   dbc.Config
-	Cache dbc.Config
+	// Driver select the ReadWriter implementation, constant.DriverMySQL
+	// is used when it left empty
+	Driver string     `yaml:"driver"`
+	SQL    dbc.Config `yaml:"sql"`
+	// Replicas serve ReadNewses*, ReadTags and ReadTopics, only used
+	// with constant.DriverMySQL
+	Replicas []dbc.Config `yaml:"replicas"`
+	// NoSQL mongodb connection, only used with constant.DriverMongoDB
+	NoSQL dbc.Config `yaml:"nosql"`
+	// CacheDriver select the cache implementation, constant.CacheDriverRedis
+	// is used when it left empty
+	CacheDriver string          `yaml:"cache_driver"`
+	Cache       dbc.RedisConfig `yaml:"cache"`
+	// CacheOptions value compression and the in-process tier in front of Cache
+	CacheOptions cache.Options `yaml:"cache_options"`
+}
+
+func newReadWriter(rc RepoConf, tracer trace.Tracer) (_interface.ReadWrite, error) {
+	switch rc.Driver {
+	case "", constant.DriverMySQL:
+		return sql.NewSQL(rc.SQL, rc.Replicas, tracer)
+	case constant.DriverSQLite:
+		return sql.NewSQLite(rc.SQL, tracer)
+	case constant.DriverMongoDB:
+		return nosql.NewNoSQL(rc.NoSQL, tracer)
+	default:
+		return nil, fmt.Errorf("unknown repository driver %q", rc.Driver)
+	}
+}
+
+func newCache(ctx context.Context, rc RepoConf, tracer trace.Tracer) (_interface.Cache, error) {
+	switch rc.CacheDriver {
+	case "", constant.CacheDriverRedis:
+		return cache.NewCache(ctx, rc.Cache, rc.CacheOptions, tracer)
+	case constant.CacheDriverMemory:
+		return cache.NewMemoryCache(tracer), nil
+	default:
+		return nil, fmt.Errorf("unknown cache driver %q", rc.CacheDriver)
+	}
 }
 
 func NewRepository(ctx context.Context, rc RepoConf, tracer trace.Tracer) (*Repository, error) {
-	readWriter, err := sql.NewSQL(rc.SQL, tracer)
+	readWriter, err := newReadWriter(rc, tracer)
 	if err != nil {
 		return nil, err
 	}
-	cacheReadWriter, err := cache.NewCache(rc.Cache, tracer)
+	readWriter = newTimedReadWriter(readWriter, rc.Driver)
+	cacheReadWriter, err := newCache(ctx, rc, tracer)
 	if err != nil {
 		return nil, err
 	}
@@ -34,3 +75,13 @@ func NewRepository(ctx context.Context, rc RepoConf, tracer trace.Tracer) (*Repo
 		CacheReadWriter: cacheReadWriter,
 	}, nil
 }
+
+// Close close the database before the cache, reads running meanwhile are
+// still cached
+func (r *Repository) Close() error {
+	err := r.ReadWriter.Close()
+	if cacheErr := r.CacheReadWriter.Close(); err == nil {
+		err = cacheErr
+	}
+	return err
+}
@@ -0,0 +1,48 @@
+CREATE TABLE IF NOT EXISTS `topics`
+(
+    `id`         varchar(36)  NOT NULL,
+    `title`      varchar(255) NOT NULL,
+    `headline`   varchar(255) NOT NULL,
+    `created_at` timestamp NULL DEFAULT CURRENT_TIMESTAMP,
+    `updated_at` timestamp NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (`id`)
+);
+
+CREATE TABLE IF NOT EXISTS `news`
+(
+    `id`         varchar(36)  NOT NULL,
+    `topic_id`   varchar(36)  NOT NULL,
+    `title`      varchar(255) NOT NULL,
+    `content`    varchar(255) NOT NULL,
+    `status`     int          NOT NULL,
+    `created_at` timestamp NULL DEFAULT CURRENT_TIMESTAMP,
+    `updated_at` timestamp NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (`id`),
+    CONSTRAINT `news_ibfk_1` FOREIGN KEY (`topic_id`) REFERENCES `topics` (`id`) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS `news_topic_id` ON `news` (`topic_id`);
+
+CREATE TABLE IF NOT EXISTS `tags`
+(
+    `id`         varchar(36)  NOT NULL,
+    `tag`        varchar(125) NOT NULL,
+    `created_at` timestamp NULL DEFAULT CURRENT_TIMESTAMP,
+    `updated_at` timestamp NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (`id`)
+);
+
+CREATE TABLE IF NOT EXISTS `news_tags`
+(
+    `id`         varchar(36) NOT NULL,
+    `news_id`    varchar(36) NOT NULL,
+    `tag_id`     varchar(36) NOT NULL,
+    `created_at` timestamp NULL DEFAULT CURRENT_TIMESTAMP,
+    `updated_at` timestamp NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (`id`),
+    CONSTRAINT `news_tags_ibfk_1` FOREIGN KEY (`news_id`) REFERENCES `news` (`id`) ON DELETE CASCADE,
+    CONSTRAINT `fk_tag_news_tag` FOREIGN KEY (`tag_id`) REFERENCES `tags` (`id`)
+);
+
+CREATE INDEX IF NOT EXISTS `news_tags_news_id` ON `news_tags` (`news_id`);
+CREATE INDEX IF NOT EXISTS `fk_tag_news_tag` ON `news_tags` (`tag_id`);
@@ -0,0 +1,3 @@
+ALTER TABLE `topics` ADD COLUMN `version` bigint NOT NULL DEFAULT 1;
+ALTER TABLE `news` ADD COLUMN `version` bigint NOT NULL DEFAULT 1;
+ALTER TABLE `tags` ADD COLUMN `version` bigint NOT NULL DEFAULT 1;
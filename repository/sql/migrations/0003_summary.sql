@@ -0,0 +1 @@
+ALTER TABLE `news` ADD COLUMN `summary` varchar(1024) NOT NULL DEFAULT '';
@@ -1,25 +1,33 @@
 package sql
 
 const (
-	queryWriteBulkNewsTags            = `INSERT INTO news_tags(id, news_id, tag_id, created_at, updated_at) VALUES %s`
-	queryReadNewsTags                 = `SELECT news_tags.tag_id, tags.tag FROM news_tags LEFT JOIN tags ON tags.id = news_tags.tag_id WHERE news_tags.news_id = ?`
-	queryRemoveNewsTagsByNewsID       = `DELETE FROM news_tags WHERE news_id = ?`
-	queryLookupCreateAtNews           = `SELECT id, created_at FROM news WHERE id = ?`
-	queryReadNewses                   = `SELECT id, topic_id, title, content, status, created_at, updated_at FROM news ORDER BY created_at DESC`
-	queryReadNewsesByStatus           = `SELECT id, topic_id, title, content, status, created_at, updated_at FROM news WHERE status = ? ORDER BY created_at DESC`
-	queryReadNewsesByTopicID          = `SELECT id, topic_id, title, content, status, created_at, updated_at FROM news WHERE topic_id = ? AND status = 1 ORDER BY created_at DESC`
-	queryReadNewsesByStatusAndTopicID = `SELECT id, topic_id, title, content, status, created_at, updated_at FROM news WHERE topic_id = ? AND status = ? ORDER BY created_at DESC`
-	queryWriteNews                    = `INSERT INTO news(id, topic_id, title, content, status, created_at, updated_at) VALUES (?,?,?,?,?,?,?)`
-	queryUpdateNews                   = `UPDATE news SET topic_id = ?, title = ?, content = ?, status = ?, created_at = ?, updated_at = ? WHERE id = ?`
-	queryRemoveNews                   = `DELETE FROM news WHERE id = ?`
-	queryLookupCreateAtTag            = `SELECT id, created_at FROM tags WHERE id = ?`
-	queryReadTags                     = `SELECT id, tag, created_at, updated_at FROM tags ORDER BY created_at DESC`
-	queryWriteTag                     = `INSERT INTO tags(id, tag, created_at, updated_at) VALUES (?,?,?,?)`
-	queryUpdateTag                    = `UPDATE tags SET tag = ?, created_at = ?, updated_at = ? WHERE id = ?`
-	queryRemoveTag                    = `DELETE FROM tags WHERE id = ?`
-	queryLookupCreateAtTopic          = `SELECT id, created_at FROM topics WHERE id = ?`
-	queryReadTopics                   = `SELECT id, title, headline, created_at, updated_at FROM topics ORDER BY created_at DESC`
-	queryWriteTopic                   = `INSERT INTO topics(id, title, headline, created_at, updated_at) VALUES (?,?,?,?,?)`
-	queryUpdateTopic                  = `UPDATE topics SET title = ?, headline = ?, created_at = ?, updated_at = ? WHERE id = ?`
-	queryRemoveTopic                  = `DELETE FROM topics WHERE id = ?`
+	queryWriteBulkNewsTags                   = `INSERT INTO news_tags(id, news_id, tag_id, created_at, updated_at) VALUES %s`
+	queryReadNewsTags                        = `SELECT news_tags.tag_id, tags.tag FROM news_tags LEFT JOIN tags ON tags.id = news_tags.tag_id WHERE news_tags.news_id = ?`
+	queryRemoveNewsTagsByNewsID              = `DELETE FROM news_tags WHERE news_id = ?`
+	queryLookupCreateAtNews                  = `SELECT id, created_at, version FROM news WHERE id = ?`
+	queryReadNewses                          = `SELECT id, topic_id, title, content, summary, status, version, created_at, updated_at FROM news ORDER BY created_at DESC`
+	queryReadNewsesByStatus                  = `SELECT id, topic_id, title, content, summary, status, version, created_at, updated_at FROM news WHERE status = ? ORDER BY created_at DESC`
+	queryReadNewsesByTopicID                 = `SELECT id, topic_id, title, content, summary, status, version, created_at, updated_at FROM news WHERE topic_id = ? AND status = 1 ORDER BY created_at DESC`
+	queryReadNewsesByStatusAndTopicID        = `SELECT id, topic_id, title, content, summary, status, version, created_at, updated_at FROM news WHERE topic_id = ? AND status = ? ORDER BY created_at DESC`
+	queryReadNewsSummaries                   = `SELECT id, topic_id, title, CASE WHEN summary = '' THEN content ELSE '' END AS content, summary, status, version, created_at, updated_at FROM news ORDER BY created_at DESC`
+	queryReadNewsSummariesByStatus           = `SELECT id, topic_id, title, CASE WHEN summary = '' THEN content ELSE '' END AS content, summary, status, version, created_at, updated_at FROM news WHERE status = ? ORDER BY created_at DESC`
+	queryReadNewsSummariesByTopicID          = `SELECT id, topic_id, title, CASE WHEN summary = '' THEN content ELSE '' END AS content, summary, status, version, created_at, updated_at FROM news WHERE topic_id = ? AND status = 1 ORDER BY created_at DESC`
+	queryReadNewsSummariesByStatusAndTopicID = `SELECT id, topic_id, title, CASE WHEN summary = '' THEN content ELSE '' END AS content, summary, status, version, created_at, updated_at FROM news WHERE topic_id = ? AND status = ? ORDER BY created_at DESC`
+	queryPage                                = ` LIMIT ? OFFSET ?`
+	queryWriteNews                           = `INSERT INTO news(id, topic_id, title, content, summary, status, created_at, updated_at) VALUES (?,?,?,?,?,?,?,?)`
+	queryUpdateNews                          = `UPDATE news SET topic_id = ?, title = ?, content = ?, summary = ?, status = ?, created_at = ?, updated_at = ?, version = version + 1 WHERE id = ? AND version = ?`
+	queryRemoveNews                          = `DELETE FROM news WHERE id = ?`
+	queryLookupCreateAtTag                   = `SELECT id, created_at, version FROM tags WHERE id = ?`
+	queryReadTags                            = `SELECT id, tag, version, created_at, updated_at FROM tags ORDER BY created_at DESC`
+	queryWriteTag                            = `INSERT INTO tags(id, tag, created_at, updated_at) VALUES (?,?,?,?)`
+	queryUpdateTag                           = `UPDATE tags SET tag = ?, created_at = ?, updated_at = ?, version = version + 1 WHERE id = ? AND version = ?`
+	queryRemoveTag                           = `DELETE FROM tags WHERE id = ?`
+	queryLookupCreateAtTopic                 = `SELECT id, created_at, version FROM topics WHERE id = ?`
+	queryReadTopics                          = `SELECT id, title, headline, version, created_at, updated_at FROM topics ORDER BY created_at DESC`
+	queryWriteTopic                          = `INSERT INTO topics(id, title, headline, created_at, updated_at) VALUES (?,?,?,?,?)`
+	queryUpdateTopic                         = `UPDATE topics SET title = ?, headline = ?, created_at = ?, updated_at = ?, version = version + 1 WHERE id = ? AND version = ?`
+	queryRemoveTopic                         = `DELETE FROM topics WHERE id = ?`
+	queryCreateSchemaMigrations              = `CREATE TABLE IF NOT EXISTS schema_migrations (version varchar(255) NOT NULL PRIMARY KEY, applied_at timestamp NULL DEFAULT CURRENT_TIMESTAMP)`
+	queryLookupSchemaMigration               = `SELECT COUNT(1) FROM schema_migrations WHERE version = ?`
+	queryWriteSchemaMigration                = `INSERT INTO schema_migrations(version, applied_at) VALUES (?,?)`
 )
@@ -0,0 +1,138 @@
+package sql
+
+import (
+	"context"
+	"database/sql"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-kit/kit/log/level"
+	"github.com/muhammadisa/bareksanews/gvars"
+	"github.com/muhammadisa/bareksanews/util/dbc"
+	"github.com/muhammadisa/bareksanews/util/lgr"
+)
+
+type replica struct {
+	db      *sql.DB
+	healthy int32
+}
+
+func (rp *replica) isHealthy() bool {
+	return atomic.LoadInt32(&rp.healthy) == 1
+}
+
+func (rp *replica) setHealthy(healthy bool) {
+	var state int32
+	if healthy {
+		state = 1
+	}
+	atomic.StoreInt32(&rp.healthy, state)
+}
+
+// replicaSet spread reads over healthy replicas using round robin, reads
+// happening shortly after a write of this process stay on the primary
+type replicaSet struct {
+	replicas []*replica
+	next     uint32
+	// lastWrite is shared by every caller of the set rather than scoped to
+	// the writer, one write pins all reads of the process to the primary
+	// until sticky passed
+	lastWrite int64
+	sticky    time.Duration
+	stop      chan struct{}
+}
+
+func newReplicaSet(dbs []*sql.DB, sticky time.Duration) *replicaSet {
+	rs := &replicaSet{
+		sticky: sticky,
+		stop:   make(chan struct{}),
+	}
+	for _, db := range dbs {
+		rs.replicas = append(rs.replicas, &replica{db: db, healthy: 1})
+	}
+	return rs
+}
+
+// pick return the next healthy replica, nil means the primary must be used
+func (rs *replicaSet) pick(ctx context.Context) *replica {
+	if rs == nil || len(rs.replicas) == 0 || dbc.UsePrimary(ctx) {
+		return nil
+	}
+	if time.Since(time.Unix(0, atomic.LoadInt64(&rs.lastWrite))) < rs.sticky {
+		return nil
+	}
+	for range rs.replicas {
+		n := atomic.AddUint32(&rs.next, 1)
+		rp := rs.replicas[int(n)%len(rs.replicas)]
+		if rp.isHealthy() {
+			return rp
+		}
+	}
+	return nil
+}
+
+// wrote record a write of any caller, see lastWrite
+func (rs *replicaSet) wrote() {
+	if rs == nil {
+		return
+	}
+	atomic.StoreInt64(&rs.lastWrite, time.Now().UnixNano())
+}
+
+// watch ping every replica periodically, a replica is only routed reads
+// again after it answers a ping
+func (rs *replicaSet) watch(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-rs.stop:
+			return
+		case <-ticker.C:
+			rs.check(interval)
+		}
+	}
+}
+
+func (rs *replicaSet) check(timeout time.Duration) {
+	for _, rp := range rs.replicas {
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		err := rp.db.PingContext(ctx)
+		cancel()
+		if err != nil && rp.isHealthy() {
+			level.Warn(gvars.Log).Log(lgr.LogWarn, "sql replica is unhealthy", "err", err)
+		}
+		rp.setHealthy(err == nil)
+	}
+}
+
+func (rs *replicaSet) close() {
+	if rs == nil {
+		return
+	}
+	close(rs.stop)
+	for _, rp := range rs.replicas {
+		_ = rp.db.Close()
+	}
+}
+
+// query run a read only query on a healthy replica, the query falls back
+// to the primary when no replica is available or the chosen one fails
+func (r *readWrite) query(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	rp := r.replicas.pick(ctx)
+	if rp != nil {
+		rows, err := queryOn(ctx, rp.db, query, args...)
+		if err == nil || ctx.Err() != nil {
+			return rows, err
+		}
+		rp.setHealthy(false)
+		level.Warn(gvars.Log).Log(lgr.LogWarn, "sql replica failed, falling back to primary", "err", err)
+	}
+	return queryOn(ctx, r.db, query, args...)
+}
+
+// queryOn run query on db without preparing it, the statement would be
+// prepared again on every read and left open on the server
+func queryOn(ctx context.Context, db *sql.DB, query string, args ...interface{}) (*sql.Rows, error) {
+	return db.QueryContext(ctx, query, args...)
+}
@@ -0,0 +1,134 @@
+package sql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-kit/kit/log"
+	"github.com/muhammadisa/bareksanews/gvars"
+	pb "github.com/muhammadisa/bareksanews/protoc/api/v1"
+	"github.com/muhammadisa/bareksanews/util/dbc"
+	"github.com/muhammadisa/bareksanews/util/mocker"
+	uuid "github.com/satori/go.uuid"
+	"github.com/stretchr/testify/suite"
+	"go.opencensus.io/trace"
+)
+
+type sqlReplicaTestSuite struct {
+	suite.Suite
+}
+
+func TestReplicaTestSuite(t *testing.T) {
+	suite.Run(t, new(sqlReplicaTestSuite))
+}
+
+func (ts *sqlReplicaTestSuite) SetupSuite() {
+	gvars.Log = log.NewNopLogger()
+}
+
+func (ts *sqlReplicaTestSuite) TestReadTagsRouting() {
+	now := time.Now()
+	errorDummy := errors.New("sql error while executing query")
+
+	// test case
+	tests := []struct {
+		Name          string
+		Primary       bool
+		AfterWrite    bool
+		ReplicaFailed bool
+		WantPrimary   bool
+	}{
+		{
+			Name:        "read tags served by replica",
+			WantPrimary: false,
+		},
+		{
+			Name:        "read tags forced to primary",
+			Primary:     true,
+			WantPrimary: true,
+		},
+		{
+			Name:        "read tags right after a write",
+			AfterWrite:  true,
+			WantPrimary: true,
+		},
+		{
+			Name:          "read tags fall back to primary when replica fails",
+			ReplicaFailed: true,
+			WantPrimary:   true,
+		},
+	}
+
+	for _, test := range tests {
+		ts.Run(test.Name, func() {
+			primaryDB, primaryMock, err := mocker.SQLMocker()
+			ts.Require().NoError(err)
+			replicaDB, replicaMock, err := mocker.SQLMocker()
+			ts.Require().NoError(err)
+
+			repository := &readWrite{
+				db:       primaryDB,
+				tracer:   trace.DefaultTracer,
+				replicas: newReplicaSet([]*sql.DB{replicaDB}, time.Minute),
+			}
+			rows := sqlmock.NewRows([]string{"id", "tag", "version", "created_at", "updated_at"}).
+				AddRow(uuid.NewV4().String(), "health", 1, now, now)
+
+			ctx := context.Background()
+			if test.Primary {
+				ctx = dbc.WithPrimary(ctx)
+			}
+			if test.AfterWrite {
+				repository.replicas.wrote()
+			}
+			if test.ReplicaFailed {
+				replicaMock.ExpectQuery(queryReadTags).
+					WillReturnError(errorDummy)
+			}
+			if test.WantPrimary {
+				primaryMock.ExpectQuery(queryReadTags).WillReturnRows(rows)
+			} else {
+				replicaMock.ExpectQuery(queryReadTags).WillReturnRows(rows)
+			}
+
+			tags, err := repository.ReadTags(ctx)
+			ts.Assert().NoError(err)
+			ts.Assert().Len(tags.Tags, 1)
+			ts.Assert().Equal(!test.ReplicaFailed, repository.replicas.replicas[0].isHealthy())
+
+			ts.Assert().NoError(primaryMock.ExpectationsWereMet())
+			ts.Assert().NoError(replicaMock.ExpectationsWereMet())
+		})
+	}
+}
+
+func (ts *sqlReplicaTestSuite) TestWriteTagStaysOnPrimary() {
+	primaryDB, primaryMock, err := mocker.SQLMocker()
+	ts.Require().NoError(err)
+	replicaDB, replicaMock, err := mocker.SQLMocker()
+	ts.Require().NoError(err)
+
+	repository := &readWrite{
+		db:       primaryDB,
+		tracer:   trace.DefaultTracer,
+		replicas: newReplicaSet([]*sql.DB{replicaDB}, time.Minute),
+	}
+	request := &pb.Tag{Id: uuid.NewV4().String(), Tag: "health"}
+	currentDate := mocker.AnyTime{}
+
+	primaryMock.ExpectPrepare(queryWriteTag)
+	primaryMock.ExpectExec(queryWriteTag).
+		WithArgs(request.Id, request.Tag, currentDate, currentDate).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	_, err = repository.WriteTag(context.Background(), request)
+	ts.Assert().NoError(err)
+	ts.Assert().Nil(repository.replicas.pick(context.Background()))
+
+	ts.Assert().NoError(primaryMock.ExpectationsWereMet())
+	ts.Assert().NoError(replicaMock.ExpectationsWereMet())
+}
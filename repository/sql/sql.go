@@ -1,28 +1,108 @@
 package sql
 
 import (
+	"context"
 	"database/sql"
-	"sync"
+	"errors"
+	"time"
 
+	"github.com/go-sql-driver/mysql"
+	"github.com/muhammadisa/bareksanews/constant"
+	"github.com/muhammadisa/bareksanews/model"
+	"github.com/muhammadisa/bareksanews/repository/errs"
 	_interface "github.com/muhammadisa/bareksanews/repository/interface"
 	"github.com/muhammadisa/bareksanews/util/dbc"
 	"go.opencensus.io/trace"
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
 )
 
-var mutex = &sync.RWMutex{}
+const (
+	mysqlDuplicateEntry  = 1062
+	mysqlRowIsReferenced = 1451
+	mysqlNoReferencedRow = 1452
+)
 
 type readWrite struct {
-	tracer trace.Tracer
-	db     *sql.DB
+	tracer   trace.Tracer
+	db       *sql.DB
+	replicas *replicaSet
 }
 
-func NewSQL(config dbc.Config, tracer trace.Tracer) (_interface.ReadWrite, error) {
+// NewSQL create the sql repository, writes always go to the primary
+// config while reads are spread over the replicas when some are given
+func NewSQL(config dbc.Config, replicas []dbc.Config, tracer trace.Tracer) (_interface.ReadWrite, error) {
 	sqlDB, err := dbc.OpenDB(config)
 	if err != nil {
 		return nil, err
 	}
-	return &readWrite{
+	rw := &readWrite{
 		db:     sqlDB,
 		tracer: tracer,
-	}, nil
+	}
+	if len(replicas) > 0 {
+		var replicaDBs []*sql.DB
+		for _, replicaConfig := range replicas {
+			replicaDB, err := dbc.NewDB(replicaConfig)
+			if err != nil {
+				return nil, err
+			}
+			replicaDBs = append(replicaDBs, replicaDB)
+		}
+		// an unreachable replica must not block the startup, it only
+		// receives reads once the health check sees it up
+		rw.replicas = newReplicaSet(replicaDBs, constant.ReplicaStickySeconds*time.Second)
+		rw.replicas.check(constant.ReplicaHealthCheckSeconds * time.Second)
+		go rw.replicas.watch(constant.ReplicaHealthCheckSeconds * time.Second)
+	}
+	return rw, nil
+}
+
+// Ping check the primary only, the replicas have their own health checks
+// and reads fall back to the primary
+func (r *readWrite) Ping(ctx context.Context) error {
+	return r.db.PingContext(ctx)
+}
+
+// Close stop the replica health checks and close the replicas before the
+// primary
+func (r *readWrite) Close() error {
+	r.replicas.close()
+	return r.db.Close()
+}
+
+// paginate append the LIMIT and OFFSET of a limited page to the query
+func paginate(query string, page model.Page, args ...interface{}) (string, []interface{}) {
+	if page.Limit <= 0 {
+		return query, args
+	}
+	return query + queryPage, append(args, page.Limit, page.Offset)
+}
+
+// translate turn a driver error into one of the repository errors, field
+// names the foreign key column the statement may violate, errors without
+// a known meaning are returned untouched
+func translate(err error, entity, id, field string) error {
+	if errors.Is(err, sql.ErrNoRows) {
+		return errs.NotFound(entity, id)
+	}
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) {
+		switch mysqlErr.Number {
+		case mysqlDuplicateEntry:
+			return errs.Conflict(entity, id, err)
+		case mysqlRowIsReferenced, mysqlNoReferencedRow:
+			return errs.ForeignKeyViolation(entity, id, field, err)
+		}
+	}
+	var sqliteErr *sqlite.Error
+	if errors.As(err, &sqliteErr) {
+		switch sqliteErr.Code() {
+		case sqlite3.SQLITE_CONSTRAINT_PRIMARYKEY, sqlite3.SQLITE_CONSTRAINT_UNIQUE:
+			return errs.Conflict(entity, id, err)
+		case sqlite3.SQLITE_CONSTRAINT_FOREIGNKEY:
+			return errs.ForeignKeyViolation(entity, id, field, err)
+		}
+	}
+	return err
 }
@@ -4,10 +4,13 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/muhammadisa/bareksanews/model"
 	pb "github.com/muhammadisa/bareksanews/protoc/api/v1"
+	"github.com/muhammadisa/bareksanews/repository/errs"
+	uuid "github.com/satori/go.uuid"
 )
 
 func (r *readWrite) WriteNews(ctx context.Context, req *pb.News) (res *pb.News, err error) {
@@ -28,16 +31,19 @@ func (r *readWrite) WriteNews(ctx context.Context, req *pb.News) (res *pb.News,
 		req.TopicId, // topic_id
 		req.Title,   // title
 		req.Content, // content
+		req.Summary, // summary
 		req.Status,  // status
 		currentTime, // created_at
 		currentTime, // updated_at
 	)
 	if err != nil {
-		return res, err
+		return res, translate(err, "news", req.Id, "news.topic_id")
 	}
 	if affected, err := result.RowsAffected(); affected == 0 || err != nil {
 		return res, fmt.Errorf("failed to insert reason : %+v", err)
 	}
+	r.replicas.wrote()
+	req.Version = 1
 	return req, nil
 }
 
@@ -55,11 +61,15 @@ func (r *readWrite) ModifyNews(ctx context.Context, req *pb.News) (res *pb.News,
 	err = row.Scan(
 		&oldNews.ID,      // id
 		&oldNews.Created, // created_at
+		&oldNews.Version, // version
 	)
-	if err != nil || err == sql.ErrNoRows {
-		return res, err
+	if err != nil {
+		return res, translate(err, "news", req.Id, "")
 	}
 	oldNews.UseUnixTimeStamp()
+	if req.Version != 0 && req.Version != oldNews.Version {
+		return res, errs.StaleVersion("news", req.Id, req.Version)
+	}
 
 	currentTime := time.Now()
 	req.CreatedAt = oldNews.CreatedAt
@@ -73,17 +83,25 @@ func (r *readWrite) ModifyNews(ctx context.Context, req *pb.News) (res *pb.News,
 		req.TopicId,     // topic_id
 		req.Title,       // title
 		req.Content,     // content
+		req.Summary,     // summary
 		req.Status,      // status
 		oldNews.Created, // created_at
 		currentTime,     // updated_at
 		req.Id,          // id
+		oldNews.Version, // version
 	)
 	if err != nil {
-		return res, err
+		return res, translate(err, "news", req.Id, "news.topic_id")
 	}
-	if affected, err := result.RowsAffected(); affected == 0 || err != nil {
-		return res, fmt.Errorf("failed to insert reason : %+v", err)
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return res, fmt.Errorf("failed to update reason : %+v", err)
+	}
+	if affected == 0 {
+		return res, errs.StaleVersion("news", req.Id, oldNews.Version)
 	}
+	r.replicas.wrote()
+	req.Version = oldNews.Version + 1
 	return req, nil
 }
 
@@ -101,11 +119,16 @@ func (r *readWrite) RemoveNews(ctx context.Context, req *pb.Select) error {
 		req.Id, // id
 	)
 	if err != nil {
-		return err
+		return translate(err, "news", req.Id, "")
 	}
-	if affected, err := result.RowsAffected(); affected == 0 || err != nil {
-		return fmt.Errorf("failed to insert reason : %+v", err)
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to delete reason : %+v", err)
+	}
+	if affected == 0 {
+		return errs.NotFound("news", req.Id)
 	}
+	r.replicas.wrote()
 	return nil
 }
 
@@ -122,7 +145,9 @@ func (r *readWrite) rowsNewsesNextAndScan(ctx context.Context, row *sql.Rows) (r
 			&news.TopicID, // topic_id
 			&news.Title,   // title
 			&news.Content, // content
+			&news.Summary, // summary
 			&news.Status,  // status
+			&news.Version, // version
 			&news.Created, // created_at
 			&news.Updated, // updated_at
 		)
@@ -135,7 +160,9 @@ func (r *readWrite) rowsNewsesNextAndScan(ctx context.Context, row *sql.Rows) (r
 			TopicId:      news.TopicID,
 			Title:        news.Title,
 			Content:      news.Content,
+			Summary:      news.Summary,
 			Status:       news.Status,
+			Version:      news.Version,
 			NewsTagIds:   r.ReadNewsTagsTagIDAndTagByNewsID(ctx, news.ID, false),
 			NewsTagNames: r.ReadNewsTagsTagIDAndTagByNewsID(ctx, news.ID, true),
 			CreatedAt:    news.CreatedAt,
@@ -145,74 +172,134 @@ func (r *readWrite) rowsNewsesNextAndScan(ctx context.Context, row *sql.Rows) (r
 	return &newses, nil
 }
 
-func (r *readWrite) ReadNewsesByStatusAndTopicID(ctx context.Context, status int32, topicID string) (res *pb.Newses, err error) {
+// listing the query reading page, the summary query reads the mediumtext
+// content only of the newses still to be summarized
+func listing(full, summary string, page model.Page) string {
+	if page.SummaryOnly {
+		return summary
+	}
+	return full
+}
+
+func (r *readWrite) ReadNewsesByStatusAndTopicID(ctx context.Context, status int32, topicID string, page model.Page) (res *pb.Newses, err error) {
 	const funcName = `ReadNewsesByStatusAndTopicID`
 	_, span := r.tracer.StartSpan(ctx, funcName)
 	defer span.End()
 
-	stmt, err := r.db.Prepare(queryReadNewsesByStatusAndTopicID)
+	query, args := paginate(listing(queryReadNewsesByStatusAndTopicID, queryReadNewsSummariesByStatusAndTopicID, page), page, topicID, status)
+	row, err := r.query(ctx, query, args...)
 	if err != nil {
 		return res, err
 	}
-	mutex.Lock()
-	row, err := stmt.QueryContext(ctx, topicID, status)
-	if err != nil {
-		return res, err
-	}
-	mutex.Unlock()
 	return r.rowsNewsesNextAndScan(ctx, row)
 }
 
-func (r *readWrite) ReadNewsesByTopicID(ctx context.Context, topicID string) (res *pb.Newses, err error) {
+func (r *readWrite) ReadNewsesByTopicID(ctx context.Context, topicID string, page model.Page) (res *pb.Newses, err error) {
 	const funcName = `ReadNewsesByTopicID`
 	_, span := r.tracer.StartSpan(ctx, funcName)
 	defer span.End()
 
-	stmt, err := r.db.Prepare(queryReadNewsesByTopicID)
+	query, args := paginate(listing(queryReadNewsesByTopicID, queryReadNewsSummariesByTopicID, page), page, topicID)
+	row, err := r.query(ctx, query, args...)
 	if err != nil {
 		return res, err
 	}
-	mutex.Lock()
-	row, err := stmt.QueryContext(ctx, topicID)
-	if err != nil {
-		return res, err
-	}
-	mutex.Unlock()
 	return r.rowsNewsesNextAndScan(ctx, row)
 }
 
-func (r *readWrite) ReadNewsesByStatus(ctx context.Context, status int32) (res *pb.Newses, err error) {
+func (r *readWrite) ReadNewsesByStatus(ctx context.Context, status int32, page model.Page) (res *pb.Newses, err error) {
 	const funcName = `ReadNewsesByStatus`
 	_, span := r.tracer.StartSpan(ctx, funcName)
 	defer span.End()
 
-	stmt, err := r.db.Prepare(queryReadNewsesByStatus)
+	query, args := paginate(listing(queryReadNewsesByStatus, queryReadNewsSummariesByStatus, page), page, status)
+	row, err := r.query(ctx, query, args...)
 	if err != nil {
 		return res, err
 	}
-	mutex.Lock()
-	row, err := stmt.QueryContext(ctx, status)
+	return r.rowsNewsesNextAndScan(ctx, row)
+}
+
+func (r *readWrite) ReadNewses(ctx context.Context, page model.Page) (res *pb.Newses, err error) {
+	const funcName = `ReadNewses`
+	_, span := r.tracer.StartSpan(ctx, funcName)
+	defer span.End()
+
+	query, args := paginate(listing(queryReadNewses, queryReadNewsSummaries, page), page)
+	row, err := r.query(ctx, query, args...)
 	if err != nil {
 		return res, err
 	}
-	mutex.Unlock()
 	return r.rowsNewsesNextAndScan(ctx, row)
 }
 
-func (r *readWrite) ReadNewses(ctx context.Context) (res *pb.Newses, err error) {
-	const funcName = `ReadNewses`
+// WriteNewsBatch insert the newses and their tags within one transaction,
+// either every news of the batch is written or none of them
+func (r *readWrite) WriteNewsBatch(ctx context.Context, newses []*pb.News) (err error) {
+	const funcName = `WriteNewsBatch`
 	_, span := r.tracer.StartSpan(ctx, funcName)
 	defer span.End()
 
-	stmt, err := r.db.Prepare(queryReadNewses)
+	if len(newses) == 0 {
+		return nil
+	}
+	tx, err := r.db.BeginTx(ctx, nil)
 	if err != nil {
-		return res, err
+		return err
 	}
-	mutex.Lock()
-	row, err := stmt.QueryContext(ctx)
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	currentTime := time.Now()
+	stmt, err := tx.PrepareContext(ctx, queryWriteNews)
 	if err != nil {
-		return res, err
+		return err
 	}
-	mutex.Unlock()
-	return r.rowsNewsesNextAndScan(ctx, row)
+	defer stmt.Close()
+
+	var valueStrings []string
+	var valueArgs []interface{}
+	for _, news := range newses {
+		news.CreatedAt = currentTime.Unix()
+		news.UpdatedAt = currentTime.Unix()
+		_, err = stmt.ExecContext(
+			ctx,
+			news.Id,      // id
+			news.TopicId, // topic_id
+			news.Title,   // title
+			news.Content, // content
+			news.Summary, // summary
+			news.Status,  // status
+			currentTime,  // created_at
+			currentTime,  // updated_at
+		)
+		if err != nil {
+			return translate(err, "news", news.Id, "news.topic_id")
+		}
+		for _, tagID := range news.NewsTagIds {
+			valueStrings = append(valueStrings, "(?,?,?,?,?)")
+			valueArgs = append(valueArgs, uuid.NewV4().String()) // id
+			valueArgs = append(valueArgs, news.Id)               // news_id
+			valueArgs = append(valueArgs, tagID)                 // tag_id
+			valueArgs = append(valueArgs, currentTime)           // created_at
+			valueArgs = append(valueArgs, currentTime)           // updated_at
+		}
+	}
+	if len(valueStrings) > 0 {
+		query := fmt.Sprintf(queryWriteBulkNewsTags, strings.Join(valueStrings, ","))
+		if _, err = tx.ExecContext(ctx, query, valueArgs...); err != nil {
+			return translate(err, "news", "", "news_tags.tag_id")
+		}
+	}
+	if err = tx.Commit(); err != nil {
+		return err
+	}
+	r.replicas.wrote()
+	for _, news := range newses {
+		news.Version = 1
+	}
+	return nil
 }
@@ -26,9 +26,11 @@ func (r *readWrite) RemoveNewsTagsByNewsID(ctx context.Context, req *pb.Select)
 	if err != nil {
 		return err
 	}
-	if affected, err := result.RowsAffected(); affected == 0 || err != nil {
+	// a news without any tag has nothing to delete, it is not an error
+	if _, err := result.RowsAffected(); err != nil {
 		return fmt.Errorf("failed to delete reason : %+v", err)
 	}
+	r.replicas.wrote()
 	return nil
 }
 
@@ -38,16 +40,10 @@ func (r *readWrite) ReadNewsTagsTagIDAndTagByNewsID(ctx context.Context, newsID
 	defer span.End()
 
 	var tagID, tag string
-	stmt, err := r.db.Prepare(queryReadNewsTags)
+	row, err := r.query(ctx, queryReadNewsTags, newsID)
 	if err != nil {
 		return nil
 	}
-	mutex.Lock()
-	row, err := stmt.QueryContext(ctx, newsID)
-	if err != nil {
-		return nil
-	}
-	mutex.Unlock()
 	for row.Next() {
 		err = row.Scan(
 			&tagID,
@@ -73,16 +69,15 @@ func (r *readWrite) WriteNewsTags(ctx context.Context, newsID string, tagIDs []s
 	timeNow := time.Now()
 	length := len(tagIDs)
 
-	if length < 0 {
-		return nil
-	}
-
 	if !new {
 		err := r.RemoveNewsTagsByNewsID(ctx, &pb.Select{Id: newsID})
 		if err != nil {
 			return err
 		}
 	}
+	if length == 0 {
+		return nil
+	}
 
 	var valueStrings []string
 	var valueArgs []interface{}
@@ -99,10 +94,11 @@ func (r *readWrite) WriteNewsTags(ctx context.Context, newsID string, tagIDs []s
 	query := fmt.Sprintf(queryWriteBulkNewsTags, strings.Join(valueStrings, ","))
 	result, err := r.db.Exec(query, valueArgs...)
 	if err != nil {
-		return err
+		return translate(err, "news", newsID, "news_tags.tag_id")
 	}
 	if affected, err := result.RowsAffected(); affected != int64(length) || err != nil {
 		return fmt.Errorf("failed to insert reason : %+v", err)
 	}
+	r.replicas.wrote()
 	return nil
 }
@@ -79,7 +79,6 @@ func (ts *sqlNewsTagTestSuite) TestReadNewsTagsTagIDAndTagByNewsID() {
 	for _, test := range tests {
 		ts.Run(test.Name, func() {
 			if !test.WantError {
-				mock.ExpectPrepare(queryReadNewsTags)
 				mock.ExpectQuery(queryReadNewsTags).
 					WillReturnRows(sqlmock.NewRows([]string{"tag_id", "tag"}).
 						AddRow(test.TagID, test.Tag))
@@ -94,8 +93,6 @@ func (ts *sqlNewsTagTestSuite) TestReadNewsTagsTagIDAndTagByNewsID() {
 				err = mock.ExpectationsWereMet()
 				ts.Assert().NoError(err)
 			} else {
-				mock.ExpectPrepare(queryReadNewsTags).
-					WillReturnError(errorDummy)
 				mock.ExpectQuery(queryReadNewsTags).
 					WillReturnError(errorDummy)
 
@@ -103,7 +100,7 @@ func (ts *sqlNewsTagTestSuite) TestReadNewsTagsTagIDAndTagByNewsID() {
 				ts.Assert().Nil(newsTags)
 
 				err = mock.ExpectationsWereMet()
-				ts.Assert().Error(err)
+				ts.Assert().NoError(err)
 			}
 		})
 	}
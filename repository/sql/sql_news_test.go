@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/muhammadisa/bareksanews/model"
 	pb "github.com/muhammadisa/bareksanews/protoc/api/v1"
 	"github.com/muhammadisa/bareksanews/util/mocker"
 	uuid "github.com/satori/go.uuid"
@@ -70,13 +71,12 @@ func (ts *sqlNewsTestSuite) TestReadNewsesByStatusAndTopicID() {
 	for _, test := range tests {
 		ts.Run(test.Name, func() {
 			if !test.WantError {
-				mock.ExpectPrepare(queryReadNewsesByStatusAndTopicID)
 				mock.ExpectQuery(queryReadNewsesByStatusAndTopicID).
 					WithArgs(test.Request.TopicId, test.Request.Status).
-					WillReturnRows(sqlmock.NewRows([]string{"id", "topic_id", "title", "content", "status", "created_at", "updated_at"}).
-						AddRow(test.Request.Id, test.Request.TopicId, test.Request.Title, test.Request.Content, test.Request.Status, now, now))
+					WillReturnRows(sqlmock.NewRows([]string{"id", "topic_id", "title", "content", "summary", "status", "version", "created_at", "updated_at"}).
+						AddRow(test.Request.Id, test.Request.TopicId, test.Request.Title, test.Request.Content, test.Request.Summary, test.Request.Status, 1, now, now))
 
-				newses, err := repository.ReadNewsesByStatusAndTopicID(ctx, test.Request.Status, test.Request.TopicId)
+				newses, err := repository.ReadNewsesByStatusAndTopicID(ctx, test.Request.Status, test.Request.TopicId, model.Page{})
 				ts.Assert().NoError(err)
 				ts.Assert().NotNil(newses)
 				ts.Assert().NotNil(len(newses.Newses))
@@ -84,18 +84,16 @@ func (ts *sqlNewsTestSuite) TestReadNewsesByStatusAndTopicID() {
 				err = mock.ExpectationsWereMet()
 				ts.Assert().NoError(err)
 			} else {
-				mock.ExpectPrepare(queryReadNewsesByStatusAndTopicID).
-					WillReturnError(errorDummy)
 				mock.ExpectQuery(queryReadNewsesByStatusAndTopicID).
 					WithArgs(test.Request.TopicId, test.Request.Status).
 					WillReturnError(errorDummy)
 
-				newses, err := repository.ReadNewsesByStatusAndTopicID(ctx, test.Request.Status, test.Request.TopicId)
+				newses, err := repository.ReadNewsesByStatusAndTopicID(ctx, test.Request.Status, test.Request.TopicId, model.Page{})
 				ts.Assert().Error(err)
 				ts.Assert().Nil(newses)
 
 				err = mock.ExpectationsWereMet()
-				ts.Assert().Error(err)
+				ts.Assert().NoError(err)
 			}
 		})
 	}
@@ -150,13 +148,12 @@ func (ts *sqlNewsTestSuite) TestReadNewsesByTopicID() {
 	for _, test := range tests {
 		ts.Run(test.Name, func() {
 			if !test.WantError {
-				mock.ExpectPrepare(queryReadNewsesByTopicID)
 				mock.ExpectQuery(queryReadNewsesByTopicID).
 					WithArgs(test.Request.TopicId).
-					WillReturnRows(sqlmock.NewRows([]string{"id", "topic_id", "title", "content", "status", "created_at", "updated_at"}).
-						AddRow(test.Request.Id, test.Request.TopicId, test.Request.Title, test.Request.Content, test.Request.Status, now, now))
+					WillReturnRows(sqlmock.NewRows([]string{"id", "topic_id", "title", "content", "summary", "status", "version", "created_at", "updated_at"}).
+						AddRow(test.Request.Id, test.Request.TopicId, test.Request.Title, test.Request.Content, test.Request.Summary, test.Request.Status, 1, now, now))
 
-				newses, err := repository.ReadNewsesByTopicID(ctx, test.Request.TopicId)
+				newses, err := repository.ReadNewsesByTopicID(ctx, test.Request.TopicId, model.Page{})
 				ts.Assert().NoError(err)
 				ts.Assert().NotNil(newses)
 				ts.Assert().NotNil(len(newses.Newses))
@@ -164,18 +161,16 @@ func (ts *sqlNewsTestSuite) TestReadNewsesByTopicID() {
 				err = mock.ExpectationsWereMet()
 				ts.Assert().NoError(err)
 			} else {
-				mock.ExpectPrepare(queryReadNewsesByTopicID).
-					WillReturnError(errorDummy)
 				mock.ExpectQuery(queryReadNewsesByTopicID).
 					WithArgs(test.Request.TopicId).
 					WillReturnError(errorDummy)
 
-				newses, err := repository.ReadNewsesByTopicID(ctx, test.Request.TopicId)
+				newses, err := repository.ReadNewsesByTopicID(ctx, test.Request.TopicId, model.Page{})
 				ts.Assert().Error(err)
 				ts.Assert().Nil(newses)
 
 				err = mock.ExpectationsWereMet()
-				ts.Assert().Error(err)
+				ts.Assert().NoError(err)
 			}
 		})
 	}
@@ -230,13 +225,12 @@ func (ts *sqlNewsTestSuite) TestReadNewsesByStatus() {
 	for _, test := range tests {
 		ts.Run(test.Name, func() {
 			if !test.WantError {
-				mock.ExpectPrepare(queryReadNewsesByStatus)
 				mock.ExpectQuery(queryReadNewsesByStatus).
 					WithArgs(test.Request.Status).
-					WillReturnRows(sqlmock.NewRows([]string{"id", "topic_id", "title", "content", "status", "created_at", "updated_at"}).
-						AddRow(test.Request.Id, test.Request.TopicId, test.Request.Title, test.Request.Content, test.Request.Status, now, now))
+					WillReturnRows(sqlmock.NewRows([]string{"id", "topic_id", "title", "content", "summary", "status", "version", "created_at", "updated_at"}).
+						AddRow(test.Request.Id, test.Request.TopicId, test.Request.Title, test.Request.Content, test.Request.Summary, test.Request.Status, 1, now, now))
 
-				newses, err := repository.ReadNewsesByStatus(ctx, test.Request.Status)
+				newses, err := repository.ReadNewsesByStatus(ctx, test.Request.Status, model.Page{})
 				ts.Assert().NoError(err)
 				ts.Assert().NotNil(newses)
 				ts.Assert().NotNil(len(newses.Newses))
@@ -244,18 +238,16 @@ func (ts *sqlNewsTestSuite) TestReadNewsesByStatus() {
 				err = mock.ExpectationsWereMet()
 				ts.Assert().NoError(err)
 			} else {
-				mock.ExpectPrepare(queryReadNewsesByStatus).
-					WillReturnError(errorDummy)
 				mock.ExpectQuery(queryReadNewsesByStatus).
 					WithArgs(test.Request.Status).
 					WillReturnError(errorDummy)
 
-				newses, err := repository.ReadNewsesByStatus(ctx, test.Request.Status)
+				newses, err := repository.ReadNewsesByStatus(ctx, test.Request.Status, model.Page{})
 				ts.Assert().Error(err)
 				ts.Assert().Nil(newses)
 
 				err = mock.ExpectationsWereMet()
-				ts.Assert().Error(err)
+				ts.Assert().NoError(err)
 			}
 		})
 	}
@@ -310,12 +302,11 @@ func (ts *sqlNewsTestSuite) TestReadNewses() {
 	for _, test := range tests {
 		ts.Run(test.Name, func() {
 			if !test.WantError {
-				mock.ExpectPrepare(queryReadNewses)
 				mock.ExpectQuery(queryReadNewses).
-					WillReturnRows(sqlmock.NewRows([]string{"id", "topic_id", "title", "content", "status", "created_at", "updated_at"}).
-						AddRow(test.Request.Id, test.Request.TopicId, test.Request.Title, test.Request.Content, test.Request.Status, now, now))
+					WillReturnRows(sqlmock.NewRows([]string{"id", "topic_id", "title", "content", "summary", "status", "version", "created_at", "updated_at"}).
+						AddRow(test.Request.Id, test.Request.TopicId, test.Request.Title, test.Request.Content, test.Request.Summary, test.Request.Status, 1, now, now))
 
-				newses, err := repository.ReadNewses(ctx)
+				newses, err := repository.ReadNewses(ctx, model.Page{})
 				ts.Assert().NoError(err)
 				ts.Assert().NotNil(newses)
 				ts.Assert().NotNil(len(newses.Newses))
@@ -323,17 +314,15 @@ func (ts *sqlNewsTestSuite) TestReadNewses() {
 				err = mock.ExpectationsWereMet()
 				ts.Assert().NoError(err)
 			} else {
-				mock.ExpectPrepare(queryReadNewses).
-					WillReturnError(errorDummy)
 				mock.ExpectQuery(queryReadNewses).
 					WillReturnError(errorDummy)
 
-				newses, err := repository.ReadNewses(ctx)
+				newses, err := repository.ReadNewses(ctx, model.Page{})
 				ts.Assert().Error(err)
 				ts.Assert().Nil(newses)
 
 				err = mock.ExpectationsWereMet()
-				ts.Assert().Error(err)
+				ts.Assert().NoError(err)
 			}
 		})
 	}
@@ -450,11 +439,11 @@ func (ts *sqlNewsTestSuite) TestModifyNews() {
 				mock.ExpectPrepare(queryLookupCreateAtNews)
 				mock.ExpectQuery(queryLookupCreateAtNews).
 					WithArgs(test.Request.Id).
-					WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).
-						AddRow(test.Request.Id, now))
+					WillReturnRows(sqlmock.NewRows([]string{"id", "created_at", "version"}).
+						AddRow(test.Request.Id, now, 1))
 				mock.ExpectPrepare(queryUpdateNews)
 				mock.ExpectExec(queryUpdateNews).
-					WithArgs(test.Request.TopicId, test.Request.Title, test.Request.Content, test.Request.Status, currentDate, currentDate, test.Request.Id).
+					WithArgs(test.Request.TopicId, test.Request.Title, test.Request.Content, test.Request.Summary, test.Request.Status, currentDate, currentDate, test.Request.Id, 1).
 					WillReturnResult(sqlmock.NewResult(1, 1))
 
 				updatedNews, err := repository.ModifyNews(ctx, test.Request)
@@ -529,7 +518,7 @@ func (ts *sqlNewsTestSuite) TestWriteNews() {
 			if !test.WantError {
 				mock.ExpectPrepare(queryWriteNews)
 				mock.ExpectExec(queryWriteNews).
-					WithArgs(test.Request.Id, test.Request.TopicId, test.Request.Title, test.Request.Content, test.Request.Status, currentDate, currentDate).
+					WithArgs(test.Request.Id, test.Request.TopicId, test.Request.Title, test.Request.Content, test.Request.Summary, test.Request.Status, currentDate, currentDate).
 					WillReturnResult(sqlmock.NewResult(1, 1))
 
 				newNews, err := repository.WriteNews(ctx, test.Request)
@@ -546,7 +535,7 @@ func (ts *sqlNewsTestSuite) TestWriteNews() {
 				mock.ExpectPrepare(queryWriteNews).
 					WillReturnError(errorDummy)
 				mock.ExpectExec(queryWriteNews).
-					WithArgs(test.Request.Id, test.Request.TopicId, test.Request.Title, test.Request.Content, test.Request.Status, currentDate, currentDate).
+					WithArgs(test.Request.Id, test.Request.TopicId, test.Request.Title, test.Request.Content, test.Request.Summary, test.Request.Status, currentDate, currentDate).
 					WillReturnError(errorDummy)
 
 				_, err := repository.WriteNews(ctx, test.Request)
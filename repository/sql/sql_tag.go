@@ -2,12 +2,12 @@ package sql
 
 import (
 	"context"
-	"database/sql"
 	"fmt"
 	"time"
 
 	"github.com/muhammadisa/bareksanews/model"
 	pb "github.com/muhammadisa/bareksanews/protoc/api/v1"
+	"github.com/muhammadisa/bareksanews/repository/errs"
 )
 
 func (r *readWrite) WriteTag(ctx context.Context, req *pb.Tag) (res *pb.Tag, err error) {
@@ -30,11 +30,13 @@ func (r *readWrite) WriteTag(ctx context.Context, req *pb.Tag) (res *pb.Tag, err
 		currentTime, // updated_at
 	)
 	if err != nil {
-		return res, err
+		return res, translate(err, "tag", req.Id, "")
 	}
 	if affected, err := result.RowsAffected(); affected == 0 || err != nil {
 		return res, fmt.Errorf("failed to insert reason : %+v", err)
 	}
+	r.replicas.wrote()
+	req.Version = 1
 	return req, nil
 }
 
@@ -53,11 +55,15 @@ func (r *readWrite) ModifyTag(ctx context.Context, req *pb.Tag) (res *pb.Tag, er
 	err = row.Scan(
 		&oldTag.ID,      // id
 		&oldTag.Created, // created_at
+		&oldTag.Version, // version
 	)
-	if err != nil || err == sql.ErrNoRows {
-		return res, err
+	if err != nil {
+		return res, translate(err, "tag", req.Id, "")
 	}
 	oldTag.UseUnixTimeStamp()
+	if req.Version != 0 && req.Version != oldTag.Version {
+		return res, errs.StaleVersion("tag", req.Id, req.Version)
+	}
 
 	currentTime := time.Now()
 	req.CreatedAt = oldTag.CreatedAt
@@ -72,13 +78,20 @@ func (r *readWrite) ModifyTag(ctx context.Context, req *pb.Tag) (res *pb.Tag, er
 		oldTag.Created, // created_at
 		currentTime,    // updated_at
 		req.Id,         // id
+		oldTag.Version, // version
 	)
 	if err != nil {
-		return res, err
+		return res, translate(err, "tag", req.Id, "")
 	}
-	if affected, err := result.RowsAffected(); affected == 0 || err != nil {
-		return res, fmt.Errorf("failed to insert reason : %+v", err)
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return res, fmt.Errorf("failed to update reason : %+v", err)
 	}
+	if affected == 0 {
+		return res, errs.StaleVersion("tag", req.Id, oldTag.Version)
+	}
+	r.replicas.wrote()
+	req.Version = oldTag.Version + 1
 	return req, nil
 }
 
@@ -96,11 +109,16 @@ func (r *readWrite) RemoveTag(ctx context.Context, req *pb.Select) (err error) {
 		req.Id, // id
 	)
 	if err != nil {
-		return err
+		return translate(err, "tag", req.Id, "news_tags.tag_id")
 	}
-	if affected, err := result.RowsAffected(); affected == 0 || err != nil {
-		return fmt.Errorf("failed to insert reason : %+v", err)
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to delete reason : %+v", err)
 	}
+	if affected == 0 {
+		return errs.NotFound("tag", req.Id)
+	}
+	r.replicas.wrote()
 	return nil
 }
 
@@ -112,20 +130,15 @@ func (r *readWrite) ReadTags(ctx context.Context) (res *pb.Tags, err error) {
 	var tags pb.Tags
 	var tag model.Tag
 
-	stmt, err := r.db.Prepare(queryReadTags)
-	if err != nil {
-		return res, err
-	}
-	mutex.Lock()
-	row, err := stmt.QueryContext(ctx)
+	row, err := r.query(ctx, queryReadTags)
 	if err != nil {
 		return res, err
 	}
-	mutex.Unlock()
 	for row.Next() {
 		err = row.Scan(
 			&tag.ID,      // id
 			&tag.Tag,     // tag
+			&tag.Version, // version
 			&tag.Created, // created_at
 			&tag.Updated, // updated_at
 		)
@@ -136,6 +149,7 @@ func (r *readWrite) ReadTags(ctx context.Context) (res *pb.Tags, err error) {
 		tags.Tags = append(tags.Tags, &pb.Tag{
 			Id:        tag.ID,
 			Tag:       tag.Tag,
+			Version:   tag.Version,
 			CreatedAt: tag.CreatedAt,
 			UpdatedAt: tag.UpdatedAt,
 		})
@@ -12,6 +12,8 @@ import (
 	"github.com/muhammadisa/bareksanews/util/mocker"
 	uuid "github.com/satori/go.uuid"
 	"github.com/stretchr/testify/suite"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 type sqlTagTestSuite struct {
@@ -57,10 +59,9 @@ func (ts *sqlTagTestSuite) TestReadTags() {
 	for _, test := range tests {
 		ts.Run(test.Name, func() {
 			if !test.WantError {
-				mock.ExpectPrepare(queryReadTags)
 				mock.ExpectQuery(queryReadTags).
-					WillReturnRows(sqlmock.NewRows([]string{"id", "tag", "created_at", "updated_at"}).
-						AddRow(test.Request.Id, test.Request.Tag, now, now))
+					WillReturnRows(sqlmock.NewRows([]string{"id", "tag", "version", "created_at", "updated_at"}).
+						AddRow(test.Request.Id, test.Request.Tag, 1, now, now))
 
 				tags, err := repository.ReadTags(ctx)
 				ts.Assert().NoError(err)
@@ -70,8 +71,6 @@ func (ts *sqlTagTestSuite) TestReadTags() {
 				err = mock.ExpectationsWereMet()
 				ts.Assert().NoError(err)
 			} else {
-				mock.ExpectPrepare(queryReadTags).
-					WillReturnError(errorDummy)
 				mock.ExpectQuery(queryReadTags).
 					WillReturnError(errorDummy)
 
@@ -196,11 +195,11 @@ func (ts *sqlTagTestSuite) TestModifyTag() {
 				mock.ExpectPrepare(queryLookupCreateAtTag)
 				mock.ExpectQuery(queryLookupCreateAtTag).
 					WithArgs(test.Request.Id).
-					WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).
-						AddRow(test.Request.Id, now))
+					WillReturnRows(sqlmock.NewRows([]string{"id", "created_at", "version"}).
+						AddRow(test.Request.Id, now, 1))
 				mock.ExpectPrepare(queryUpdateTag)
 				mock.ExpectExec(queryUpdateTag).
-					WithArgs(test.Request.Tag, currentDate, currentDate, test.Request.Id).
+					WithArgs(test.Request.Tag, currentDate, currentDate, test.Request.Id, 1).
 					WillReturnResult(sqlmock.NewResult(1, 1))
 
 				updatedTag, err := repository.ModifyTag(ctx, test.Request)
@@ -229,6 +228,73 @@ func (ts *sqlTagTestSuite) TestModifyTag() {
 	}
 }
 
+func (ts *sqlTagTestSuite) TestModifyTagStaleVersion() {
+	// sql mock
+	mockDB, mock, err := mocker.SQLMocker()
+	ts.Require().NoError(err)
+	ts.Require().NotNil(mockDB)
+	ts.Require().NotNil(mock)
+
+	now := time.Now()
+
+	// test case
+	tests := []struct {
+		Name           string
+		Request        *pb.Tag
+		StoredVersion  int64
+		ConcurrentEdit bool
+	}{
+		{
+			Name: "modify tag with stale version",
+			Request: &pb.Tag{
+				Id:      uuid.NewV4().String(),
+				Tag:     "health",
+				Version: 1,
+			},
+			StoredVersion:  2,
+			ConcurrentEdit: false,
+		},
+		{
+			Name: "modify tag edited concurrently",
+			Request: &pb.Tag{
+				Id:      uuid.NewV4().String(),
+				Tag:     "health",
+				Version: 2,
+			},
+			StoredVersion:  2,
+			ConcurrentEdit: true,
+		},
+	}
+
+	repository := &readWrite{db: mockDB, tracer: trace.DefaultTracer}
+	currentDate := mocker.AnyTime{}
+	ctx := context.Background()
+	defer ctx.Done()
+
+	for _, test := range tests {
+		ts.Run(test.Name, func() {
+			mock.ExpectPrepare(queryLookupCreateAtTag)
+			mock.ExpectQuery(queryLookupCreateAtTag).
+				WithArgs(test.Request.Id).
+				WillReturnRows(sqlmock.NewRows([]string{"id", "created_at", "version"}).
+					AddRow(test.Request.Id, now, test.StoredVersion))
+			if test.ConcurrentEdit {
+				mock.ExpectPrepare(queryUpdateTag)
+				mock.ExpectExec(queryUpdateTag).
+					WithArgs(test.Request.Tag, currentDate, currentDate, test.Request.Id, test.StoredVersion).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+			}
+
+			_, err := repository.ModifyTag(ctx, test.Request)
+			ts.Assert().Error(err)
+			ts.Assert().Equal(codes.Aborted, status.Code(err))
+
+			err = mock.ExpectationsWereMet()
+			ts.Assert().NoError(err)
+		})
+	}
+}
+
 func (ts *sqlTagTestSuite) TestWriteTag() {
 	// sql mock
 	mockDB, mock, err := mocker.SQLMocker()
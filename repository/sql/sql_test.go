@@ -0,0 +1,63 @@
+package sql
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/muhammadisa/bareksanews/repository/errs"
+	"github.com/stretchr/testify/suite"
+)
+
+type sqlTestSuite struct {
+	suite.Suite
+}
+
+func TestSQLTestSuite(t *testing.T) {
+	suite.Run(t, new(sqlTestSuite))
+}
+
+func (ts *sqlTestSuite) TestTranslate() {
+	errorDummy := errors.New("sql error while executing query")
+
+	// test case
+	tests := []struct {
+		Name string
+		Err  error
+		Want error
+	}{
+		{
+			Name: "no rows is not found",
+			Err:  sql.ErrNoRows,
+			Want: errs.ErrNotFound,
+		},
+		{
+			Name: "duplicate entry is conflict",
+			Err:  &mysql.MySQLError{Number: mysqlDuplicateEntry},
+			Want: errs.ErrConflict,
+		},
+		{
+			Name: "unknown reference is foreign key violation",
+			Err:  &mysql.MySQLError{Number: mysqlNoReferencedRow},
+			Want: errs.ErrForeignKeyViolation,
+		},
+		{
+			Name: "still referenced is foreign key violation",
+			Err:  &mysql.MySQLError{Number: mysqlRowIsReferenced},
+			Want: errs.ErrForeignKeyViolation,
+		},
+		{
+			Name: "unknown error is untouched",
+			Err:  errorDummy,
+			Want: errorDummy,
+		},
+	}
+
+	for _, test := range tests {
+		ts.Run(test.Name, func() {
+			err := translate(test.Err, "news", "id_1", "news.topic_id")
+			ts.Assert().True(errors.Is(err, test.Want))
+		})
+	}
+}
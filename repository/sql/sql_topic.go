@@ -2,12 +2,12 @@ package sql
 
 import (
 	"context"
-	"database/sql"
 	"fmt"
 	"time"
 
 	"github.com/muhammadisa/bareksanews/model"
 	pb "github.com/muhammadisa/bareksanews/protoc/api/v1"
+	"github.com/muhammadisa/bareksanews/repository/errs"
 )
 
 func (r *readWrite) WriteTopic(ctx context.Context, req *pb.Topic) (res *pb.Topic, err error) {
@@ -31,11 +31,13 @@ func (r *readWrite) WriteTopic(ctx context.Context, req *pb.Topic) (res *pb.Topi
 		currentTime,  // updated_at
 	)
 	if err != nil {
-		return res, err
+		return res, translate(err, "topic", req.Id, "")
 	}
 	if affected, err := result.RowsAffected(); affected == 0 || err != nil {
 		return res, fmt.Errorf("failed to insert reason : %+v", err)
 	}
+	r.replicas.wrote()
+	req.Version = 1
 	return req, nil
 }
 
@@ -54,11 +56,15 @@ func (r *readWrite) ModifyTopic(ctx context.Context, req *pb.Topic) (res *pb.Top
 	err = row.Scan(
 		&oldTopic.ID,      // id
 		&oldTopic.Created, // created_at
+		&oldTopic.Version, // version
 	)
-	if err != nil || err == sql.ErrNoRows {
-		return res, err
+	if err != nil {
+		return res, translate(err, "topic", req.Id, "")
 	}
 	oldTopic.UseUnixTimeStamp()
+	if req.Version != 0 && req.Version != oldTopic.Version {
+		return res, errs.StaleVersion("topic", req.Id, req.Version)
+	}
 
 	currentTime := time.Now()
 	req.CreatedAt = oldTopic.CreatedAt
@@ -74,13 +80,20 @@ func (r *readWrite) ModifyTopic(ctx context.Context, req *pb.Topic) (res *pb.Top
 		oldTopic.Created, // created_at
 		currentTime,      // updated_at
 		req.Id,           // id
+		oldTopic.Version, // version
 	)
 	if err != nil {
-		return res, err
+		return res, translate(err, "topic", req.Id, "")
 	}
-	if affected, err := result.RowsAffected(); affected == 0 || err != nil {
-		return res, fmt.Errorf("failed to insert reason : %+v", err)
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return res, fmt.Errorf("failed to update reason : %+v", err)
 	}
+	if affected == 0 {
+		return res, errs.StaleVersion("topic", req.Id, oldTopic.Version)
+	}
+	r.replicas.wrote()
+	req.Version = oldTopic.Version + 1
 	return req, nil
 }
 
@@ -98,11 +111,16 @@ func (r *readWrite) RemoveTopic(ctx context.Context, req *pb.Select) error {
 		req.Id, // id
 	)
 	if err != nil {
-		return err
+		return translate(err, "topic", req.Id, "")
 	}
-	if affected, err := result.RowsAffected(); affected == 0 || err != nil {
-		return fmt.Errorf("failed to insert reason : %+v", err)
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to delete reason : %+v", err)
 	}
+	if affected == 0 {
+		return errs.NotFound("topic", req.Id)
+	}
+	r.replicas.wrote()
 	return nil
 }
 
@@ -114,21 +132,16 @@ func (r *readWrite) ReadTopics(ctx context.Context) (res *pb.Topics, err error)
 	var topics pb.Topics
 	var topic model.Topic
 
-	stmt, err := r.db.Prepare(queryReadTopics)
-	if err != nil {
-		return res, err
-	}
-	mutex.Lock()
-	row, err := stmt.QueryContext(ctx)
+	row, err := r.query(ctx, queryReadTopics)
 	if err != nil {
 		return res, err
 	}
-	mutex.Unlock()
 	for row.Next() {
 		err = row.Scan(
 			&topic.ID,       // id
 			&topic.Title,    // title
 			&topic.Headline, // headline
+			&topic.Version,  // version
 			&topic.Created,  // created_at
 			&topic.Updated,  // updated_at
 		)
@@ -140,6 +153,7 @@ func (r *readWrite) ReadTopics(ctx context.Context) (res *pb.Topics, err error)
 			Id:        topic.ID,
 			Title:     topic.Title,
 			Headline:  topic.Headline,
+			Version:   topic.Version,
 			CreatedAt: topic.CreatedAt,
 			UpdatedAt: topic.UpdatedAt,
 		})
@@ -58,10 +58,9 @@ func (ts *sqlTopicTestSuite) TestReadTopics() {
 	for _, test := range tests {
 		ts.Run(test.Name, func() {
 			if !test.WantError {
-				mock.ExpectPrepare(queryReadTopics)
 				mock.ExpectQuery(queryReadTopics).
-					WillReturnRows(sqlmock.NewRows([]string{"id", "title", "headline", "created_at", "updated_at"}).
-						AddRow(test.Request.Id, test.Request.Title, test.Request.Headline, now, now))
+					WillReturnRows(sqlmock.NewRows([]string{"id", "title", "headline", "version", "created_at", "updated_at"}).
+						AddRow(test.Request.Id, test.Request.Title, test.Request.Headline, 1, now, now))
 
 				topics, err := repository.ReadTopics(ctx)
 				ts.Assert().NoError(err)
@@ -71,8 +70,6 @@ func (ts *sqlTopicTestSuite) TestReadTopics() {
 				err = mock.ExpectationsWereMet()
 				ts.Assert().NoError(err)
 			} else {
-				mock.ExpectPrepare(queryReadTopics).
-					WillReturnError(errorDummy)
 				mock.ExpectQuery(queryReadTopics).
 					WillReturnError(errorDummy)
 
@@ -199,11 +196,11 @@ func (ts *sqlTopicTestSuite) TestModifyTopic() {
 				mock.ExpectPrepare(queryLookupCreateAtTopic)
 				mock.ExpectQuery(queryLookupCreateAtTopic).
 					WithArgs(test.Request.Id).
-					WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).
-						AddRow(test.Request.Id, now))
+					WillReturnRows(sqlmock.NewRows([]string{"id", "created_at", "version"}).
+						AddRow(test.Request.Id, now, 1))
 				mock.ExpectPrepare(queryUpdateTopic)
 				mock.ExpectExec(queryUpdateTopic).
-					WithArgs(test.Request.Title, test.Request.Headline, currentDate, currentDate, test.Request.Id).
+					WithArgs(test.Request.Title, test.Request.Headline, currentDate, currentDate, test.Request.Id, 1).
 					WillReturnResult(sqlmock.NewResult(1, 1))
 
 				updatedTopic, err := repository.ModifyTopic(ctx, test.Request)
@@ -0,0 +1,90 @@
+package sql
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"sort"
+	"strings"
+	"time"
+
+	_interface "github.com/muhammadisa/bareksanews/repository/interface"
+	"github.com/muhammadisa/bareksanews/util/dbc"
+	"go.opencensus.io/trace"
+)
+
+//go:embed migrations/*.sql
+var migrations embed.FS
+
+// NewSQLite create the sql repository backed by an embedded sqlite database,
+// pending schema migrations are applied before the repository is returned
+func NewSQLite(config dbc.Config, tracer trace.Tracer) (_interface.ReadWrite, error) {
+	sqliteDB, err := dbc.OpenSQLite(config)
+	if err != nil {
+		return nil, err
+	}
+	err = migrate(context.Background(), sqliteDB)
+	if err != nil {
+		_ = sqliteDB.Close()
+		return nil, err
+	}
+	return &readWrite{
+		db:     sqliteDB,
+		tracer: tracer,
+	}, nil
+}
+
+// migrate apply every embedded migration that is not recorded yet inside
+// schema_migrations table, each file runs inside its own transaction
+func migrate(ctx context.Context, db *sql.DB) error {
+	_, err := db.ExecContext(ctx, queryCreateSchemaMigrations)
+	if err != nil {
+		return err
+	}
+	entries, err := migrations.ReadDir("migrations")
+	if err != nil {
+		return err
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Name() < entries[j].Name()
+	})
+	for _, entry := range entries {
+		version := strings.TrimSuffix(entry.Name(), ".sql")
+		var applied int
+		err = db.QueryRowContext(ctx, queryLookupSchemaMigration, version).Scan(&applied)
+		if err != nil {
+			return err
+		}
+		if applied > 0 {
+			continue
+		}
+		script, err := migrations.ReadFile("migrations/" + entry.Name())
+		if err != nil {
+			return err
+		}
+		tx, err := db.BeginTx(ctx, nil)
+		if err != nil {
+			return err
+		}
+		for _, statement := range strings.Split(string(script), ";") {
+			if strings.TrimSpace(statement) == "" {
+				continue
+			}
+			_, err = tx.ExecContext(ctx, statement)
+			if err != nil {
+				_ = tx.Rollback()
+				return err
+			}
+		}
+		_, err = tx.ExecContext(ctx, queryWriteSchemaMigration, version, time.Now())
+		if err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+		err = tx.Commit()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
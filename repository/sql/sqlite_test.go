@@ -0,0 +1,194 @@
+package sql
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/muhammadisa/bareksanews/model"
+	pb "github.com/muhammadisa/bareksanews/protoc/api/v1"
+	"github.com/muhammadisa/bareksanews/repository/errs"
+	_interface "github.com/muhammadisa/bareksanews/repository/interface"
+	"github.com/muhammadisa/bareksanews/util/dbc"
+	uuid "github.com/satori/go.uuid"
+	"github.com/stretchr/testify/suite"
+	"go.opencensus.io/trace"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type sqliteTestSuite struct {
+	suite.Suite
+	repository _interface.ReadWrite
+}
+
+func TestSQLiteTestSuite(t *testing.T) {
+	suite.Run(t, new(sqliteTestSuite))
+}
+
+func (ts *sqliteTestSuite) SetupTest() {
+	config := dbc.Config{Name: filepath.Join(ts.T().TempDir(), "bareksa_news.db")}
+	repository, err := NewSQLite(config, trace.DefaultTracer)
+	ts.Require().NoError(err)
+	ts.repository = repository
+}
+
+func (ts *sqliteTestSuite) TestMigrateTwice() {
+	rw := ts.repository.(*readWrite)
+	err := migrate(context.Background(), rw.db)
+	ts.Assert().NoError(err)
+}
+
+func (ts *sqliteTestSuite) TestTopicTagNewsRoundTrip() {
+	ctx := context.Background()
+	defer ctx.Done()
+
+	topic, err := ts.repository.WriteTopic(ctx, &pb.Topic{
+		Id:       uuid.NewV4().String(),
+		Title:    "health",
+		Headline: "Talk about health",
+	})
+	ts.Require().NoError(err)
+
+	tag, err := ts.repository.WriteTag(ctx, &pb.Tag{
+		Id:  uuid.NewV4().String(),
+		Tag: "game",
+	})
+	ts.Require().NoError(err)
+
+	news, err := ts.repository.WriteNews(ctx, &pb.News{
+		Id:      uuid.NewV4().String(),
+		TopicId: topic.Id,
+		Title:   "title news number 1",
+		Content: "content news 1",
+		Summary: "summary news 1",
+		Status:  1,
+	})
+	ts.Require().NoError(err)
+	err = ts.repository.WriteNewsTags(ctx, news.Id, []string{tag.Id}, true)
+	ts.Require().NoError(err)
+
+	newses, err := ts.repository.ReadNewsesByStatusAndTopicID(ctx, 1, topic.Id, model.Page{})
+	ts.Require().NoError(err)
+	ts.Require().Len(newses.Newses, 1)
+	ts.Assert().Equal(news.Id, newses.Newses[0].Id)
+	ts.Assert().Equal([]string{tag.Id}, newses.Newses[0].NewsTagIds)
+	ts.Assert().Equal([]string{tag.Tag}, newses.Newses[0].NewsTagNames)
+	ts.Assert().Equal(news.CreatedAt, newses.Newses[0].CreatedAt)
+	ts.Assert().Equal(news.Summary, newses.Newses[0].Summary)
+
+	news.Title = "title news number 1 edited"
+	news, err = ts.repository.ModifyNews(ctx, news)
+	ts.Require().NoError(err)
+	ts.Assert().Equal(int64(2), news.Version)
+
+	stale := &pb.News{Id: news.Id, TopicId: topic.Id, Title: "stale edit", Version: 1}
+	_, err = ts.repository.ModifyNews(ctx, stale)
+	ts.Assert().Equal(codes.Aborted, status.Code(err))
+	newses, err = ts.repository.ReadNewses(ctx, model.Page{})
+	ts.Require().NoError(err)
+	ts.Require().Len(newses.Newses, 1)
+	ts.Assert().Equal(news.Title, newses.Newses[0].Title)
+
+	tags, err := ts.repository.ReadTags(ctx)
+	ts.Require().NoError(err)
+	ts.Assert().Len(tags.Tags, 1)
+
+	err = ts.repository.RemoveTopic(ctx, &pb.Select{Id: topic.Id})
+	ts.Require().NoError(err)
+	newses, err = ts.repository.ReadNewses(ctx, model.Page{})
+	ts.Require().NoError(err)
+	ts.Assert().Len(newses.Newses, 0)
+}
+
+func (ts *sqliteTestSuite) TestWriteNewsUnknownTopic() {
+	ctx := context.Background()
+	defer ctx.Done()
+
+	_, err := ts.repository.WriteNews(ctx, &pb.News{
+		Id:      uuid.NewV4().String(),
+		TopicId: uuid.NewV4().String(),
+		Title:   "title news number 1",
+		Content: "content news 1",
+		Status:  1,
+	})
+	ts.Assert().True(errors.Is(err, errs.ErrForeignKeyViolation))
+	ts.Assert().Equal(codes.FailedPrecondition, status.Code(err))
+}
+
+func (ts *sqliteTestSuite) TestModifyUnknownNews() {
+	ctx := context.Background()
+	defer ctx.Done()
+
+	_, err := ts.repository.ModifyNews(ctx, &pb.News{Id: uuid.NewV4().String()})
+	ts.Assert().True(errors.Is(err, errs.ErrNotFound))
+
+	err = ts.repository.RemoveNews(ctx, &pb.Select{Id: uuid.NewV4().String()})
+	ts.Assert().Equal(codes.NotFound, status.Code(err))
+}
+
+func (ts *sqliteTestSuite) TestWriteNewsBatch() {
+	ctx := context.Background()
+	defer ctx.Done()
+
+	topic, err := ts.repository.WriteTopic(ctx, &pb.Topic{Id: uuid.NewV4().String(), Title: "health"})
+	ts.Require().NoError(err)
+	tag, err := ts.repository.WriteTag(ctx, &pb.Tag{Id: uuid.NewV4().String(), Tag: "game"})
+	ts.Require().NoError(err)
+
+	batch := []*pb.News{
+		{Id: uuid.NewV4().String(), TopicId: topic.Id, Title: "news 1", Status: 1, NewsTagIds: []string{tag.Id}},
+		{Id: uuid.NewV4().String(), TopicId: topic.Id, Title: "news 2", Status: 1},
+	}
+	err = ts.repository.WriteNewsBatch(ctx, batch)
+	ts.Require().NoError(err)
+	ts.Assert().Equal(int64(1), batch[0].Version)
+
+	newses, err := ts.repository.ReadNewses(ctx, model.Page{})
+	ts.Require().NoError(err)
+	ts.Assert().Len(newses.Newses, 2)
+
+	newses, err = ts.repository.ReadNewses(ctx, model.Page{Limit: 1, Offset: 1})
+	ts.Require().NoError(err)
+	ts.Assert().Len(newses.Newses, 1)
+
+	// one news referencing an unknown topic rolls back the whole batch
+	failing := []*pb.News{
+		{Id: uuid.NewV4().String(), TopicId: topic.Id, Title: "news 3", Status: 1},
+		{Id: uuid.NewV4().String(), TopicId: uuid.NewV4().String(), Title: "news 4", Status: 1},
+	}
+	err = ts.repository.WriteNewsBatch(ctx, failing)
+	ts.Assert().True(errors.Is(err, errs.ErrForeignKeyViolation))
+
+	newses, err = ts.repository.ReadNewses(ctx, model.Page{})
+	ts.Require().NoError(err)
+	ts.Assert().Len(newses.Newses, 2)
+}
+
+func (ts *sqliteTestSuite) TestReadNewsSummaries() {
+	ctx := context.Background()
+	defer ctx.Done()
+
+	topic, err := ts.repository.WriteTopic(ctx, &pb.Topic{Id: uuid.NewV4().String(), Title: "health"})
+	ts.Require().NoError(err)
+	summarized, err := ts.repository.WriteNews(ctx, &pb.News{
+		Id: uuid.NewV4().String(), TopicId: topic.Id, Title: "news 1", Content: "long content", Summary: "short", Status: 1,
+	})
+	ts.Require().NoError(err)
+	unsummarized, err := ts.repository.WriteNews(ctx, &pb.News{
+		Id: uuid.NewV4().String(), TopicId: topic.Id, Title: "news 2", Content: "older content", Status: 1,
+	})
+	ts.Require().NoError(err)
+
+	newses, err := ts.repository.ReadNewsesByTopicID(ctx, topic.Id, model.Page{SummaryOnly: true})
+	ts.Require().NoError(err)
+	ts.Require().Len(newses.Newses, 2)
+	contents := make(map[string]string, len(newses.Newses))
+	for _, news := range newses.Newses {
+		contents[news.Id] = news.Content
+	}
+	// the content is only read for the news left to be summarized
+	ts.Assert().Equal("", contents[summarized.Id])
+	ts.Assert().Equal("older content", contents[unsummarized.Id])
+}
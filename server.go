@@ -0,0 +1,169 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/go-kit/kit/log/level"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"github.com/muhammadisa/bareksanews/constant"
+	"github.com/muhammadisa/bareksanews/gvars"
+	pb "github.com/muhammadisa/bareksanews/protoc/api/v1"
+	"github.com/muhammadisa/bareksanews/transport"
+	"github.com/muhammadisa/bareksanews/util/hdr"
+	"github.com/muhammadisa/bareksanews/util/hlth"
+	"github.com/muhammadisa/bareksanews/util/lgr"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/soheilhy/cmux"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
+)
+
+// NewGRPCServer the GRPC server merged by MergeServer, healthServer answers
+// grpc.health.v1, server reflection lets clients like grpcurl discover the
+// services
+func NewGRPCServer(service pb.BareksaNewsServiceServer, serverOptions []grpc.ServerOption, healthServer *health.Server) *grpc.Server {
+	level.Info(gvars.Log).Log(lgr.LogInfo, "initialize grpc server")
+
+	grpcServer := grpc.NewServer(serverOptions...)
+	pb.RegisterBareksaNewsServiceServer(grpcServer, service)
+	healthpb.RegisterHealthServer(grpcServer, healthServer)
+	reflection.Register(grpcServer)
+	return grpcServer
+}
+
+// NewHTTPServer the rest gateway merged by MergeServer, /healthz and
+// /readyz answer the checks of checker, /metrics the prometheus metrics,
+// /openapi.json and /docs document the rest api
+func NewHTTPServer(service pb.BareksaNewsServiceServer, checker *hlth.Checker) (*http.Server, error) {
+	level.Info(gvars.Log).Log(lgr.LogInfo, "initialize rest server")
+
+	mux := runtime.NewServeMux(
+		runtime.WithIncomingHeaderMatcher(hdr.HeaderMatcher),
+		runtime.WithErrorHandler(hdr.HTTPErrorHandler),
+	)
+	err := pb.RegisterBareksaNewsServiceHandlerServer(context.Background(), mux, service)
+	if err != nil {
+		return nil, err
+	}
+	err = transport.RegisterNDJSONHandlers(mux, service)
+	if err != nil {
+		return nil, err
+	}
+	err = transport.RegisterOpenAPIHandlers(mux)
+	if err != nil {
+		return nil, err
+	}
+	httpMux := http.NewServeMux()
+	httpMux.Handle("/", mux)
+	httpMux.Handle("/healthz", checker.LivenessHandler())
+	httpMux.Handle("/readyz", checker.ReadinessHandler())
+	httpMux.Handle("/metrics", promhttp.Handler())
+	return &http.Server{Handler: hdr.CORS(httpMux)}, nil
+}
+
+// mergedServer the GRPC server and the rest gateway sharing one listener
+type mergedServer struct {
+	listener net.Listener
+	mux      cmux.CMux
+	grpc     *grpc.Server
+	http     *http.Server
+	checker  *hlth.Checker
+	health   *health.Server
+}
+
+// MergeServer listen on addr and split the connections between the GRPC
+// server and the rest gateway, both report the readiness of checker, the
+// connections are served over tls unless tlsConfig is nil
+func MergeServer(addr string, service pb.BareksaNewsServiceServer, serverOptions []grpc.ServerOption, checker *hlth.Checker, tlsConfig *tls.Config) (*mergedServer, error) {
+	httpServer, err := NewHTTPServer(service, checker)
+	if err != nil {
+		return nil, err
+	}
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	// cmux matches the decrypted bytes, tls ends before the connections are
+	// split
+	if tlsConfig != nil {
+		listener = tls.NewListener(listener, tlsConfig)
+	}
+	healthServer := health.NewServer()
+	return &mergedServer{
+		listener: listener,
+		mux:      cmux.New(listener),
+		grpc:     NewGRPCServer(service, serverOptions, healthServer),
+		http:     httpServer,
+		checker:  checker,
+		health:   healthServer,
+	}, nil
+}
+
+// Run serve until ctx is done then shut down with delay and timeout, a
+// server failing by itself stops the others the same way
+func (s *mergedServer) Run(ctx context.Context, delay, timeout time.Duration) error {
+	grpcListener := s.mux.MatchWithWriters(cmux.HTTP2MatchHeaderFieldSendSettings(
+		"content-type", "application/grpc",
+	))
+	httpListener := s.mux.Match(cmux.HTTP1Fast())
+
+	watchCtx, stopWatch := context.WithCancel(context.Background())
+	defer stopWatch()
+	go s.checker.Watch(watchCtx, s.health, constant.HealthWatchSeconds*time.Second, pb.BareksaNewsService_ServiceDesc.ServiceName)
+
+	failed := make(chan error, 3)
+	go func() { failed <- s.grpc.Serve(grpcListener) }()
+	go func() { failed <- s.http.Serve(httpListener) }()
+	go func() { failed <- s.mux.Serve() }()
+
+	var err error
+	select {
+	case <-ctx.Done():
+	case err = <-failed:
+	}
+	if shutdownErr := s.Shutdown(delay, timeout); err == nil {
+		err = shutdownErr
+	}
+	return err
+}
+
+// Shutdown report the server not ready for delay, then stop accepting
+// connections and wait for the requests in flight, the ones still running
+// after timeout are cut off
+func (s *mergedServer) Shutdown(delay, timeout time.Duration) error {
+	s.checker.Drain()
+	s.health.Shutdown()
+	time.Sleep(delay)
+	level.Info(gvars.Log).Log(lgr.LogInfo, "draining requests in flight")
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	stopped := make(chan struct{})
+	go func() {
+		s.grpc.GracefulStop()
+		close(stopped)
+	}()
+	err := s.http.Shutdown(ctx)
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		s.grpc.Stop()
+		err = ctx.Err()
+	}
+
+	// the listeners of both servers close the shared listener, closing it
+	// again only reports it is closed already
+	s.mux.Close()
+	if closeErr := s.listener.Close(); err == nil && !errors.Is(closeErr, net.ErrClosed) {
+		err = closeErr
+	}
+	return err
+}
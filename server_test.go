@@ -0,0 +1,352 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/muhammadisa/bareksanews/gvars"
+	pb "github.com/muhammadisa/bareksanews/protoc/api/v1"
+	"github.com/muhammadisa/bareksanews/util/crt"
+	"github.com/muhammadisa/bareksanews/util/crt/crttest"
+	"github.com/muhammadisa/bareksanews/util/hlth"
+	"github.com/stretchr/testify/suite"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	reflectionpb "google.golang.org/grpc/reflection/grpc_reflection_v1alpha"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// blockingService answer GetTags once release is closed
+type blockingService struct {
+	pb.UnimplementedBareksaNewsServiceServer
+	started chan struct{}
+	release chan struct{}
+}
+
+func (s *blockingService) GetTags(context.Context, *emptypb.Empty) (*pb.Tags, error) {
+	s.started <- struct{}{}
+	<-s.release
+	return &pb.Tags{Tags: []*pb.Tag{{Id: "c63b17cc-e227-4947-a01f-74f429ce99be", Tag: "tech"}}}, nil
+}
+
+type serverTestSuite struct {
+	suite.Suite
+	service *blockingService
+	// database fails the required check while it is set
+	database error
+	mu       sync.Mutex
+	server   *mergedServer
+	// tlsConfig the server is run with, nil serves plaintext
+	tlsConfig *tls.Config
+	addr      string
+	stop      context.CancelFunc
+	stopped   chan error
+}
+
+func TestServerTestSuite(t *testing.T) {
+	suite.Run(t, new(serverTestSuite))
+}
+
+func (ts *serverTestSuite) SetupSuite() {
+	gvars.Log = log.NewNopLogger()
+}
+
+func (ts *serverTestSuite) SetupTest() {
+	ts.tlsConfig = nil
+}
+
+func (ts *serverTestSuite) setDatabase(err error) {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	ts.database = err
+}
+
+func (ts *serverTestSuite) run(delay, timeout time.Duration) {
+	ts.service = &blockingService{started: make(chan struct{}, 2), release: make(chan struct{})}
+	ts.setDatabase(nil)
+	checker := hlth.NewChecker(time.Second)
+	checker.Register("database", true, func(context.Context) error {
+		ts.mu.Lock()
+		defer ts.mu.Unlock()
+		return ts.database
+	})
+	checker.Register("cache", false, func(context.Context) error {
+		return errors.New("connection refused")
+	})
+	server, err := MergeServer("127.0.0.1:0", ts.service, nil, checker, ts.tlsConfig)
+	ts.Require().NoError(err)
+	ts.server = server
+	ts.addr = server.listener.Addr().String()
+
+	ctx, stop := context.WithCancel(context.Background())
+	ts.stop = stop
+	ts.stopped = make(chan error, 1)
+	go func() { ts.stopped <- server.Run(ctx, delay, timeout) }()
+}
+
+// readiness the status code and report of /readyz
+func (ts *serverTestSuite) readiness() (int, hlth.Report) {
+	res, err := http.Get("http://" + ts.addr + "/readyz")
+	ts.Require().NoError(err)
+	defer res.Body.Close()
+	var report hlth.Report
+	ts.Require().NoError(json.NewDecoder(res.Body).Decode(&report))
+	return res.StatusCode, report
+}
+
+func (ts *serverTestSuite) TestHealth() {
+	ts.run(0, time.Second)
+	defer func() {
+		ts.stop()
+		ts.Assert().NoError(<-ts.stopped)
+	}()
+
+	res, err := http.Get("http://" + ts.addr + "/healthz")
+	ts.Require().NoError(err)
+	res.Body.Close()
+	ts.Assert().Equal(http.StatusOK, res.StatusCode)
+
+	res, err = http.Get("http://" + ts.addr + "/metrics")
+	ts.Require().NoError(err)
+	metrics, err := ioutil.ReadAll(res.Body)
+	res.Body.Close()
+	ts.Require().NoError(err)
+	ts.Assert().Equal(http.StatusOK, res.StatusCode)
+	ts.Assert().Contains(string(metrics), "bareksa_news_cache_degraded 0")
+
+	// a failing optional check is reported without making the service unready
+	code, report := ts.readiness()
+	ts.Assert().Equal(http.StatusOK, code)
+	ts.Assert().Equal(hlth.StatusOK, report.Status)
+	ts.Require().Len(report.Checks, 2)
+	ts.Assert().Equal("cache", report.Checks[1].Name)
+	ts.Assert().Equal(hlth.StatusUnavailable, report.Checks[1].Status)
+	ts.Assert().Equal("connection refused", report.Checks[1].Error)
+
+	ts.setDatabase(errors.New("too many connections"))
+	code, report = ts.readiness()
+	ts.Assert().Equal(http.StatusServiceUnavailable, code)
+	ts.Assert().Equal(hlth.StatusUnavailable, report.Status)
+
+	ts.setDatabase(nil)
+	conn, err := grpc.Dial(ts.addr, grpc.WithInsecure())
+	ts.Require().NoError(err)
+	defer conn.Close()
+	client := healthpb.NewHealthClient(conn)
+	for _, service := range []string{"", "api.v1.BareksaNewsService"} {
+		ts.Eventually(func() bool {
+			res, err := client.Check(context.Background(), &healthpb.HealthCheckRequest{Service: service})
+			return err == nil && res.Status == healthpb.HealthCheckResponse_SERVING
+		}, time.Second, 5*time.Millisecond)
+	}
+}
+
+func (ts *serverTestSuite) TestDiscovery() {
+	ts.run(0, time.Second)
+	defer func() {
+		ts.stop()
+		ts.Assert().NoError(<-ts.stopped)
+	}()
+
+	conn, err := grpc.Dial(ts.addr, grpc.WithInsecure())
+	ts.Require().NoError(err)
+	defer conn.Close()
+	stream, err := reflectionpb.NewServerReflectionClient(conn).ServerReflectionInfo(context.Background())
+	ts.Require().NoError(err)
+	ts.Require().NoError(stream.Send(&reflectionpb.ServerReflectionRequest{
+		MessageRequest: &reflectionpb.ServerReflectionRequest_ListServices{},
+	}))
+	res, err := stream.Recv()
+	ts.Require().NoError(err)
+	ts.Require().NoError(stream.CloseSend())
+	var services []string
+	for _, service := range res.GetListServicesResponse().GetService() {
+		services = append(services, service.Name)
+	}
+	ts.Assert().Contains(services, "api.v1.BareksaNewsService")
+	ts.Assert().Contains(services, "grpc.health.v1.Health")
+
+	document, err := http.Get("http://" + ts.addr + "/openapi.json")
+	ts.Require().NoError(err)
+	defer document.Body.Close()
+	ts.Assert().Equal(http.StatusOK, document.StatusCode)
+	ts.Assert().Equal("application/json", document.Header.Get("Content-Type"))
+	var openAPI struct {
+		Swagger string                     `json:"swagger"`
+		Paths   map[string]json.RawMessage `json:"paths"`
+	}
+	ts.Require().NoError(json.NewDecoder(document.Body).Decode(&openAPI))
+	ts.Assert().Equal("2.0", openAPI.Swagger)
+	for _, path := range []string{"/v1/tags", "/v1/topic/{id}", "/v1/newses", "/v1/admin/cache/rebuild"} {
+		ts.Assert().Contains(openAPI.Paths, path)
+	}
+
+	docs, err := http.Get("http://" + ts.addr + "/docs")
+	ts.Require().NoError(err)
+	page, err := ioutil.ReadAll(docs.Body)
+	docs.Body.Close()
+	ts.Require().NoError(err)
+	ts.Assert().Equal(http.StatusOK, docs.StatusCode)
+	ts.Assert().Contains(string(page), `url: "/openapi.json"`)
+
+	// the swagger ui assets are served by the gateway itself
+	for _, asset := range []string{"/docs/swagger-ui.css", "/docs/swagger-ui-bundle.js"} {
+		res, err := http.Get("http://" + ts.addr + asset)
+		ts.Require().NoError(err)
+		res.Body.Close()
+		ts.Assert().Equal(http.StatusOK, res.StatusCode, asset)
+	}
+	ts.Assert().NotContains(string(page), "unpkg.com")
+}
+
+func (ts *serverTestSuite) TestTLS() {
+	serverCA, err := crttest.NewCA("server ca")
+	ts.Require().NoError(err)
+	clientCA, err := crttest.NewCA("client ca")
+	ts.Require().NoError(err)
+
+	dir := ts.T().TempDir()
+	config := crt.Config{
+		CertFile:       filepath.Join(dir, "server.crt"),
+		KeyFile:        filepath.Join(dir, "server.key"),
+		ClientCAFile:   filepath.Join(dir, "client_ca.crt"),
+		ReloadInterval: time.Hour,
+	}
+	issue := func(name string) {
+		certPEM, keyPEM, err := serverCA.Issue(name, "127.0.0.1")
+		ts.Require().NoError(err)
+		ts.Require().NoError(ioutil.WriteFile(config.CertFile, certPEM, 0600))
+		ts.Require().NoError(ioutil.WriteFile(config.KeyFile, keyPEM, 0600))
+	}
+	issue("first")
+	ts.Require().NoError(ioutil.WriteFile(config.ClientCAFile, clientCA.PEM, 0600))
+	reloader, err := crt.NewReloader(config)
+	ts.Require().NoError(err)
+
+	ts.tlsConfig = reloader.TLSConfig()
+	ts.run(0, time.Second)
+	defer func() {
+		ts.stop()
+		ts.Assert().NoError(<-ts.stopped)
+	}()
+
+	clientCert, err := clientCA.IssueTLS("internal caller")
+	ts.Require().NoError(err)
+	trusted := &tls.Config{RootCAs: serverCA.Pool(), Certificates: []tls.Certificate{clientCert}}
+
+	// grpc negotiates h2
+	conn, err := grpc.Dial(ts.addr, grpc.WithTransportCredentials(credentials.NewTLS(trusted.Clone())))
+	ts.Require().NoError(err)
+	defer conn.Close()
+	ts.Eventually(func() bool {
+		res, err := healthpb.NewHealthClient(conn).Check(context.Background(), &healthpb.HealthCheckRequest{})
+		return err == nil && res.Status == healthpb.HealthCheckResponse_SERVING
+	}, time.Second, 5*time.Millisecond)
+
+	// rest clients offering h2 are answered with http/1.1
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: trusted.Clone(), ForceAttemptHTTP2: true}}
+	res, err := client.Get("https://" + ts.addr + "/healthz")
+	ts.Require().NoError(err)
+	res.Body.Close()
+	ts.Assert().Equal(http.StatusOK, res.StatusCode)
+	ts.Assert().Equal(1, res.ProtoMajor)
+
+	// callers without a client certificate and plaintext are refused
+	anonymous := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: serverCA.Pool()}}}
+	_, err = anonymous.Get("https://" + ts.addr + "/healthz")
+	ts.Assert().Error(err)
+	_, err = http.Get("http://" + ts.addr + "/healthz")
+	ts.Assert().Error(err)
+
+	// a renewed certificate is served by the next handshake
+	issue("second")
+	reloaded, err := reloader.Reload()
+	ts.Require().NoError(err)
+	ts.Assert().True(reloaded)
+	tlsConn, err := tls.Dial("tcp", ts.addr, trusted.Clone())
+	ts.Require().NoError(err)
+	defer tlsConn.Close()
+	ts.Assert().Equal("second", tlsConn.ConnectionState().PeerCertificates[0].Subject.CommonName)
+}
+
+func (ts *serverTestSuite) TestDrainsRequestsInFlight() {
+	ts.run(200*time.Millisecond, 5*time.Second)
+
+	conn, err := grpc.Dial(ts.addr, grpc.WithInsecure())
+	ts.Require().NoError(err)
+	defer conn.Close()
+
+	grpcDone := make(chan error, 1)
+	go func() {
+		_, err := pb.NewBareksaNewsServiceClient(conn).GetTags(context.Background(), &emptypb.Empty{})
+		grpcDone <- err
+	}()
+	<-ts.service.started
+
+	httpDone := make(chan *http.Response, 1)
+	go func() {
+		res, err := http.Get("http://" + ts.addr + "/v1/tags")
+		if err == nil {
+			res.Body.Close()
+		}
+		httpDone <- res
+	}()
+	<-ts.service.started
+
+	ts.stop()
+	// the service is reported unready while it still accepts connections
+	ts.Eventually(func() bool { return ts.server.checker.Draining() }, time.Second, 5*time.Millisecond)
+	code, report := ts.readiness()
+	ts.Assert().Equal(http.StatusServiceUnavailable, code)
+	ts.Assert().Equal(hlth.StatusDraining, report.Status)
+	health, err := healthpb.NewHealthClient(conn).Check(context.Background(), &healthpb.HealthCheckRequest{})
+	ts.Require().NoError(err)
+	ts.Assert().Equal(healthpb.HealthCheckResponse_NOT_SERVING, health.Status)
+
+	// no connection is accepted anymore while the requests drain
+	ts.Eventually(func() bool {
+		conn, err := net.Dial("tcp", ts.addr)
+		if err == nil {
+			conn.Close()
+		}
+		return err != nil
+	}, time.Second, 5*time.Millisecond)
+
+	close(ts.service.release)
+	ts.Assert().NoError(<-grpcDone)
+	res := <-httpDone
+	ts.Require().NotNil(res)
+	ts.Assert().Equal(http.StatusOK, res.StatusCode)
+	ts.Assert().NoError(<-ts.stopped)
+}
+
+func (ts *serverTestSuite) TestCutsOffAfterTimeout() {
+	ts.run(0, 50*time.Millisecond)
+	defer close(ts.service.release)
+
+	conn, err := grpc.Dial(ts.addr, grpc.WithInsecure())
+	ts.Require().NoError(err)
+	defer conn.Close()
+
+	grpcDone := make(chan error, 1)
+	go func() {
+		_, err := pb.NewBareksaNewsServiceClient(conn).GetTags(context.Background(), &emptypb.Empty{})
+		grpcDone <- err
+	}()
+	<-ts.service.started
+
+	ts.stop()
+	ts.Assert().ErrorIs(<-ts.stopped, context.DeadlineExceeded)
+	ts.Assert().Error(<-grpcDone)
+}
@@ -0,0 +1,46 @@
+package service
+
+import (
+	"context"
+
+	"github.com/muhammadisa/bareksanews/constant"
+	pb "github.com/muhammadisa/bareksanews/protoc/api/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// ListCacheKeys the keys of this service namespace only, a zero limit lists
+// up to constant.MaxCacheKeys
+func (s service) ListCacheKeys(ctx context.Context, req *pb.CacheKeysRequest) (res *pb.CacheKeys, err error) {
+	const funcName = `ListCacheKeys`
+	_, span := s.tracer.StartSpan(ctx, funcName)
+	defer span.End()
+
+	if req.Limit < 0 || req.Limit > constant.MaxCacheKeys {
+		return nil, status.Errorf(codes.InvalidArgument, "limit must be between 0 and %d", constant.MaxCacheKeys)
+	}
+	limit := int(req.Limit)
+	if limit == 0 {
+		limit = constant.MaxCacheKeys
+	}
+	keys, truncated, err := s.repo.CacheReadWriter.ListKeys(ctx, limit)
+	if err != nil {
+		return nil, err
+	}
+	return &pb.CacheKeys{Keys: keys, Truncated: truncated}, nil
+}
+
+// FlushCache delete the namespace of this service, every value is reloaded
+// from the database on its next read
+func (s service) FlushCache(ctx context.Context, _ *emptypb.Empty) (res *pb.FlushCacheResult, err error) {
+	const funcName = `FlushCache`
+	_, span := s.tracer.StartSpan(ctx, funcName)
+	defer span.End()
+
+	deleted, err := s.repo.CacheReadWriter.FlushKeys(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &pb.FlushCacheResult{Deleted: deleted}, nil
+}
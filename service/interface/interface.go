@@ -1,7 +1,18 @@
 package _interface
 
-import pb "github.com/muhammadisa/bareksanews/protoc/api/v1"
+import (
+	"context"
+
+	pb "github.com/muhammadisa/bareksanews/protoc/api/v1"
+)
 
 type Service interface {
 	pb.BareksaNewsServiceServer
+
+	// WarmUp preload the cache from the database without flushing it first
+	WarmUp(ctx context.Context) (*pb.RebuildCacheResult, error)
+
+	// Wait block until the cache reloads still running in the background
+	// are done
+	Wait()
 }
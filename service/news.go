@@ -2,10 +2,19 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	uuid "github.com/satori/go.uuid"
+	"strings"
+	"unicode/utf8"
 
+	"github.com/muhammadisa/bareksanews/constant"
+	"github.com/muhammadisa/bareksanews/model"
 	pb "github.com/muhammadisa/bareksanews/protoc/api/v1"
+	"github.com/muhammadisa/bareksanews/repository/errs"
+	"github.com/muhammadisa/bareksanews/util/smry"
+	uuid "github.com/satori/go.uuid"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/types/known/emptypb"
 )
 
@@ -15,6 +24,10 @@ func (s service) AddNews(ctx context.Context, news *pb.News) (res *emptypb.Empty
 	defer span.End()
 
 	news.Id = uuid.NewV4().String()
+	if err = validateSummary(news); err != nil {
+		return nil, err
+	}
+	summarize(news)
 	newNews, err := s.repo.ReadWriter.WriteNews(ctx, news)
 	if err != nil {
 		return nil, err
@@ -32,7 +45,10 @@ func (s service) EditNews(ctx context.Context, news *pb.News) (res *emptypb.Empt
 	_, span := s.tracer.StartSpan(ctx, funcName)
 	defer span.End()
 
-	_ = s.repo.CacheReadWriter.UnsetNews(ctx, news.Id)
+	if err = validateSummary(news); err != nil {
+		return nil, err
+	}
+	summarize(news)
 	oldNews, err := s.repo.ReadWriter.ModifyNews(ctx, news)
 	if err != nil {
 		return nil, err
@@ -49,7 +65,6 @@ func (s service) DeleteNews(ctx context.Context, selectNews *pb.Select) (res *em
 	_, span := s.tracer.StartSpan(ctx, funcName)
 	defer span.End()
 
-	_ = s.repo.CacheReadWriter.UnsetNews(ctx, selectNews.Id)
 	err = s.repo.ReadWriter.RemoveNews(ctx, selectNews)
 	if err != nil {
 		return nil, err
@@ -71,33 +86,123 @@ func (s service) filterRedisKeyGenerator(ctx context.Context, filters *pb.Filter
 	} else if filters.TopicId == "" && filters.Status == 0 {
 		res = "none"
 	}
+	if filters.SummaryOnly {
+		res += "_summary_only"
+	}
+	if page := pageOf(filters); page.Limit > 0 {
+		res += fmt.Sprintf("_page_%d_%d", page.Limit, page.Offset)
+	}
 	return
 }
 
+// validateSummary reject a summary given by the editor that does not fit
+// the news.summary column, an extracted summary always fits
+func validateSummary(news *pb.News) error {
+	if utf8.RuneCountInString(news.Summary) > constant.SummaryMaxLength {
+		return errs.InvalidArgument("news", news.Id, "summary", fmt.Errorf("longer than %d runes", constant.SummaryMaxLength))
+	}
+	return nil
+}
+
+// summarize fill an empty summary with the first sentences of the content
+func summarize(news *pb.News) {
+	if strings.TrimSpace(news.Summary) == "" {
+		news.Summary = smry.Extract(news.Content, constant.SummarySentences, constant.SummaryMaxLength)
+	}
+}
+
+// pageOf the window of the one based page of filters, every news is read
+// when no page_size is given
+func pageOf(filters *pb.Filters) model.Page {
+	if filters.PageSize <= 0 {
+		return model.Page{SummaryOnly: filters.SummaryOnly}
+	}
+	page := filters.Page
+	if page < 1 {
+		page = 1
+	}
+	return model.Page{Limit: filters.PageSize, Offset: (page - 1) * filters.PageSize, SummaryOnly: filters.SummaryOnly}
+}
+
+// readNewses read the newses matching the filters straight from the database,
+// only the content of unsummarized newses is read when only the summaries
+// are requested
+func (s service) readNewses(ctx context.Context, filters *pb.Filters) (*pb.Newses, error) {
+	return s.readPage(ctx, filters, pageOf(filters))
+}
+
+// readPage read page of the newses matching the filters, ignoring the page
+// and page_size of the filters
+func (s service) readPage(ctx context.Context, filters *pb.Filters, page model.Page) (res *pb.Newses, err error) {
+	if filters.TopicId != "" && filters.Status != 0 {
+		res, err = s.repo.ReadWriter.ReadNewsesByStatusAndTopicID(ctx, filters.Status, filters.TopicId, page)
+	} else if filters.Status != 0 {
+		res, err = s.repo.ReadWriter.ReadNewsesByStatus(ctx, filters.Status, page)
+	} else if filters.TopicId != "" {
+		res, err = s.repo.ReadWriter.ReadNewsesByTopicID(ctx, filters.TopicId, page)
+	} else {
+		res, err = s.repo.ReadWriter.ReadNewses(ctx, page)
+	}
+	if err != nil {
+		return nil, err
+	}
+	for _, news := range res.Newses {
+		// newses written before summaries existed are summarized on read
+		summarize(news)
+		if filters.SummaryOnly {
+			news.Content = ""
+		}
+	}
+	return res, nil
+}
+
+// reloadNewses read the newses matching filters from the database and cache
+// them as the listing under key and the stale listing of filter
+func (s service) reloadNewses(ctx context.Context, filters *pb.Filters, filter, key string) (*pb.Newses, error) {
+	res, err := s.readNewses(ctx, filters)
+	if err != nil {
+		return nil, err
+	}
+	_ = s.repo.CacheReadWriter.SetNewses(ctx, key, res)
+	_ = s.repo.CacheReadWriter.SetStaleNewses(ctx, filter, key, res)
+	return res, nil
+}
+
 func (s service) GetNewses(ctx context.Context, filters *pb.Filters) (res *pb.Newses, err error) {
 	const funcName = `GetNewses`
 	_, span := s.tracer.StartSpan(ctx, funcName)
 	defer span.End()
 
-	filterValue := s.filterRedisKeyGenerator(ctx, filters)
-	if reload := s.repo.CacheReadWriter.ReloadRequired(ctx, filterValue); reload {
-		_ = s.repo.CacheReadWriter.SetFilter(ctx, filterValue)
-		if filters.TopicId != "" && filters.Status != 0 {
-			res, err = s.repo.ReadWriter.ReadNewsesByStatusAndTopicID(ctx, filters.Status, filters.TopicId)
-		} else if filters.Status != 0 {
-			res, err = s.repo.ReadWriter.ReadNewsesByStatus(ctx, filters.Status)
-		} else if filters.TopicId != "" {
-			res, err = s.repo.ReadWriter.ReadNewsesByTopicID(ctx, filters.TopicId)
-		} else if filters.TopicId == "" && filters.Status == 0 {
-			res, err = s.repo.ReadWriter.ReadNewses(ctx)
-		}
-		if res != nil{
-			_ = s.repo.CacheReadWriter.ReloadNewses(ctx, res)
-		}
-		fmt.Println("database")
+	if filters.Page < 0 || filters.PageSize < 0 || filters.PageSize > constant.MaxPageSize {
+		return nil, status.Errorf(codes.InvalidArgument, "page must not be negative and page_size must be between 0 and %d", constant.MaxPageSize)
+	}
+	filter := s.filterRedisKeyGenerator(ctx, filters)
+	key, err := s.repo.CacheReadWriter.NewsesKey(ctx, filter)
+	if err != nil {
+		return s.readNewses(ctx, filters)
+	}
+	res, err = s.repo.CacheReadWriter.GetNewses(ctx, key)
+	if err == nil {
 		return res, nil
-	} else {
-		fmt.Println("redis")
-		return s.repo.CacheReadWriter.GetNewses(ctx)
 	}
+	if errors.Is(err, errs.ErrCacheUnavailable) {
+		return s.readNewses(ctx, filters)
+	}
+
+	result := s.refresh(ctx, key, func(ctx context.Context) (interface{}, error) {
+		return s.reloadNewses(ctx, filters, filter, key)
+	}, func(ctx context.Context) (interface{}, bool) {
+		res, err := s.repo.CacheReadWriter.GetNewses(ctx, key)
+		return res, err == nil
+	})
+	// the listing cached before the last write is served while it reloads,
+	// a listing of the same key only expired and is waited for
+	if stale, staleKey, err := s.repo.CacheReadWriter.GetStaleNewses(ctx, filter); err == nil && staleKey != key {
+		return stale, nil
+	}
+	value, err := await(ctx, result)
+	if err != nil {
+		return nil, err
+	}
+	return value.(*pb.Newses), nil
 }
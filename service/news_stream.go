@@ -0,0 +1,265 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/muhammadisa/bareksanews/constant"
+	"github.com/muhammadisa/bareksanews/model"
+	pb "github.com/muhammadisa/bareksanews/protoc/api/v1"
+	"github.com/muhammadisa/bareksanews/repository/errs"
+	uuid "github.com/satori/go.uuid"
+)
+
+// importer resolve topic and tag names of imported records into ids, the
+// missing ones are created unless the import is a dry run
+type importer struct {
+	service
+	dryRun bool
+	topics map[string]string
+	tags   map[string]string
+	result *pb.ImportNewsResult
+
+	batch   []*pb.News
+	indexes []int64
+}
+
+func (s service) newImporter(ctx context.Context) (*importer, error) {
+	topics, err := s.repo.ReadWriter.ReadTopics(ctx)
+	if err != nil {
+		return nil, err
+	}
+	tags, err := s.repo.ReadWriter.ReadTags(ctx)
+	if err != nil {
+		return nil, err
+	}
+	imp := &importer{
+		service: s,
+		topics:  make(map[string]string, len(topics.Topics)),
+		tags:    make(map[string]string, len(tags.Tags)),
+		result:  &pb.ImportNewsResult{},
+	}
+	for _, topic := range topics.Topics {
+		imp.topics[nameKey(topic.Title)] = topic.Id
+	}
+	for _, tag := range tags.Tags {
+		imp.tags[nameKey(tag.Tag)] = tag.Id
+	}
+	return imp, nil
+}
+
+func nameKey(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
+func (imp *importer) fail(index int64, err error) {
+	imp.result.Errors = append(imp.result.Errors, &pb.ImportNewsError{
+		Index:   index,
+		Message: err.Error(),
+	})
+}
+
+func (imp *importer) topicID(ctx context.Context, name string) (string, error) {
+	if id, ok := imp.topics[nameKey(name)]; ok {
+		return id, nil
+	}
+	topic := &pb.Topic{Id: uuid.NewV4().String(), Title: strings.TrimSpace(name)}
+	if !imp.dryRun {
+		newTopic, err := imp.repo.ReadWriter.WriteTopic(ctx, topic)
+		if err != nil {
+			return "", err
+		}
+		_ = imp.repo.CacheReadWriter.SetTopic(ctx, newTopic)
+	}
+	imp.topics[nameKey(name)] = topic.Id
+	return topic.Id, nil
+}
+
+func (imp *importer) tagID(ctx context.Context, name string) (string, error) {
+	if id, ok := imp.tags[nameKey(name)]; ok {
+		return id, nil
+	}
+	tag := &pb.Tag{Id: uuid.NewV4().String(), Tag: strings.TrimSpace(name)}
+	if !imp.dryRun {
+		newTag, err := imp.repo.ReadWriter.WriteTag(ctx, tag)
+		if err != nil {
+			return "", err
+		}
+		_ = imp.repo.CacheReadWriter.SetTag(ctx, newTag)
+	}
+	imp.tags[nameKey(name)] = tag.Id
+	return tag.Id, nil
+}
+
+// add validate the record and queue it for the next batch
+func (imp *importer) add(ctx context.Context, index int64, record *pb.ImportNewsRecord) error {
+	if strings.TrimSpace(record.Title) == "" {
+		return errors.New("title is required")
+	}
+	if strings.TrimSpace(record.Topic) == "" {
+		return errors.New("topic is required")
+	}
+	topicID, err := imp.topicID(ctx, record.Topic)
+	if err != nil {
+		return err
+	}
+	news := &pb.News{
+		Id:      uuid.NewV4().String(),
+		TopicId: topicID,
+		Title:   record.Title,
+		Content: record.Content,
+		Summary: record.Summary,
+		Status:  record.Status,
+	}
+	if err = validateSummary(news); err != nil {
+		return err
+	}
+	summarize(news)
+	seen := make(map[string]bool, len(record.Tags))
+	for _, name := range record.Tags {
+		if nameKey(name) == "" || seen[nameKey(name)] {
+			continue
+		}
+		seen[nameKey(name)] = true
+		tagID, err := imp.tagID(ctx, name)
+		if err != nil {
+			return err
+		}
+		news.NewsTagIds = append(news.NewsTagIds, tagID)
+	}
+	imp.batch = append(imp.batch, news)
+	imp.indexes = append(imp.indexes, index)
+	return nil
+}
+
+// flush write the queued newses in one transaction, when the transaction
+// fails every news is written on its own to find the failing records
+func (imp *importer) flush(ctx context.Context) error {
+	defer func() {
+		imp.batch = imp.batch[:0]
+		imp.indexes = imp.indexes[:0]
+	}()
+	if len(imp.batch) == 0 {
+		return nil
+	}
+	if imp.dryRun {
+		imp.result.Imported += int64(len(imp.batch))
+		return nil
+	}
+	err := imp.repo.ReadWriter.WriteNewsBatch(ctx, imp.batch)
+	if err == nil {
+		imp.result.Imported += int64(len(imp.batch))
+		return nil
+	}
+	// the records left stored would be reported as conflicts one by one, the
+	// import stops but the listings must show what earlier batches stored
+	if errors.Is(err, errs.ErrRollbackFailed) {
+		_ = imp.repo.CacheReadWriter.InvalidateNewses(ctx)
+		return err
+	}
+	for i, news := range imp.batch {
+		_, err := imp.repo.ReadWriter.WriteNews(ctx, news)
+		if err != nil {
+			imp.fail(imp.indexes[i], err)
+			continue
+		}
+		err = imp.repo.ReadWriter.WriteNewsTags(ctx, news.Id, news.NewsTagIds, true)
+		if err != nil {
+			_ = imp.repo.ReadWriter.RemoveNews(ctx, &pb.Select{Id: news.Id})
+			imp.fail(imp.indexes[i], err)
+			continue
+		}
+		imp.result.Imported++
+	}
+	return nil
+}
+
+func (s service) ImportNews(stream pb.BareksaNewsService_ImportNewsServer) error {
+	const funcName = `ImportNews`
+	ctx, span := s.tracer.StartSpan(stream.Context(), funcName)
+	defer span.End()
+
+	imp, err := s.newImporter(ctx)
+	if err != nil {
+		return err
+	}
+	for {
+		record, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		index := imp.result.Received
+		if index == 0 {
+			imp.dryRun = record.DryRun
+		}
+		imp.result.Received++
+		if err = imp.add(ctx, index, record); err != nil {
+			imp.fail(index, err)
+			continue
+		}
+		if len(imp.batch) >= constant.ImportBatchSize {
+			if err = imp.flush(ctx); err != nil {
+				return err
+			}
+		}
+	}
+	if err = imp.flush(ctx); err != nil {
+		return err
+	}
+
+	imp.result.DryRun = imp.dryRun
+	imp.result.Failed = int64(len(imp.result.Errors))
+	sort.Slice(imp.result.Errors, func(i, j int) bool {
+		return imp.result.Errors[i].Index < imp.result.Errors[j].Index
+	})
+	if !imp.dryRun && imp.result.Imported > 0 {
+		_ = s.repo.CacheReadWriter.InvalidateNewses(ctx)
+	}
+	return stream.SendAndClose(imp.result)
+}
+
+func (s service) ExportNews(filters *pb.Filters, stream pb.BareksaNewsService_ExportNewsServer) error {
+	const funcName = `ExportNews`
+	ctx, span := s.tracer.StartSpan(stream.Context(), funcName)
+	defer span.End()
+
+	// an explicit page is exported as it is listed
+	if filters.PageSize > 0 {
+		newses, err := s.readNewses(ctx, filters)
+		if err != nil {
+			return err
+		}
+		return send(stream, newses)
+	}
+	// every news is read in batches and sent before the next one is read, a
+	// news written meanwhile shifts the later batches and may be sent twice
+	page := model.Page{Limit: constant.ExportBatchSize, SummaryOnly: filters.SummaryOnly}
+	for {
+		newses, err := s.readPage(ctx, filters, page)
+		if err != nil {
+			return err
+		}
+		if err = send(stream, newses); err != nil {
+			return err
+		}
+		if len(newses.Newses) < constant.ExportBatchSize {
+			return nil
+		}
+		page.Offset += constant.ExportBatchSize
+	}
+}
+
+func send(stream pb.BareksaNewsService_ExportNewsServer, newses *pb.Newses) error {
+	for _, news := range newses.Newses {
+		if err := stream.Send(news); err != nil {
+			return err
+		}
+	}
+	return nil
+}
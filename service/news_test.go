@@ -0,0 +1,166 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/muhammadisa/bareksanews/constant"
+	"github.com/muhammadisa/bareksanews/gvars"
+	pb "github.com/muhammadisa/bareksanews/protoc/api/v1"
+	"github.com/muhammadisa/bareksanews/repository"
+	"github.com/muhammadisa/bareksanews/repository/cache"
+	"github.com/muhammadisa/bareksanews/repository/sql"
+	"github.com/muhammadisa/bareksanews/util/dbc"
+	uuid "github.com/satori/go.uuid"
+	"github.com/stretchr/testify/suite"
+	"go.opencensus.io/trace"
+	"golang.org/x/sync/singleflight"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// exportStream record the exported newses along with the reads done before
+// each of them was sent
+type exportStream struct {
+	grpc.ServerStream
+	readWriter *heldReadWriter
+	sent       []*pb.News
+	reads      []int
+}
+
+func (s *exportStream) Context() context.Context {
+	return context.Background()
+}
+
+func (s *exportStream) Send(news *pb.News) error {
+	s.readWriter.mu.Lock()
+	defer s.readWriter.mu.Unlock()
+	s.sent = append(s.sent, news)
+	s.reads = append(s.reads, s.readWriter.reads)
+	return nil
+}
+
+type newsTestSuite struct {
+	suite.Suite
+	service service
+}
+
+func TestNewsTestSuite(t *testing.T) {
+	suite.Run(t, new(newsTestSuite))
+}
+
+// newTestService a service over a fresh sqlite database and the memory cache
+func newTestService(t *testing.T) service {
+	gvars.Log = log.NewNopLogger()
+	readWriter, err := sql.NewSQLite(dbc.Config{Name: filepath.Join(t.TempDir(), "bareksa_news.db")}, trace.DefaultTracer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return service{
+		tracer: trace.DefaultTracer,
+		repo: repository.Repository{
+			ReadWriter:      readWriter,
+			CacheReadWriter: cache.NewMemoryCache(trace.DefaultTracer),
+		},
+		flight:    new(singleflight.Group),
+		refreshes: new(sync.WaitGroup),
+		timeout:   constant.CircuitBreakerTimeout * time.Second,
+	}
+}
+
+// writeNewses store count published newses of a new topic
+func writeNewses(ctx context.Context, s service, count int) (*pb.Topic, error) {
+	topic, err := s.repo.ReadWriter.WriteTopic(ctx, &pb.Topic{Id: uuid.NewV4().String(), Title: "health"})
+	if err != nil {
+		return nil, err
+	}
+	for i := 0; i < count; i++ {
+		_, err = s.repo.ReadWriter.WriteNews(ctx, &pb.News{
+			Id:      uuid.NewV4().String(),
+			TopicId: topic.Id,
+			Title:   fmt.Sprintf("news %d", i),
+			Content: "content",
+			Status:  1,
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return topic, nil
+}
+
+func (ts *newsTestSuite) SetupTest() {
+	ts.service = newTestService(ts.T())
+}
+
+func (ts *newsTestSuite) TearDownTest() {
+	ts.service.Wait()
+	ts.Require().NoError(ts.service.repo.Close())
+}
+
+func (ts *newsTestSuite) TestGetNewsesPages() {
+	ctx := context.Background()
+	defer ctx.Done()
+
+	_, err := writeNewses(ctx, ts.service, 25)
+	ts.Require().NoError(err)
+
+	// every news is listed without page_size
+	newses, err := ts.service.GetNewses(ctx, &pb.Filters{})
+	ts.Require().NoError(err)
+	ts.Assert().Len(newses.Newses, 25)
+
+	newses, err = ts.service.GetNewses(ctx, &pb.Filters{Page: 2, PageSize: 20})
+	ts.Require().NoError(err)
+	ts.Assert().Len(newses.Newses, 5)
+}
+
+func (ts *newsTestSuite) TestExportNewsInBatches() {
+	ctx := context.Background()
+	defer ctx.Done()
+
+	_, err := writeNewses(ctx, ts.service, constant.ExportBatchSize+5)
+	ts.Require().NoError(err)
+	readWriter := &heldReadWriter{ReadWrite: ts.service.repo.ReadWriter}
+	ts.service.repo.ReadWriter = readWriter
+
+	stream := &exportStream{readWriter: readWriter}
+	ts.Require().NoError(ts.service.ExportNews(&pb.Filters{}, stream))
+	ts.Require().Len(stream.sent, constant.ExportBatchSize+5)
+	// the first batch is sent before the second one is read
+	ts.Assert().Equal(1, stream.reads[constant.ExportBatchSize-1])
+	ts.Assert().Equal(2, stream.reads[constant.ExportBatchSize])
+	ts.Assert().Equal(2, readWriter.reads)
+
+	stream = &exportStream{readWriter: readWriter}
+	ts.Require().NoError(ts.service.ExportNews(&pb.Filters{Page: 2, PageSize: 10}, stream))
+	ts.Assert().Len(stream.sent, 10)
+}
+
+func (ts *newsTestSuite) TestSummaryTooLong() {
+	ctx := context.Background()
+	defer ctx.Done()
+
+	topic, err := writeNewses(ctx, ts.service, 0)
+	ts.Require().NoError(err)
+	news := &pb.News{
+		TopicId: topic.Id,
+		Title:   "news",
+		Content: "content",
+		Summary: strings.Repeat("é", constant.SummaryMaxLength+1),
+		Status:  1,
+	}
+	_, err = ts.service.AddNews(ctx, news)
+	ts.Assert().Equal(codes.InvalidArgument, status.Code(err))
+
+	news.Summary = strings.Repeat("é", constant.SummaryMaxLength)
+	_, err = ts.service.AddNews(ctx, news)
+	ts.Require().NoError(err)
+}
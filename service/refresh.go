@@ -0,0 +1,64 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/muhammadisa/bareksanews/constant"
+	"github.com/muhammadisa/bareksanews/util/dbc"
+	"golang.org/x/sync/singleflight"
+)
+
+// refresh reload the value of key once for every concurrent caller of this
+// replica, the redis lock of key does the same across replicas, a replica
+// losing the lock waits for the winner to cache the value found by cached
+//
+// load reads from the primary, a lagging replica would cache a value older
+// than the write of another replica for the whole ttl
+func (s service) refresh(
+	ctx context.Context,
+	key string,
+	load func(ctx context.Context) (interface{}, error),
+	cached func(ctx context.Context) (interface{}, bool),
+) <-chan singleflight.Result {
+	// the reload outlives the request starting it once its callers are
+	// served the stale value, only the trace is carried over
+	span := s.tracer.FromContext(ctx)
+	s.refreshes.Add(1)
+	result := make(chan singleflight.Result, 1)
+	shared := s.flight.DoChan(key, func() (interface{}, error) {
+		ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+		defer cancel()
+		ctx = dbc.WithPrimary(s.tracer.NewContext(ctx, span))
+
+		lockTTL := constant.CacheLockSeconds * time.Second
+		token, locked, err := s.repo.CacheReadWriter.Lock(ctx, key, lockTTL)
+		if err == nil && !locked {
+			for deadline := time.Now().Add(lockTTL); time.Now().Before(deadline); {
+				time.Sleep(constant.CacheLockPollMillis * time.Millisecond)
+				if value, ok := cached(ctx); ok {
+					return value, nil
+				}
+			}
+		}
+		if locked {
+			defer func() { _ = s.repo.CacheReadWriter.Unlock(ctx, key, token) }()
+		}
+		return load(ctx)
+	})
+	go func() {
+		defer s.refreshes.Done()
+		result <- <-shared
+	}()
+	return result
+}
+
+// await the result of refresh unless the request is cancelled first
+func await(ctx context.Context, result <-chan singleflight.Result) (interface{}, error) {
+	select {
+	case res := <-result:
+		return res.Val, res.Err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
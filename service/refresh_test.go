@@ -0,0 +1,220 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/muhammadisa/bareksanews/constant"
+	"github.com/muhammadisa/bareksanews/model"
+	pb "github.com/muhammadisa/bareksanews/protoc/api/v1"
+	"github.com/muhammadisa/bareksanews/repository/errs"
+	_interface "github.com/muhammadisa/bareksanews/repository/interface"
+	"github.com/muhammadisa/bareksanews/util/dbc"
+	"github.com/stretchr/testify/suite"
+	"golang.org/x/sync/singleflight"
+)
+
+// heldReadWriter count the listings read, record whether they were sent to
+// the primary and hold them while held is set
+type heldReadWriter struct {
+	_interface.ReadWrite
+
+	mu      sync.Mutex
+	reads   int
+	primary []bool
+	held    chan struct{}
+}
+
+func (rw *heldReadWriter) hold() {
+	rw.mu.Lock()
+	defer rw.mu.Unlock()
+	rw.held = make(chan struct{})
+}
+
+func (rw *heldReadWriter) release() {
+	rw.mu.Lock()
+	defer rw.mu.Unlock()
+	close(rw.held)
+	rw.held = nil
+}
+
+func (rw *heldReadWriter) ReadNewses(ctx context.Context, page model.Page) (*pb.Newses, error) {
+	rw.mu.Lock()
+	rw.reads++
+	rw.primary = append(rw.primary, dbc.UsePrimary(ctx))
+	held := rw.held
+	rw.mu.Unlock()
+	if held != nil {
+		<-held
+	}
+	return rw.ReadWrite.ReadNewses(ctx, page)
+}
+
+// expiringCache report a miss for every listing while expired is set, as
+// redis does once the ttl of the key ran out
+type expiringCache struct {
+	_interface.Cache
+
+	mu      sync.Mutex
+	expired bool
+}
+
+func (c *expiringCache) expire(expired bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.expired = expired
+}
+
+func (c *expiringCache) GetNewses(ctx context.Context, key string) (*pb.Newses, error) {
+	c.mu.Lock()
+	expired := c.expired
+	c.mu.Unlock()
+	if expired {
+		return nil, errs.ErrCacheMiss
+	}
+	return c.Cache.GetNewses(ctx, key)
+}
+
+type refreshTestSuite struct {
+	suite.Suite
+	service    service
+	readWriter *heldReadWriter
+	cache      *expiringCache
+}
+
+func TestRefreshTestSuite(t *testing.T) {
+	suite.Run(t, new(refreshTestSuite))
+}
+
+func (ts *refreshTestSuite) SetupTest() {
+	ts.service = newTestService(ts.T())
+	ts.readWriter = &heldReadWriter{ReadWrite: ts.service.repo.ReadWriter}
+	ts.cache = &expiringCache{Cache: ts.service.repo.CacheReadWriter}
+	ts.service.repo.ReadWriter = ts.readWriter
+	ts.service.repo.CacheReadWriter = ts.cache
+}
+
+func (ts *refreshTestSuite) TearDownTest() {
+	ts.service.Wait()
+	ts.Require().NoError(ts.service.repo.Close())
+}
+
+func (ts *refreshTestSuite) TestConcurrentCallersShareOneLoad() {
+	ctx := context.Background()
+	defer ctx.Done()
+
+	var loads int
+	release := make(chan struct{})
+	load := func(ctx context.Context) (interface{}, error) {
+		loads++
+		<-release
+		return "loaded", nil
+	}
+	cached := func(ctx context.Context) (interface{}, bool) {
+		return nil, false
+	}
+
+	results := make([]<-chan singleflight.Result, 5)
+	for i := range results {
+		results[i] = ts.service.refresh(ctx, "newses_key", load, cached)
+	}
+	close(release)
+	for _, result := range results {
+		value, err := await(ctx, result)
+		ts.Require().NoError(err)
+		ts.Assert().Equal("loaded", value)
+	}
+	ts.Assert().Equal(1, loads)
+}
+
+func (ts *refreshTestSuite) TestLockedKeyWaitsForTheHolder() {
+	ctx := context.Background()
+	defer ctx.Done()
+
+	// another replica is reloading the key
+	_, locked, err := ts.service.repo.CacheReadWriter.Lock(ctx, "newses_key", constant.CacheLockSeconds*time.Second)
+	ts.Require().NoError(err)
+	ts.Require().True(locked)
+
+	var loads, polls int
+	load := func(ctx context.Context) (interface{}, error) {
+		loads++
+		return "loaded", nil
+	}
+	cached := func(ctx context.Context) (interface{}, bool) {
+		polls++
+		ts.Assert().True(dbc.UsePrimary(ctx))
+		return "cached by the holder", polls == 2
+	}
+
+	value, err := await(ctx, ts.service.refresh(ctx, "newses_key", load, cached))
+	ts.Require().NoError(err)
+	ts.Assert().Equal("cached by the holder", value)
+	ts.Assert().Equal(0, loads)
+	ts.Assert().Equal(2, polls)
+}
+
+func (ts *refreshTestSuite) TestStaleListingServedAfterWrite() {
+	ctx := context.Background()
+	defer ctx.Done()
+
+	_, err := writeNewses(ctx, ts.service, 2)
+	ts.Require().NoError(err)
+	newses, err := ts.service.GetNewses(ctx, &pb.Filters{})
+	ts.Require().NoError(err)
+	ts.Require().Len(newses.Newses, 2)
+
+	// a write leaves the listing behind, its callers get the stale copy
+	// while the reload is held
+	_, err = writeNewses(ctx, ts.service, 1)
+	ts.Require().NoError(err)
+	ts.Require().NoError(ts.service.repo.CacheReadWriter.InvalidateNewses(ctx))
+	ts.readWriter.hold()
+	newses, err = ts.service.GetNewses(ctx, &pb.Filters{})
+	ts.Require().NoError(err)
+	ts.Assert().Len(newses.Newses, 2)
+
+	ts.readWriter.release()
+	ts.Assert().Eventually(func() bool {
+		newses, err := ts.service.GetNewses(ctx, &pb.Filters{})
+		return err == nil && len(newses.Newses) == 3
+	}, time.Second, 10*time.Millisecond)
+
+	ts.readWriter.mu.Lock()
+	defer ts.readWriter.mu.Unlock()
+	ts.Assert().Equal(2, ts.readWriter.reads)
+	ts.Assert().Equal([]bool{true, true}, ts.readWriter.primary)
+}
+
+func (ts *refreshTestSuite) TestExpiredListingIsNotServedStale() {
+	ctx := context.Background()
+	defer ctx.Done()
+
+	_, err := writeNewses(ctx, ts.service, 2)
+	ts.Require().NoError(err)
+	_, err = ts.service.GetNewses(ctx, &pb.Filters{})
+	ts.Require().NoError(err)
+
+	// the listing expired without a write, its callers wait for the reload
+	ts.cache.expire(true)
+	ts.readWriter.hold()
+	done := make(chan *pb.Newses)
+	go func() {
+		newses, _ := ts.service.GetNewses(ctx, &pb.Filters{})
+		done <- newses
+	}()
+	select {
+	case <-done:
+		ts.FailNow("an expired listing was served stale")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	_, err = writeNewses(ctx, ts.service, 1)
+	ts.Require().NoError(err)
+	ts.readWriter.release()
+	newses := <-done
+	ts.Require().NotNil(newses)
+	ts.Assert().Len(newses.Newses, 3)
+}
@@ -1,19 +1,39 @@
 package service
 
 import (
+	"sync"
+	"time"
+
 	_repointerface "github.com/muhammadisa/bareksanews/repository"
 	_interface "github.com/muhammadisa/bareksanews/service/interface"
 	"go.opencensus.io/trace"
+	"golang.org/x/sync/singleflight"
 )
 
 type service struct {
 	tracer trace.Tracer
 	repo   _repointerface.Repository
+	// flight collapse concurrent reloads of the same cache key
+	flight *singleflight.Group
+	// refreshes track the reloads outliving the requests that started them
+	refreshes *sync.WaitGroup
+	// timeout bounds a reload like the circuit breaker bounds the request
+	// it outlives
+	timeout time.Duration
 }
 
-func NewUsecases(repo _repointerface.Repository, tracer trace.Tracer) _interface.Service {
+func NewUsecases(repo _repointerface.Repository, tracer trace.Tracer, timeout time.Duration) _interface.Service {
 	return &service{
-		tracer: tracer,
-		repo:   repo,
+		tracer:    tracer,
+		repo:      repo,
+		flight:    new(singleflight.Group),
+		refreshes: new(sync.WaitGroup),
+		timeout:   timeout,
 	}
 }
+
+// Wait block until every reload started by refresh is done, the repository
+// must not be closed before
+func (s service) Wait() {
+	s.refreshes.Wait()
+}
@@ -2,7 +2,7 @@ package service
 
 import (
 	"context"
-	"fmt"
+	"github.com/muhammadisa/bareksanews/constant"
 	pb "github.com/muhammadisa/bareksanews/protoc/api/v1"
 	uuid "github.com/satori/go.uuid"
 	"google.golang.org/protobuf/types/known/emptypb"
@@ -26,11 +26,11 @@ func (s service) EditTag(ctx context.Context, tag *pb.Tag) (res *emptypb.Empty,
 	_, span := s.tracer.StartSpan(ctx, funcName)
 	defer span.End()
 
-	err = s.repo.CacheReadWriter.UnsetTag(ctx, tag.Id)
+	updatedTag, err := s.repo.ReadWriter.ModifyTag(ctx, tag)
 	if err != nil {
 		return nil, err
 	}
-	updatedTag, err := s.repo.ReadWriter.ModifyTag(ctx, tag)
+	err = s.repo.CacheReadWriter.UnsetTag(ctx, tag.Id)
 	if err != nil {
 		return nil, err
 	}
@@ -60,17 +60,25 @@ func (s service) GetTags(ctx context.Context, _ *emptypb.Empty) (res *pb.Tags, e
 
 	res, err = s.repo.CacheReadWriter.GetTags(ctx)
 	if err != nil {
-		return nil, err
+		// redis is unavailable, the database serves every read until it recovers
+		return s.repo.ReadWriter.ReadTags(ctx)
 	}
-	if len(res.Tags) == 0 {
-		fmt.Println("from database")
-		res, err = s.repo.ReadWriter.ReadTags(ctx)
+	if len(res.Tags) > 0 {
+		return res, nil
+	}
+	value, err := await(ctx, s.refresh(ctx, constant.Tags, func(ctx context.Context) (interface{}, error) {
+		res, err := s.repo.ReadWriter.ReadTags(ctx)
 		if err != nil {
 			return nil, err
 		}
 		_ = s.repo.CacheReadWriter.ReloadTags(ctx, res)
 		return res, nil
+	}, func(ctx context.Context) (interface{}, bool) {
+		res, err := s.repo.CacheReadWriter.GetTags(ctx)
+		return res, err == nil && len(res.Tags) > 0
+	}))
+	if err != nil {
+		return nil, err
 	}
-	fmt.Println("from cache")
-	return res, nil
+	return value.(*pb.Tags), nil
 }
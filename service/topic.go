@@ -2,7 +2,7 @@ package service
 
 import (
 	"context"
-	"fmt"
+	"github.com/muhammadisa/bareksanews/constant"
 
 	pb "github.com/muhammadisa/bareksanews/protoc/api/v1"
 	uuid "github.com/satori/go.uuid"
@@ -27,11 +27,11 @@ func (s service) EditTopic(ctx context.Context, topic *pb.Topic) (res *emptypb.E
 	_, span := s.tracer.StartSpan(ctx, funcName)
 	defer span.End()
 
-	err = s.repo.CacheReadWriter.UnsetTopic(ctx, topic.Id)
+	updatedTopic, err := s.repo.ReadWriter.ModifyTopic(ctx, topic)
 	if err != nil {
 		return nil, err
 	}
-	updatedTopic, err := s.repo.ReadWriter.ModifyTopic(ctx, topic)
+	err = s.repo.CacheReadWriter.UnsetTopic(ctx, topic.Id)
 	if err != nil {
 		return nil, err
 	}
@@ -61,17 +61,25 @@ func (s service) GetTopics(ctx context.Context, _ *emptypb.Empty) (res *pb.Topic
 
 	res, err = s.repo.CacheReadWriter.GetTopics(ctx)
 	if err != nil {
-		return nil, nil
+		// redis is unavailable, the database serves every read until it recovers
+		return s.repo.ReadWriter.ReadTopics(ctx)
 	}
-	if len(res.Topics) == 0 {
-		fmt.Println("from database")
-		res, err = s.repo.ReadWriter.ReadTopics(ctx)
+	if len(res.Topics) > 0 {
+		return res, nil
+	}
+	value, err := await(ctx, s.refresh(ctx, constant.Topics, func(ctx context.Context) (interface{}, error) {
+		res, err := s.repo.ReadWriter.ReadTopics(ctx)
 		if err != nil {
 			return nil, err
 		}
 		_ = s.repo.CacheReadWriter.ReloadTopics(ctx, res)
 		return res, nil
+	}, func(ctx context.Context) (interface{}, bool) {
+		res, err := s.repo.CacheReadWriter.GetTopics(ctx)
+		return res, err == nil && len(res.Topics) > 0
+	}))
+	if err != nil {
+		return nil, err
 	}
-	fmt.Println("from cache")
-	return res, nil
+	return value.(*pb.Topics), nil
 }
@@ -0,0 +1,97 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/muhammadisa/bareksanews/constant"
+	pb "github.com/muhammadisa/bareksanews/protoc/api/v1"
+	"github.com/muhammadisa/bareksanews/util/dbc"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// warmFilters the newses listings read the most, the first page of newses,
+// the first page of summaries and the first page of summaries of every topic
+func warmFilters(topics *pb.Topics) []*pb.Filters {
+	filters := []*pb.Filters{
+		{PageSize: constant.WarmUpPageSize},
+		{SummaryOnly: true, PageSize: constant.WarmUpPageSize},
+	}
+	for _, topic := range topics.Topics {
+		filters = append(filters, &pb.Filters{TopicId: topic.Id, SummaryOnly: true, PageSize: constant.WarmUpPageSize})
+	}
+	return filters
+}
+
+// RebuildCache flush the cache namespace and load it again from the
+// database, for a cache drifting from the database
+func (s service) RebuildCache(ctx context.Context, _ *emptypb.Empty) (res *pb.RebuildCacheResult, err error) {
+	const funcName = `RebuildCache`
+	_, span := s.tracer.StartSpan(ctx, funcName)
+	defer span.End()
+
+	start := time.Now()
+	if _, err = s.repo.CacheReadWriter.FlushKeys(ctx); err != nil {
+		return nil, err
+	}
+	res, err = s.WarmUp(ctx)
+	if err != nil {
+		return nil, err
+	}
+	res.Duration = durationpb.New(time.Since(start))
+	return res, nil
+}
+
+// WarmUp preload tags, topics and the newses listings of warmFilters so the
+// first readers are not served by the database, everything cached is read
+// from the primary
+func (s service) WarmUp(ctx context.Context) (res *pb.RebuildCacheResult, err error) {
+	const funcName = `WarmUp`
+	_, span := s.tracer.StartSpan(ctx, funcName)
+	defer span.End()
+
+	ctx = dbc.WithPrimary(ctx)
+
+	start := time.Now()
+	res = new(pb.RebuildCacheResult)
+
+	tags, err := s.repo.ReadWriter.ReadTags(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(tags.Tags) > 0 {
+		if err = s.repo.CacheReadWriter.ReloadTags(ctx, tags); err != nil {
+			return nil, err
+		}
+	}
+	res.Tags = int64(len(tags.Tags))
+
+	topics, err := s.repo.ReadWriter.ReadTopics(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(topics.Topics) > 0 {
+		if err = s.repo.CacheReadWriter.ReloadTopics(ctx, topics); err != nil {
+			return nil, err
+		}
+	}
+	res.Topics = int64(len(topics.Topics))
+
+	for _, filters := range warmFilters(topics) {
+		filter := s.filterRedisKeyGenerator(ctx, filters)
+		key, err := s.repo.CacheReadWriter.NewsesKey(ctx, filter)
+		if err != nil {
+			return nil, err
+		}
+		newses, err := s.reloadNewses(ctx, filters, filter, key)
+		if err != nil {
+			return nil, err
+		}
+		res.Pages++
+		res.Newses += int64(len(newses.Newses))
+	}
+
+	res.Duration = durationpb.New(time.Since(start))
+	return res, nil
+}
@@ -0,0 +1,206 @@
+package transport
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/utilities"
+	pb "github.com/muhammadisa/bareksanews/protoc/api/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// ContentTypeNDJSON newline delimited json, one message per line
+	ContentTypeNDJSON = `application/x-ndjson`
+
+	// maxNDJSONLine longest accepted line of an imported NDJSON body
+	maxNDJSONLine = 16 << 20
+)
+
+// RegisterNDJSONHandlers expose ImportNews and ExportNews over HTTP, the in
+// process gateway can not serve streaming rpcs so the messages are
+// exchanged as NDJSON instead
+func RegisterNDJSONHandlers(mux *runtime.ServeMux, server pb.BareksaNewsServiceServer) error {
+	err := mux.HandlePath(http.MethodPost, "/v1/news/import", importNewsHandler(mux, server))
+	if err != nil {
+		return err
+	}
+	return mux.HandlePath(http.MethodGet, "/v1/newses/export", exportNewsHandler(mux, server))
+}
+
+func importNewsHandler(mux *runtime.ServeMux, server pb.BareksaNewsServiceServer) runtime.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request, _ map[string]string) {
+		inbound, outbound := runtime.MarshalerForRequest(mux, req)
+		ctx, err := runtime.AnnotateIncomingContext(req.Context(), mux, req, "/api.v1.BareksaNewsService/ImportNews")
+		if err != nil {
+			runtime.HTTPError(ctx, mux, outbound, w, req, err)
+			return
+		}
+		dryRun, _ := strconv.ParseBool(req.URL.Query().Get("dry_run"))
+		stream := newNDJSONImportStream(ctx, req.Body, inbound, dryRun)
+		if err = server.ImportNews(stream); err != nil {
+			runtime.HTTPError(ctx, mux, outbound, w, req, err)
+			return
+		}
+		ctx = runtime.NewServerMetadataContext(ctx, runtime.ServerMetadata{})
+		runtime.ForwardResponseMessage(ctx, mux, outbound, w, req, stream.result)
+	}
+}
+
+func exportNewsHandler(mux *runtime.ServeMux, server pb.BareksaNewsServiceServer) runtime.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request, _ map[string]string) {
+		_, outbound := runtime.MarshalerForRequest(mux, req)
+		ctx, err := runtime.AnnotateIncomingContext(req.Context(), mux, req, "/api.v1.BareksaNewsService/ExportNews")
+		if err != nil {
+			runtime.HTTPError(ctx, mux, outbound, w, req, err)
+			return
+		}
+		var filters pb.Filters
+		err = runtime.PopulateQueryParameters(&filters, req.URL.Query(), utilities.NewDoubleArray(nil))
+		if err != nil {
+			runtime.HTTPError(ctx, mux, outbound, w, req, status.Error(codes.InvalidArgument, err.Error()))
+			return
+		}
+		stream := &ndjsonExportStream{httpServerStream: httpServerStream{ctx: ctx}, w: w, marshaler: outbound}
+		err = server.ExportNews(&filters, stream)
+		if err == nil {
+			return
+		}
+		if !stream.started {
+			runtime.HTTPError(ctx, mux, outbound, w, req, err)
+			return
+		}
+		// the status is already sent, the failure is reported as the last line
+		_ = stream.writeLine(status.Convert(err).Proto())
+	}
+}
+
+// httpServerStream the grpc.ServerStream part of the NDJSON streams, headers
+// and trailers have no meaning over plain HTTP
+type httpServerStream struct {
+	ctx context.Context
+}
+
+func (s httpServerStream) SetHeader(metadata.MD) error  { return nil }
+func (s httpServerStream) SendHeader(metadata.MD) error { return nil }
+func (s httpServerStream) SetTrailer(metadata.MD)       {}
+func (s httpServerStream) Context() context.Context     { return s.ctx }
+
+func (s httpServerStream) SendMsg(interface{}) error {
+	return status.Error(codes.Unimplemented, "raw messages are not supported over NDJSON")
+}
+
+func (s httpServerStream) RecvMsg(interface{}) error {
+	return status.Error(codes.Unimplemented, "raw messages are not supported over NDJSON")
+}
+
+// ndjsonImportStream read one ImportNewsRecord per line, lines that are not
+// a valid record are reported in the result along the rejected records
+type ndjsonImportStream struct {
+	httpServerStream
+	scanner   *bufio.Scanner
+	marshaler runtime.Marshaler
+	dryRun    bool
+
+	line    int64
+	lines   []int64
+	invalid []*pb.ImportNewsError
+	result  *pb.ImportNewsResult
+}
+
+func newNDJSONImportStream(ctx context.Context, body io.Reader, marshaler runtime.Marshaler, dryRun bool) *ndjsonImportStream {
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 0, bufio.MaxScanTokenSize), maxNDJSONLine)
+	return &ndjsonImportStream{
+		httpServerStream: httpServerStream{ctx: ctx},
+		scanner:          scanner,
+		marshaler:        marshaler,
+		dryRun:           dryRun,
+	}
+}
+
+func (s *ndjsonImportStream) Recv() (*pb.ImportNewsRecord, error) {
+	for s.scanner.Scan() {
+		raw := bytes.TrimSpace(s.scanner.Bytes())
+		if len(raw) == 0 {
+			continue
+		}
+		line := s.line
+		s.line++
+
+		var record pb.ImportNewsRecord
+		if err := s.marshaler.Unmarshal(raw, &record); err != nil {
+			s.invalid = append(s.invalid, &pb.ImportNewsError{Index: line, Message: err.Error()})
+			continue
+		}
+		if len(s.lines) == 0 {
+			record.DryRun = record.DryRun || s.dryRun
+		}
+		s.lines = append(s.lines, line)
+		return &record, nil
+	}
+	if err := s.scanner.Err(); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	return nil, io.EOF
+}
+
+// SendAndClose keep the result with record indexes translated back into
+// line indexes of the body
+func (s *ndjsonImportStream) SendAndClose(result *pb.ImportNewsResult) error {
+	for _, recordErr := range result.Errors {
+		if recordErr.Index < int64(len(s.lines)) {
+			recordErr.Index = s.lines[recordErr.Index]
+		}
+	}
+	result.Errors = append(result.Errors, s.invalid...)
+	sort.Slice(result.Errors, func(i, j int) bool {
+		return result.Errors[i].Index < result.Errors[j].Index
+	})
+	result.Received += int64(len(s.invalid))
+	result.Failed += int64(len(s.invalid))
+	if len(s.lines) == 0 {
+		result.DryRun = s.dryRun
+	}
+	s.result = result
+	return nil
+}
+
+// ndjsonExportStream write every sent news as one line of the response
+type ndjsonExportStream struct {
+	httpServerStream
+	w         http.ResponseWriter
+	marshaler runtime.Marshaler
+	started   bool
+}
+
+func (s *ndjsonExportStream) Send(news *pb.News) error {
+	return s.writeLine(news)
+}
+
+func (s *ndjsonExportStream) writeLine(msg interface{}) error {
+	if !s.started {
+		s.w.Header().Set("Content-Type", ContentTypeNDJSON)
+		s.w.WriteHeader(http.StatusOK)
+		s.started = true
+	}
+	buf, err := s.marshaler.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	if _, err = s.w.Write(append(buf, '\n')); err != nil {
+		return err
+	}
+	if flusher, ok := s.w.(http.Flusher); ok {
+		flusher.Flush()
+	}
+	return nil
+}
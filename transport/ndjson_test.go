@@ -0,0 +1,137 @@
+package transport
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	pb "github.com/muhammadisa/bareksanews/protoc/api/v1"
+	"github.com/stretchr/testify/suite"
+)
+
+// streamServer accept records with a title and export the newses matching
+// the filters topic
+type streamServer struct {
+	pb.UnimplementedBareksaNewsServiceServer
+	newses []*pb.News
+}
+
+func (s *streamServer) ImportNews(stream pb.BareksaNewsService_ImportNewsServer) error {
+	result := &pb.ImportNewsResult{}
+	for {
+		record, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if result.Received == 0 {
+			result.DryRun = record.DryRun
+		}
+		if record.Title == "" {
+			result.Errors = append(result.Errors, &pb.ImportNewsError{Index: result.Received, Message: "title is required"})
+		} else {
+			result.Imported++
+		}
+		result.Received++
+	}
+	result.Failed = int64(len(result.Errors))
+	return stream.SendAndClose(result)
+}
+
+func (s *streamServer) ExportNews(filters *pb.Filters, stream pb.BareksaNewsService_ExportNewsServer) error {
+	for _, news := range s.newses {
+		if filters.TopicId != "" && news.TopicId != filters.TopicId {
+			continue
+		}
+		if err := stream.Send(news); err != nil {
+			return err
+		}
+	}
+	return errors.New("export interrupted")
+}
+
+type ndjsonTestSuite struct {
+	suite.Suite
+	server *httptest.Server
+}
+
+func TestNDJSONTestSuite(t *testing.T) {
+	suite.Run(t, new(ndjsonTestSuite))
+}
+
+func (ts *ndjsonTestSuite) SetupTest() {
+	mux := runtime.NewServeMux()
+	err := RegisterNDJSONHandlers(mux, &streamServer{newses: []*pb.News{
+		{Id: "1", TopicId: "health", Title: "news 1"},
+		{Id: "2", TopicId: "sport", Title: "news 2"},
+		{Id: "3", TopicId: "health", Title: "news 3"},
+	}})
+	ts.Require().NoError(err)
+	ts.server = httptest.NewServer(mux)
+}
+
+func (ts *ndjsonTestSuite) TearDownTest() {
+	ts.server.Close()
+}
+
+func (ts *ndjsonTestSuite) TestImportNews() {
+	body := strings.Join([]string{
+		`{"title": "news 1", "topic": "health", "tags": ["game"]}`,
+		``,
+		`{"title": "", "topic": "health"}`,
+		`not a json record`,
+		`{"title": "news 4", "topic": "sport"}`,
+	}, "\n")
+	res, err := http.Post(ts.server.URL+"/v1/news/import?dry_run=true", ContentTypeNDJSON, strings.NewReader(body))
+	ts.Require().NoError(err)
+	defer res.Body.Close()
+	ts.Require().Equal(http.StatusOK, res.StatusCode)
+
+	var result struct {
+		Received string `json:"received"`
+		Imported string `json:"imported"`
+		Failed   string `json:"failed"`
+		DryRun   bool   `json:"dryRun"`
+		Errors   []struct {
+			Index   string `json:"index"`
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	ts.Require().NoError(json.NewDecoder(res.Body).Decode(&result))
+	ts.Assert().Equal("4", result.Received)
+	ts.Assert().Equal("2", result.Imported)
+	ts.Assert().Equal("2", result.Failed)
+	ts.Assert().True(result.DryRun)
+	ts.Require().Len(result.Errors, 2)
+	ts.Assert().Equal("1", result.Errors[0].Index)
+	ts.Assert().Equal("title is required", result.Errors[0].Message)
+	ts.Assert().Equal("2", result.Errors[1].Index)
+}
+
+func (ts *ndjsonTestSuite) TestExportNews() {
+	res, err := http.Get(ts.server.URL + "/v1/newses/export?topic_id=health")
+	ts.Require().NoError(err)
+	defer res.Body.Close()
+	ts.Require().Equal(http.StatusOK, res.StatusCode)
+	ts.Assert().Equal(ContentTypeNDJSON, res.Header.Get("Content-Type"))
+
+	var lines []map[string]interface{}
+	scanner := bufio.NewScanner(res.Body)
+	for scanner.Scan() {
+		var line map[string]interface{}
+		ts.Require().NoError(json.Unmarshal(scanner.Bytes(), &line))
+		lines = append(lines, line)
+	}
+	ts.Require().Len(lines, 3)
+	ts.Assert().Equal("1", lines[0]["id"])
+	ts.Assert().Equal("3", lines[1]["id"])
+	ts.Assert().Equal("export interrupted", lines[2]["message"])
+}
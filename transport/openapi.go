@@ -0,0 +1,54 @@
+package transport
+
+import (
+	"embed"
+	"net/http"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	pb "github.com/muhammadisa/bareksanews/protoc/api/v1"
+)
+
+// swaggerUI page rendering /openapi.json with the swagger ui assets
+// vendored from swagger-ui-dist, the docs work without internet access
+//
+//go:embed swagger/index.html
+var swaggerUI []byte
+
+// swaggerAssets the vendored swagger-ui-dist files served under /docs/
+//
+//go:embed swagger/swagger-ui.css swagger/swagger-ui-bundle.js
+var swaggerAssets embed.FS
+
+// RegisterOpenAPIHandlers serve the OpenAPI document of the gateway at
+// /openapi.json and a swagger ui browsing it at /docs
+func RegisterOpenAPIHandlers(mux *runtime.ServeMux) error {
+	err := mux.HandlePath(http.MethodGet, "/openapi.json", serve("application/json", pb.OpenAPI))
+	if err != nil {
+		return err
+	}
+	err = mux.HandlePath(http.MethodGet, "/docs", serve("text/html; charset=utf-8", swaggerUI))
+	if err != nil {
+		return err
+	}
+	assets := map[string]string{
+		"swagger-ui.css":       "text/css; charset=utf-8",
+		"swagger-ui-bundle.js": "application/javascript; charset=utf-8",
+	}
+	for name, contentType := range assets {
+		content, err := swaggerAssets.ReadFile("swagger/" + name)
+		if err != nil {
+			return err
+		}
+		if err = mux.HandlePath(http.MethodGet, "/docs/"+name, serve(contentType, content)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func serve(contentType string, content []byte) runtime.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request, _ map[string]string) {
+		w.Header().Set("Content-Type", contentType)
+		_, _ = w.Write(content)
+	}
+}
@@ -7,6 +7,7 @@ import (
 	grpctransport "github.com/go-kit/kit/transport/grpc"
 	ep "github.com/muhammadisa/bareksanews/endpoint"
 	pb "github.com/muhammadisa/bareksanews/protoc/api/v1"
+	"github.com/muhammadisa/bareksanews/util/hdr"
 	"google.golang.org/protobuf/types/known/emptypb"
 )
 
@@ -25,8 +26,40 @@ type grpcTagServer struct {
 	editNews   grpctransport.Handler
 	deleteNews grpctransport.Handler
 	getNewses  grpctransport.Handler
+	importNews grpctransport.Handler
+	exportNews grpctransport.Handler
+
+	listCacheKeys grpctransport.Handler
+	flushCache    grpctransport.Handler
+	rebuildCache  grpctransport.Handler
+}
+
+func (g grpcTagServer) ListCacheKeys(ctx context.Context, req *pb.CacheKeysRequest) (*pb.CacheKeys, error) {
+	_, res, err := g.listCacheKeys.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*pb.CacheKeys), nil
+}
+
+func (g grpcTagServer) FlushCache(ctx context.Context, req *emptypb.Empty) (*pb.FlushCacheResult, error) {
+	_, res, err := g.flushCache.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*pb.FlushCacheResult), nil
+}
+
+func (g grpcTagServer) RebuildCache(ctx context.Context, req *emptypb.Empty) (*pb.RebuildCacheResult, error) {
+	_, res, err := g.rebuildCache.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*pb.RebuildCacheResult), nil
 }
 
+// ..
+
 func (g grpcTagServer) AddNews(ctx context.Context, req *pb.News) (*emptypb.Empty, error) {
 	_, res, err := g.addNews.ServeGRPC(ctx, req)
 	if err != nil {
@@ -59,6 +92,16 @@ func (g grpcTagServer) GetNewses(ctx context.Context, req *pb.Filters) (*pb.News
 	return res.(*pb.Newses), nil
 }
 
+func (g grpcTagServer) ImportNews(stream pb.BareksaNewsService_ImportNewsServer) error {
+	_, _, err := g.importNews.ServeGRPC(stream.Context(), stream)
+	return err
+}
+
+func (g grpcTagServer) ExportNews(req *pb.Filters, stream pb.BareksaNewsService_ExportNewsServer) error {
+	_, _, err := g.exportNews.ServeGRPC(stream.Context(), ep.ExportNewsRequest{Filters: req, Stream: stream})
+	return err
+}
+
 // ..
 
 func (g grpcTagServer) AddTopic(ctx context.Context, req *pb.Topic) (*emptypb.Empty, error) {
@@ -140,7 +183,7 @@ func NewBareksaNewsServer(endpoints ep.BareksaNewsEndpoint) pb.BareksaNewsServic
 		),
 		editTag: grpctransport.NewServer(
 			endpoints.EditTagEndpoint,
-			decodeRequest,
+			decodeVersionedRequest,
 			encodeResponse,
 			options...,
 		),
@@ -165,7 +208,7 @@ func NewBareksaNewsServer(endpoints ep.BareksaNewsEndpoint) pb.BareksaNewsServic
 		),
 		editTopic: grpctransport.NewServer(
 			endpoints.EditTopicEndpoint,
-			decodeRequest,
+			decodeVersionedRequest,
 			encodeResponse,
 			options...,
 		),
@@ -190,7 +233,7 @@ func NewBareksaNewsServer(endpoints ep.BareksaNewsEndpoint) pb.BareksaNewsServic
 		),
 		editNews: grpctransport.NewServer(
 			endpoints.EditNewsEndpoint,
-			decodeRequest,
+			decodeVersionedRequest,
 			encodeResponse,
 			options...,
 		),
@@ -206,6 +249,37 @@ func NewBareksaNewsServer(endpoints ep.BareksaNewsEndpoint) pb.BareksaNewsServic
 			encodeResponse,
 			options...,
 		),
+		importNews: grpctransport.NewServer(
+			endpoints.ImportNewsEndpoint,
+			decodeRequest,
+			encodeResponse,
+			options...,
+		),
+		exportNews: grpctransport.NewServer(
+			endpoints.ExportNewsEndpoint,
+			decodeRequest,
+			encodeResponse,
+			options...,
+		),
+		//..
+		listCacheKeys: grpctransport.NewServer(
+			endpoints.ListCacheKeysEndpoint,
+			decodeRequest,
+			encodeResponse,
+			options...,
+		),
+		flushCache: grpctransport.NewServer(
+			endpoints.FlushCacheEndpoint,
+			decodeRequest,
+			encodeResponse,
+			options...,
+		),
+		rebuildCache: grpctransport.NewServer(
+			endpoints.RebuildCacheEndpoint,
+			decodeRequest,
+			encodeResponse,
+			options...,
+		),
 	}
 }
 
@@ -213,6 +287,24 @@ func decodeRequest(_ context.Context, request interface{}) (interface{}, error)
 	return request, nil
 }
 
+// decodeVersionedRequest apply the version sent through If-Match metadata
+// to the edited entity, it takes precedence over the version in the body
+func decodeVersionedRequest(ctx context.Context, request interface{}) (interface{}, error) {
+	version, err := hdr.IfMatchVersion(ctx)
+	if err != nil || version == 0 {
+		return request, err
+	}
+	switch req := request.(type) {
+	case *pb.Tag:
+		req.Version = version
+	case *pb.Topic:
+		req.Version = version
+	case *pb.News:
+		req.Version = version
+	}
+	return request, nil
+}
+
 func encodeResponse(_ context.Context, response interface{}) (interface{}, error) {
 	return response, nil
 }
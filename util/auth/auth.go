@@ -0,0 +1,287 @@
+package auth
+
+import (
+	"context"
+	"crypto/rsa"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/muhammadisa/bareksanews/constant"
+	"github.com/muhammadisa/bareksanews/util/hdr"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// ranks order the roles, a role is granted every rpc of the lower ones
+var ranks = map[string]int{
+	constant.RolePublic: 0,
+	constant.RoleReader: 1,
+	constant.RoleEditor: 2,
+	constant.RoleAdmin:  3,
+}
+
+// DefaultPermissions the lowest role allowed to call every rpc, the rpcs
+// left out need constant.RoleAdmin
+var DefaultPermissions = map[string]string{
+	"GetTags":   constant.RolePublic,
+	"GetTopics": constant.RolePublic,
+	"GetNewses": constant.RolePublic,
+
+	"ExportNews": constant.RoleReader,
+
+	"AddTag":     constant.RoleEditor,
+	"EditTag":    constant.RoleEditor,
+	"AddTopic":   constant.RoleEditor,
+	"EditTopic":  constant.RoleEditor,
+	"AddNews":    constant.RoleEditor,
+	"EditNews":   constant.RoleEditor,
+	"DeleteNews": constant.RoleEditor,
+	"ImportNews": constant.RoleEditor,
+
+	"DeleteTag":     constant.RoleAdmin,
+	"DeleteTopic":   constant.RoleAdmin,
+	"ListCacheKeys": constant.RoleAdmin,
+	"FlushCache":    constant.RoleAdmin,
+	"RebuildCache":  constant.RoleAdmin,
+}
+
+// Config how the bearer tokens are verified, one of HMACSecret and
+// JWKSFile is required unless Disabled is set
+type Config struct {
+	// Disabled leave every rpc open to anyone, for local runs only
+	Disabled bool `yaml:"disabled"`
+	// HMACSecret verify HS256 tokens
+	HMACSecret string `yaml:"hmac_secret" secret:"true"`
+	// JWKSFile json web key set of the RSA public keys verifying RS256
+	// tokens, the key is selected by the kid of the token
+	JWKSFile string `yaml:"jwks_file"`
+	// Issuer and Audience the tokens must carry, unchecked while empty
+	Issuer   string `yaml:"issuer"`
+	Audience string `yaml:"audience"`
+	// RolesClaim claim holding the role or the list of roles of the caller
+	RolesClaim string `yaml:"roles_claim"`
+	// Permissions the lowest role allowed to call an rpc, overriding
+	// DefaultPermissions
+	Permissions map[string]string `yaml:"permissions"`
+}
+
+// Enabled report whether the tokens are verified
+func (c Config) Enabled() bool {
+	return !c.Disabled
+}
+
+// hasKeys report whether any token could be verified
+func (c Config) hasKeys() bool {
+	return c.HMACSecret != "" || c.JWKSFile != ""
+}
+
+// Validate report a configuration NewAuthorizer refuses without reading
+// the key set, rpcs lists the names permissions may be given to
+func (c Config) Validate(rpcs []string) error {
+	known := make(map[string]bool, len(rpcs))
+	for _, rpc := range rpcs {
+		known[rpc] = true
+	}
+	for rpc, role := range c.Permissions {
+		if !known[rpc] {
+			return fmt.Errorf("permissions: unknown rpc %q", rpc)
+		}
+		if _, ok := ranks[role]; !ok {
+			return fmt.Errorf("permissions: unknown role %q of %s", role, rpc)
+		}
+	}
+	if c.Enabled() && !c.hasKeys() {
+		return errors.New("hmac_secret or jwks_file is required, set disabled to leave every rpc open")
+	}
+	if c.Enabled() && c.RolesClaim == "" {
+		return errors.New("roles_claim is required")
+	}
+	return nil
+}
+
+// Claims the caller a verified token identifies
+type Claims struct {
+	Subject string
+	// Role the highest known role of the token
+	Role string
+}
+
+type claimsKey struct{}
+
+// NewContext attach claims to ctx
+func NewContext(ctx context.Context, claims Claims) context.Context {
+	return context.WithValue(ctx, claimsKey{}, claims)
+}
+
+// FromContext the claims of the caller, ok is false for anonymous calls
+func FromContext(ctx context.Context) (claims Claims, ok bool) {
+	claims, ok = ctx.Value(claimsKey{}).(Claims)
+	return claims, ok
+}
+
+// Authorizer verify the bearer token of every call and check the role it
+// carries against the permission of the rpc
+type Authorizer struct {
+	config      Config
+	permissions map[string]string
+	rsaKeys     map[string]*rsa.PublicKey
+	parser      *jwt.Parser
+}
+
+// NewAuthorizer read the key set of config, a config without keys is
+// refused unless it is disabled, the Authorizer of a disabled config lets
+// every call through
+func NewAuthorizer(config Config) (*Authorizer, error) {
+	if config.Enabled() && !config.hasKeys() {
+		return nil, errors.New("auth has no hmac_secret nor jwks_file and is not disabled")
+	}
+	a := &Authorizer{config: config, permissions: make(map[string]string)}
+	for rpc, role := range DefaultPermissions {
+		a.permissions[rpc] = role
+	}
+	for rpc, role := range config.Permissions {
+		a.permissions[rpc] = role
+	}
+
+	var methods []string
+	if config.HMACSecret != "" {
+		methods = append(methods, jwt.SigningMethodHS256.Alg())
+	}
+	if config.JWKSFile != "" {
+		keys, err := readJWKS(config.JWKSFile)
+		if err != nil {
+			return nil, err
+		}
+		a.rsaKeys = keys
+		methods = append(methods, jwt.SigningMethodRS256.Alg())
+	}
+	a.parser = &jwt.Parser{ValidMethods: methods}
+	return a, nil
+}
+
+// Enabled report whether the calls are checked at all
+func (a *Authorizer) Enabled() bool {
+	return a.config.Enabled()
+}
+
+// Authorize check the caller of rpc, the returned context carries the
+// claims of its token
+//
+// a missing token is only accepted by constant.RolePublic rpcs, an invalid
+// one is always rejected with codes.Unauthenticated, a role below the
+// permission of rpc is rejected with codes.PermissionDenied
+func (a *Authorizer) Authorize(ctx context.Context, rpc string) (context.Context, error) {
+	if !a.Enabled() {
+		return ctx, nil
+	}
+
+	claims, ok := FromContext(ctx)
+	if !ok {
+		token, err := bearer(ctx)
+		if err != nil {
+			return ctx, err
+		}
+		if token != "" {
+			claims, err = a.verify(token)
+			if err != nil {
+				return ctx, err
+			}
+			ctx, ok = NewContext(ctx, claims), true
+		}
+	}
+
+	required, listed := a.permissions[rpc]
+	if !listed {
+		required = constant.RoleAdmin
+	}
+	if required == constant.RolePublic {
+		return ctx, nil
+	}
+	if !ok {
+		return ctx, status.Errorf(codes.Unauthenticated, "%s needs a bearer token", rpc)
+	}
+	if ranks[claims.Role] < ranks[required] {
+		return ctx, status.Errorf(codes.PermissionDenied, "%s needs the %s role", rpc, required)
+	}
+	return ctx, nil
+}
+
+// bearer the token of the authorization metadata, empty when absent
+func bearer(ctx context.Context) (string, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	values := md.Get(hdr.Authorization)
+	if len(values) == 0 {
+		return "", nil
+	}
+	scheme, token := values[0], ""
+	if space := strings.IndexByte(scheme, ' '); space >= 0 {
+		scheme, token = scheme[:space], strings.TrimSpace(scheme[space+1:])
+	}
+	if !strings.EqualFold(scheme, "bearer") || token == "" {
+		return "", status.Error(codes.Unauthenticated, "authorization is not a bearer token")
+	}
+	return token, nil
+}
+
+// verify the signature and the registered claims of token, a token
+// without exp is refused since it could never be revoked
+func (a *Authorizer) verify(token string) (Claims, error) {
+	mapClaims := jwt.MapClaims{}
+	_, err := a.parser.ParseWithClaims(token, mapClaims, a.key)
+	if err != nil {
+		return Claims{}, status.Errorf(codes.Unauthenticated, "invalid token: %v", err)
+	}
+	if _, ok := mapClaims["exp"]; !ok {
+		return Claims{}, status.Error(codes.Unauthenticated, "invalid token: exp is required")
+	}
+	if a.config.Issuer != "" && !mapClaims.VerifyIssuer(a.config.Issuer, true) {
+		return Claims{}, status.Error(codes.Unauthenticated, "invalid token: unexpected issuer")
+	}
+	if a.config.Audience != "" && !mapClaims.VerifyAudience(a.config.Audience, true) {
+		return Claims{}, status.Error(codes.Unauthenticated, "invalid token: unexpected audience")
+	}
+
+	claims := Claims{Role: constant.RolePublic}
+	claims.Subject, _ = mapClaims["sub"].(string)
+	var roles []string
+	switch value := mapClaims[a.config.RolesClaim].(type) {
+	case string:
+		roles = strings.Fields(value)
+	case []interface{}:
+		for _, role := range value {
+			if role, ok := role.(string); ok {
+				roles = append(roles, role)
+			}
+		}
+	}
+	for _, role := range roles {
+		if rank, ok := ranks[role]; ok && rank > ranks[claims.Role] {
+			claims.Role = role
+		}
+	}
+	return claims, nil
+}
+
+// key the key verifying token, selected by its algorithm and kid
+func (a *Authorizer) key(token *jwt.Token) (interface{}, error) {
+	switch token.Method {
+	case jwt.SigningMethodHS256:
+		return []byte(a.config.HMACSecret), nil
+	case jwt.SigningMethodRS256:
+		kid, _ := token.Header["kid"].(string)
+		if key, ok := a.rsaKeys[kid]; ok {
+			return key, nil
+		}
+		// a set of one key serves the tokens without kid
+		if kid == "" && len(a.rsaKeys) == 1 {
+			for _, key := range a.rsaKeys {
+				return key, nil
+			}
+		}
+		return nil, fmt.Errorf("unknown key %q", kid)
+	}
+	return nil, fmt.Errorf("unexpected signing method %s", token.Method.Alg())
+}
@@ -0,0 +1,289 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"io/ioutil"
+	"math/big"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/muhammadisa/bareksanews/constant"
+	"github.com/stretchr/testify/suite"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	secret  = `hmac-secret`
+	service = `api.v1.BareksaNewsService`
+)
+
+type authTestSuite struct {
+	suite.Suite
+	rsaKey   *rsa.PrivateKey
+	jwksFile string
+}
+
+func TestAuthTestSuite(t *testing.T) {
+	suite.Run(t, new(authTestSuite))
+}
+
+func (ts *authTestSuite) SetupSuite() {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	ts.Require().NoError(err)
+	ts.rsaKey = key
+
+	set := map[string][]jwk{"keys": {{
+		Kty: "RSA",
+		Kid: "key-1",
+		Use: "sig",
+		Alg: "RS256",
+		N:   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+		E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+	}}}
+	content, err := json.Marshal(set)
+	ts.Require().NoError(err)
+	ts.jwksFile = filepath.Join(ts.T().TempDir(), "jwks.json")
+	ts.Require().NoError(ioutil.WriteFile(ts.jwksFile, content, 0600))
+}
+
+func claims(subject string, roles ...interface{}) jwt.MapClaims {
+	return jwt.MapClaims{
+		"sub":   subject,
+		"roles": roles,
+		"exp":   time.Now().Add(time.Hour).Unix(),
+	}
+}
+
+func (ts *authTestSuite) hs256(c jwt.MapClaims) string {
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, c).SignedString([]byte(secret))
+	ts.Require().NoError(err)
+	return token
+}
+
+func (ts *authTestSuite) rs256(kid string, c jwt.MapClaims) string {
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, c)
+	token.Header["kid"] = kid
+	signed, err := token.SignedString(ts.rsaKey)
+	ts.Require().NoError(err)
+	return signed
+}
+
+func (ts *authTestSuite) authorizer(config Config) *Authorizer {
+	if config.RolesClaim == "" {
+		config.RolesClaim = constant.RolesClaim
+	}
+	a, err := NewAuthorizer(config)
+	ts.Require().NoError(err)
+	return a
+}
+
+// incoming a call carrying authorization
+func incoming(authorization string) context.Context {
+	if authorization == "" {
+		return context.Background()
+	}
+	return metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", authorization))
+}
+
+func (ts *authTestSuite) TestAuthorize() {
+	a := ts.authorizer(Config{HMACSecret: secret, JWKSFile: ts.jwksFile})
+
+	// test case
+	tests := []struct {
+		Name          string
+		RPC           string
+		Authorization string
+		Want          codes.Code
+	}{
+		{
+			Name: "public rpc without token",
+			RPC:  "GetNewses",
+			Want: codes.OK,
+		},
+		{
+			Name: "edit without token",
+			RPC:  "EditNews",
+			Want: codes.Unauthenticated,
+		},
+		{
+			Name:          "reader edits",
+			RPC:           "EditNews",
+			Authorization: "Bearer " + ts.hs256(claims("reader-1", constant.RoleReader)),
+			Want:          codes.PermissionDenied,
+		},
+		{
+			Name:          "editor edits",
+			RPC:           "EditNews",
+			Authorization: "Bearer " + ts.hs256(claims("editor-1", constant.RoleEditor)),
+			Want:          codes.OK,
+		},
+		{
+			Name:          "editor deletes a topic",
+			RPC:           "DeleteTopic",
+			Authorization: "Bearer " + ts.hs256(claims("editor-1", constant.RoleEditor)),
+			Want:          codes.PermissionDenied,
+		},
+		{
+			Name:          "admin deletes a topic with rs256",
+			RPC:           "DeleteTopic",
+			Authorization: "bearer " + ts.rs256("key-1", claims("admin-1", constant.RoleReader, constant.RoleAdmin)),
+			Want:          codes.OK,
+		},
+		{
+			Name:          "unknown role",
+			RPC:           "ExportNews",
+			Authorization: "Bearer " + ts.hs256(claims("root", "superuser")),
+			Want:          codes.PermissionDenied,
+		},
+		{
+			Name:          "unlisted rpc needs admin",
+			RPC:           "PurgeEverything",
+			Authorization: "Bearer " + ts.hs256(claims("editor-1", constant.RoleEditor)),
+			Want:          codes.PermissionDenied,
+		},
+		{
+			Name:          "invalid token on a public rpc",
+			RPC:           "GetNewses",
+			Authorization: "Bearer " + ts.hs256(claims("reader-1", constant.RoleReader)) + "x",
+			Want:          codes.Unauthenticated,
+		},
+		{
+			Name:          "expired token",
+			RPC:           "GetNewses",
+			Authorization: "Bearer " + ts.hs256(jwt.MapClaims{"sub": "reader-1", "exp": time.Now().Add(-time.Minute).Unix()}),
+			Want:          codes.Unauthenticated,
+		},
+		{
+			Name:          "unknown kid",
+			RPC:           "GetNewses",
+			Authorization: "Bearer " + ts.rs256("key-2", claims("admin-1", constant.RoleAdmin)),
+			Want:          codes.Unauthenticated,
+		},
+		{
+			Name:          "basic authorization",
+			RPC:           "GetNewses",
+			Authorization: "Basic YWRtaW46YWRtaW4=",
+			Want:          codes.Unauthenticated,
+		},
+	}
+
+	for _, test := range tests {
+		ts.Run(test.Name, func() {
+			_, err := a.Authorize(incoming(test.Authorization), test.RPC)
+			ts.Assert().Equal(test.Want, status.Code(err), err)
+		})
+	}
+}
+
+func (ts *authTestSuite) TestClaimsInContext() {
+	a := ts.authorizer(Config{HMACSecret: secret})
+
+	ctx, err := a.Authorize(incoming("Bearer "+ts.hs256(jwt.MapClaims{"sub": "editor-1", "roles": "reader editor", "exp": time.Now().Add(time.Hour).Unix()})), "AddNews")
+	ts.Require().NoError(err)
+	c, ok := FromContext(ctx)
+	ts.Require().True(ok)
+	ts.Assert().Equal(Claims{Subject: "editor-1", Role: constant.RoleEditor}, c)
+
+	// the claims verified once are trusted by the next check
+	_, err = a.Authorize(ctx, "EditNews")
+	ts.Assert().NoError(err)
+	_, err = a.Authorize(ctx, "FlushCache")
+	ts.Assert().Equal(codes.PermissionDenied, status.Code(err))
+}
+
+func (ts *authTestSuite) TestSigningMethods() {
+	// rs256 tokens are refused without a key set and hs256 tokens without
+	// a secret
+	_, err := ts.authorizer(Config{HMACSecret: secret}).Authorize(incoming("Bearer "+ts.rs256("key-1", claims("admin-1", constant.RoleAdmin))), "GetTags")
+	ts.Assert().Equal(codes.Unauthenticated, status.Code(err))
+	_, err = ts.authorizer(Config{JWKSFile: ts.jwksFile}).Authorize(incoming("Bearer "+ts.hs256(claims("admin-1", constant.RoleAdmin))), "GetTags")
+	ts.Assert().Equal(codes.Unauthenticated, status.Code(err))
+
+	unsigned, err := jwt.NewWithClaims(jwt.SigningMethodNone, claims("admin-1", constant.RoleAdmin)).SignedString(jwt.UnsafeAllowNoneSignatureType)
+	ts.Require().NoError(err)
+	_, err = ts.authorizer(Config{HMACSecret: secret, JWKSFile: ts.jwksFile}).Authorize(incoming("Bearer "+unsigned), "GetTags")
+	ts.Assert().Equal(codes.Unauthenticated, status.Code(err))
+}
+
+func (ts *authTestSuite) TestIssuerAndAudience() {
+	a := ts.authorizer(Config{HMACSecret: secret, Issuer: "https://auth.bareksa.com", Audience: "bareksa-news"})
+
+	c := claims("editor-1", constant.RoleEditor)
+	_, err := a.Authorize(incoming("Bearer "+ts.hs256(c)), "AddNews")
+	ts.Assert().Equal(codes.Unauthenticated, status.Code(err))
+
+	c["iss"], c["aud"] = "https://auth.bareksa.com", []string{"bareksa-news", "bareksa-funds"}
+	_, err = a.Authorize(incoming("Bearer "+ts.hs256(c)), "AddNews")
+	ts.Assert().NoError(err)
+}
+
+func (ts *authTestSuite) TestPermissions() {
+	a := ts.authorizer(Config{HMACSecret: secret, Permissions: map[string]string{"GetNewses": constant.RoleReader}})
+	_, err := a.Authorize(incoming(""), "GetNewses")
+	ts.Assert().Equal(codes.Unauthenticated, status.Code(err))
+	_, err = a.Authorize(incoming(""), "GetTags")
+	ts.Assert().NoError(err)
+
+	// a disabled config opens every rpc
+	_, err = ts.authorizer(Config{Disabled: true}).Authorize(incoming(""), "DeleteTopic")
+	ts.Assert().NoError(err)
+
+	rpcs := []string{"GetNewses", "DeleteTopic"}
+	ts.Assert().NoError(Config{HMACSecret: secret, RolesClaim: constant.RolesClaim, Permissions: map[string]string{"GetNewses": constant.RoleReader}}.Validate(rpcs))
+	ts.Assert().Error(Config{Disabled: true, Permissions: map[string]string{"GetNews": constant.RoleReader}}.Validate(rpcs))
+	ts.Assert().Error(Config{Disabled: true, Permissions: map[string]string{"DeleteTopic": "root"}}.Validate(rpcs))
+}
+
+func (ts *authTestSuite) TestFailClosed() {
+	// without keys the service refuses to start unless auth is disabled
+	config := Config{RolesClaim: constant.RolesClaim}
+	ts.Assert().Error(config.Validate(nil))
+	_, err := NewAuthorizer(config)
+	ts.Assert().Error(err)
+	config.Disabled = true
+	ts.Assert().NoError(config.Validate(nil))
+
+	// a token without exp would never expire
+	c := claims("admin-1", constant.RoleAdmin)
+	delete(c, "exp")
+	_, err = ts.authorizer(Config{HMACSecret: secret}).Authorize(incoming("Bearer "+ts.hs256(c)), "DeleteTopic")
+	ts.Assert().Equal(codes.Unauthenticated, status.Code(err))
+}
+
+func (ts *authTestSuite) TestReadJWKS() {
+	path := filepath.Join(ts.T().TempDir(), "jwks.json")
+	ts.Require().NoError(ioutil.WriteFile(path, []byte(`{"keys":[{"kty":"EC","kid":"ec-1","crv":"P-256"}]}`), 0600))
+	_, err := NewAuthorizer(Config{JWKSFile: path, RolesClaim: constant.RolesClaim})
+	ts.Assert().Error(err)
+
+	_, err = NewAuthorizer(Config{JWKSFile: filepath.Join(ts.T().TempDir(), "missing.json"), RolesClaim: constant.RolesClaim})
+	ts.Assert().Error(err)
+}
+
+func (ts *authTestSuite) TestUnaryServerInterceptor() {
+	interceptor := UnaryServerInterceptor(ts.authorizer(Config{HMACSecret: secret}), service)
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		c, _ := FromContext(ctx)
+		return c.Subject, nil
+	}
+
+	// health and reflection stay open
+	_, err := interceptor(incoming(""), nil, &grpc.UnaryServerInfo{FullMethod: "/grpc.health.v1.Health/Check"}, handler)
+	ts.Assert().NoError(err)
+
+	_, err = interceptor(incoming(""), nil, &grpc.UnaryServerInfo{FullMethod: "/" + service + "/DeleteTopic"}, handler)
+	ts.Assert().Equal(codes.Unauthenticated, status.Code(err))
+
+	subject, err := interceptor(incoming("Bearer "+ts.hs256(claims("admin-1", constant.RoleAdmin))), nil, &grpc.UnaryServerInfo{FullMethod: "/" + service + "/DeleteTopic"}, handler)
+	ts.Require().NoError(err)
+	ts.Assert().Equal("admin-1", subject)
+}
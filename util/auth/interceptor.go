@@ -0,0 +1,60 @@
+package auth
+
+import (
+	"context"
+	"strings"
+
+	"google.golang.org/grpc"
+)
+
+// rpc the name of the method of service fullMethod names, ok is false for
+// the methods of other services like health and reflection
+func rpc(service, fullMethod string) (name string, ok bool) {
+	prefix := "/" + service + "/"
+	if !strings.HasPrefix(fullMethod, prefix) {
+		return "", false
+	}
+	return strings.TrimPrefix(fullMethod, prefix), true
+}
+
+// UnaryServerInterceptor authorize the unary calls of service before they
+// are decoded, the calls of other services are let through
+func UnaryServerInterceptor(a *Authorizer, service string) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		name, ok := rpc(service, info.FullMethod)
+		if !ok {
+			return handler(ctx, req)
+		}
+		ctx, err := a.Authorize(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamServerInterceptor authorize the streaming calls of service like
+// UnaryServerInterceptor
+func StreamServerInterceptor(a *Authorizer, service string) grpc.StreamServerInterceptor {
+	return func(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		name, ok := rpc(service, info.FullMethod)
+		if !ok {
+			return handler(srv, stream)
+		}
+		ctx, err := a.Authorize(stream.Context(), name)
+		if err != nil {
+			return err
+		}
+		return handler(srv, authorizedStream{ServerStream: stream, ctx: ctx})
+	}
+}
+
+// authorizedStream carry the claims of the caller in its context
+type authorizedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s authorizedStream) Context() context.Context {
+	return s.ctx
+}
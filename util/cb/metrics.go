@@ -0,0 +1,32 @@
+package cb
+
+import (
+	"github.com/afex/hystrix-go/hystrix"
+	"github.com/muhammadisa/bareksanews/constant"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// RegisterMetrics export the state of the circuit of every command with
+// registerer, 1 while it is open and 0 while it is closed
+func RegisterMetrics(registerer prometheus.Registerer, commands ...string) error {
+	for _, command := range commands {
+		command := command
+		gauge := prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace:   constant.ServiceName,
+			Subsystem:   "circuit_breaker",
+			Name:        "open",
+			Help:        "1 while the circuit of the hystrix command is open.",
+			ConstLabels: prometheus.Labels{"command": command},
+		}, func() float64 {
+			circuit, _, err := hystrix.GetCircuit(command)
+			if err != nil || !circuit.IsOpen() {
+				return 0
+			}
+			return 1
+		})
+		if err := registerer.Register(gauge); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,197 @@
+package crt
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"sync"
+	"time"
+
+	"github.com/go-kit/kit/log/level"
+	"github.com/muhammadisa/bareksanews/constant"
+	"github.com/muhammadisa/bareksanews/gvars"
+	"github.com/muhammadisa/bareksanews/util/lgr"
+)
+
+const (
+	protoHTTP2 = `h2`
+	protoHTTP1 = `http/1.1`
+)
+
+// Config the certificate of the listener, tls is off while CertFile is
+// empty
+type Config struct {
+	// CertFile and KeyFile PEM encoded certificate chain and private key
+	CertFile string `yaml:"cert_file"`
+	KeyFile  string `yaml:"key_file"`
+	// ClientCAFile PEM encoded CAs the client certificates are verified
+	// against, mutual tls is off while it is empty
+	ClientCAFile string `yaml:"client_ca_file"`
+	// ClientAuth constant.ClientAuthRequire or
+	// constant.ClientAuthVerifyIfGiven, require is used when it left empty
+	ClientAuth string `yaml:"client_auth"`
+	// ReloadInterval between checks of the files for a renewal
+	ReloadInterval time.Duration `yaml:"reload_interval"`
+}
+
+// Enabled report whether the listener serves tls
+func (c Config) Enabled() bool {
+	return c.CertFile != ""
+}
+
+// Validate report a configuration NewReloader refuses without reading the
+// files
+func (c Config) Validate() error {
+	if !c.Enabled() {
+		if c.KeyFile != "" || c.ClientCAFile != "" {
+			return errors.New("cert_file is required with key_file or client_ca_file")
+		}
+		return nil
+	}
+	if c.KeyFile == "" {
+		return errors.New("key_file is required with cert_file")
+	}
+	switch c.ClientAuth {
+	case "", constant.ClientAuthRequire, constant.ClientAuthVerifyIfGiven:
+	default:
+		return fmt.Errorf("unknown client_auth %q", c.ClientAuth)
+	}
+	if c.ReloadInterval <= 0 {
+		return fmt.Errorf("reload_interval: %s is not positive", c.ReloadInterval)
+	}
+	return nil
+}
+
+// Reloader serve the certificate and client CAs read last from the files
+// of its Config, a renewal is picked up by the next handshake
+type Reloader struct {
+	config Config
+
+	mu          sync.RWMutex
+	certificate *tls.Certificate
+	clientCAs   *x509.CertPool
+	// digest of the files read last
+	digest []byte
+}
+
+// NewReloader read the files of config, they must hold a valid certificate
+// and key
+func NewReloader(config Config) (*Reloader, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	r := &Reloader{config: config}
+	if _, err := r.Reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Reload read the files again, reloaded reports they changed, a failure
+// keeps the certificate read before
+func (r *Reloader) Reload() (reloaded bool, err error) {
+	var files [][]byte
+	for _, path := range []string{r.config.CertFile, r.config.KeyFile, r.config.ClientCAFile} {
+		if path == "" {
+			continue
+		}
+		content, err := ioutil.ReadFile(path)
+		if err != nil {
+			return false, err
+		}
+		files = append(files, content)
+	}
+	digest := sha256.New()
+	for _, content := range files {
+		digest.Write(content)
+	}
+	sum := digest.Sum(nil)
+
+	r.mu.RLock()
+	unchanged := bytes.Equal(sum, r.digest)
+	r.mu.RUnlock()
+	if unchanged {
+		return false, nil
+	}
+
+	certificate, err := tls.X509KeyPair(files[0], files[1])
+	if err != nil {
+		return false, fmt.Errorf("%s: %v", r.config.CertFile, err)
+	}
+	var clientCAs *x509.CertPool
+	if r.config.ClientCAFile != "" {
+		clientCAs = x509.NewCertPool()
+		if !clientCAs.AppendCertsFromPEM(files[2]) {
+			return false, fmt.Errorf("%s: no certificate found", r.config.ClientCAFile)
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.certificate, r.clientCAs, r.digest = &certificate, clientCAs, sum
+	return true, nil
+}
+
+// Watch reload the files every interval until ctx is done, failures are
+// logged and the certificate read before stays in use
+func (r *Reloader) Watch(ctx context.Context) {
+	ticker := time.NewTicker(r.config.ReloadInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		reloaded, err := r.Reload()
+		if err != nil {
+			level.Error(gvars.Log).Log(lgr.LogErr, fmt.Sprintf("reloading the tls certificate: %v", err))
+			continue
+		}
+		if reloaded {
+			level.Info(gvars.Log).Log(lgr.LogInfo, "tls certificate reloaded")
+		}
+	}
+}
+
+// TLSConfig the server configuration of every handshake, built from the
+// files read last
+//
+// grpc and rest share the listener and h2 can not tell them apart, clients
+// offering http/1.1 are answered with it and the grpc clients, offering h2
+// alone, with h2
+func (r *Reloader) TLSConfig() *tls.Config {
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		NextProtos: []string{protoHTTP2, protoHTTP1},
+		GetConfigForClient: func(hello *tls.ClientHelloInfo) (*tls.Config, error) {
+			r.mu.RLock()
+			certificate, clientCAs := r.certificate, r.clientCAs
+			r.mu.RUnlock()
+
+			config := &tls.Config{
+				MinVersion:   tls.VersionTLS12,
+				Certificates: []tls.Certificate{*certificate},
+				NextProtos:   []string{protoHTTP2},
+			}
+			for _, proto := range hello.SupportedProtos {
+				if proto == protoHTTP1 {
+					config.NextProtos = []string{protoHTTP1}
+				}
+			}
+			if clientCAs != nil {
+				config.ClientCAs = clientCAs
+				config.ClientAuth = tls.RequireAndVerifyClientCert
+				if r.config.ClientAuth == constant.ClientAuthVerifyIfGiven {
+					config.ClientAuth = tls.VerifyClientCertIfGiven
+				}
+			}
+			return config, nil
+		},
+	}
+}
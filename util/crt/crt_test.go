@@ -0,0 +1,181 @@
+package crt
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/muhammadisa/bareksanews/constant"
+	"github.com/muhammadisa/bareksanews/gvars"
+	"github.com/muhammadisa/bareksanews/util/crt/crttest"
+	"github.com/stretchr/testify/suite"
+)
+
+type crtTestSuite struct {
+	suite.Suite
+	ca     *crttest.CA
+	config Config
+}
+
+func TestCrtTestSuite(t *testing.T) {
+	suite.Run(t, new(crtTestSuite))
+}
+
+func (ts *crtTestSuite) SetupSuite() {
+	gvars.Log = log.NewNopLogger()
+}
+
+func (ts *crtTestSuite) SetupTest() {
+	ca, err := crttest.NewCA("test ca")
+	ts.Require().NoError(err)
+	ts.ca = ca
+
+	dir := ts.T().TempDir()
+	ts.config = Config{
+		CertFile:       filepath.Join(dir, "server.crt"),
+		KeyFile:        filepath.Join(dir, "server.key"),
+		ClientCAFile:   filepath.Join(dir, "client_ca.crt"),
+		ReloadInterval: 10 * time.Millisecond,
+	}
+	ts.issue("first")
+	ts.Require().NoError(ioutil.WriteFile(ts.config.ClientCAFile, ca.PEM, 0600))
+}
+
+// issue write a new server certificate of name over the files
+func (ts *crtTestSuite) issue(name string) {
+	certPEM, keyPEM, err := ts.ca.Issue(name, "localhost")
+	ts.Require().NoError(err)
+	ts.Require().NoError(ioutil.WriteFile(ts.config.CertFile, certPEM, 0600))
+	ts.Require().NoError(ioutil.WriteFile(ts.config.KeyFile, keyPEM, 0600))
+}
+
+// handshake the configuration the reloader answers to a client offering
+// protos
+func (ts *crtTestSuite) handshake(r *Reloader, protos ...string) *tls.Config {
+	config, err := r.TLSConfig().GetConfigForClient(&tls.ClientHelloInfo{SupportedProtos: protos})
+	ts.Require().NoError(err)
+	return config
+}
+
+func (ts *crtTestSuite) served(r *Reloader) string {
+	config := ts.handshake(r)
+	ts.Require().Len(config.Certificates, 1)
+	certificate, err := x509.ParseCertificate(config.Certificates[0].Certificate[0])
+	ts.Require().NoError(err)
+	return certificate.Subject.CommonName
+}
+
+func (ts *crtTestSuite) TestValidate() {
+	// test case
+	tests := []struct {
+		Name   string
+		Config Config
+		Valid  bool
+	}{
+		{
+			Name:  "tls off",
+			Valid: true,
+		},
+		{
+			Name:   "client ca without certificate",
+			Config: Config{ClientCAFile: "client_ca.crt"},
+		},
+		{
+			Name:   "certificate without key",
+			Config: Config{CertFile: "server.crt", ReloadInterval: time.Second},
+		},
+		{
+			Name:   "unknown client auth",
+			Config: Config{CertFile: "server.crt", KeyFile: "server.key", ClientAuth: "optional", ReloadInterval: time.Second},
+		},
+		{
+			Name:   "no reload interval",
+			Config: Config{CertFile: "server.crt", KeyFile: "server.key"},
+		},
+		{
+			Name:   "mutual tls",
+			Config: Config{CertFile: "server.crt", KeyFile: "server.key", ClientCAFile: "client_ca.crt", ClientAuth: constant.ClientAuthVerifyIfGiven, ReloadInterval: time.Second},
+			Valid:  true,
+		},
+	}
+
+	for _, test := range tests {
+		ts.Run(test.Name, func() {
+			err := test.Config.Validate()
+			if test.Valid {
+				ts.Assert().NoError(err)
+				return
+			}
+			ts.Assert().Error(err)
+		})
+	}
+}
+
+func (ts *crtTestSuite) TestReload() {
+	r, err := NewReloader(ts.config)
+	ts.Require().NoError(err)
+	ts.Assert().Equal("first", ts.served(r))
+
+	reloaded, err := r.Reload()
+	ts.Require().NoError(err)
+	ts.Assert().False(reloaded)
+
+	ts.issue("second")
+	reloaded, err = r.Reload()
+	ts.Require().NoError(err)
+	ts.Assert().True(reloaded)
+	ts.Assert().Equal("second", ts.served(r))
+
+	// a half written renewal keeps the certificate served
+	ts.Require().NoError(ioutil.WriteFile(ts.config.KeyFile, []byte("truncated"), 0600))
+	_, err = r.Reload()
+	ts.Assert().Error(err)
+	ts.Assert().Equal("second", ts.served(r))
+}
+
+func (ts *crtTestSuite) TestWatch() {
+	r, err := NewReloader(ts.config)
+	ts.Require().NoError(err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		r.Watch(ctx)
+		close(done)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	ts.issue("renewed")
+	ts.Eventually(func() bool { return ts.served(r) == "renewed" }, time.Second, 5*time.Millisecond)
+}
+
+func (ts *crtTestSuite) TestHandshake() {
+	r, err := NewReloader(ts.config)
+	ts.Require().NoError(err)
+
+	// browsers and rest clients get http/1.1, grpc clients h2
+	ts.Assert().Equal([]string{protoHTTP1}, ts.handshake(r, protoHTTP2, protoHTTP1).NextProtos)
+	ts.Assert().Equal([]string{protoHTTP2}, ts.handshake(r, protoHTTP2).NextProtos)
+
+	config := ts.handshake(r)
+	ts.Assert().Equal(tls.RequireAndVerifyClientCert, config.ClientAuth)
+	ts.Assert().NotNil(config.ClientCAs)
+
+	ts.config.ClientAuth = constant.ClientAuthVerifyIfGiven
+	r, err = NewReloader(ts.config)
+	ts.Require().NoError(err)
+	ts.Assert().Equal(tls.VerifyClientCertIfGiven, ts.handshake(r).ClientAuth)
+
+	ts.config.ClientCAFile = ""
+	r, err = NewReloader(ts.config)
+	ts.Require().NoError(err)
+	ts.Assert().Equal(tls.NoClientCert, ts.handshake(r).ClientAuth)
+}
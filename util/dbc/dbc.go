@@ -3,48 +3,155 @@ package dbc
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
-	"github.com/go-redis/redis/v8"
+	"time"
+
 	_ "github.com/go-sql-driver/mysql"
+	"github.com/muhammadisa/bareksanews/constant"
+	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
+	_ "modernc.org/sqlite"
 )
 
 type Config struct {
-	Username string
-	Password string
-	Host     string
-	Port     string
-	Name     string
+	Username string `yaml:"username"`
+	Password string `yaml:"password" secret:"true"`
+	Host     string `yaml:"host"`
+	Port     string `yaml:"port"`
+	Name     string `yaml:"name"`
+
+	// MaxOpenConns, MaxIdleConns, ConnMaxLifetime and ConnMaxIdleTime
+	// tune the connection pool, zero keeps the driver default, MaxIdleConns
+	// only applies to mysql, redis and mongodb have no such limit
+	MaxOpenConns    int           `yaml:"max_open_conns"`
+	MaxIdleConns    int           `yaml:"max_idle_conns"`
+	ConnMaxLifetime time.Duration `yaml:"conn_max_lifetime"`
+	ConnMaxIdleTime time.Duration `yaml:"conn_max_idle_time"`
+
+	// MinIdleConns connections redis and mongodb keep open while unused,
+	// mysql has no such floor
+	MinIdleConns int `yaml:"min_idle_conns"`
+
+	// DialTimeout, ReadTimeout and WriteTimeout bound every connection
+	// attempt and network round trip, zero keeps the driver default
+	DialTimeout  time.Duration `yaml:"dial_timeout"`
+	ReadTimeout  time.Duration `yaml:"read_timeout"`
+	WriteTimeout time.Duration `yaml:"write_timeout"`
+
+	// ConnectRetries is how many times connectivity is checked again on
+	// startup, waiting RetryBackoff doubled after every failed attempt
+	ConnectRetries int           `yaml:"connect_retries"`
+	RetryBackoff   time.Duration `yaml:"retry_backoff"`
+}
+
+func (conf Config) addr() string {
+	return fmt.Sprintf("%s:%s", conf.Host, conf.Port)
 }
 
-func OpenRedis(conf Config) (redis.Cmdable, error) {
-	return redis.NewClient(&redis.Options{
-		Addr:     fmt.Sprintf("%s:%s", conf.Host, conf.Port),
-		Password: conf.Password,
-		DB:       0,
-	}), nil
+// verify run ping of target until it succeeds or the configured retries
+// run out
+func verify(conf Config, target string, ping func(ctx context.Context) error) (err error) {
+	backoff := conf.RetryBackoff
+	for attempt := 0; attempt <= conf.ConnectRetries; attempt++ {
+		if attempt > 0 {
+			time.Sleep(backoff)
+			backoff *= 2
+		}
+		ctx := context.Background()
+		cancel := func() {}
+		if conf.DialTimeout > 0 {
+			ctx, cancel = context.WithTimeout(ctx, conf.DialTimeout)
+		}
+		err = ping(ctx)
+		cancel()
+		if err == nil {
+			return nil
+		}
+	}
+	return fmt.Errorf("unable to reach %s after %d attempts : %w", target, conf.ConnectRetries+1, err)
 }
 
+// noSQLIndexes indexes of every collection created by OpenNoSQL
+var noSQLIndexes = map[string][]mongo.IndexModel{
+	constant.TagsCollection: {
+		{Keys: bson.D{{Key: "created_at", Value: -1}}},
+	},
+	constant.TopicsCollection: {
+		{Keys: bson.D{{Key: "created_at", Value: -1}}},
+	},
+	constant.NewsCollection: {
+		{Keys: bson.D{{Key: "created_at", Value: -1}}},
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "created_at", Value: -1}}},
+		{Keys: bson.D{{Key: "topic_id", Value: 1}, {Key: "status", Value: 1}, {Key: "created_at", Value: -1}}},
+		{Keys: bson.D{{Key: "tags.id", Value: 1}}},
+	},
+}
+
+// mongoNamespaceExists error code of creating a collection twice
+const mongoNamespaceExists = 48
+
+// OpenNoSQL connect to mongodb and create the tags, topics and news
+// collections with their indexes when they do not exist yet
 func OpenNoSQL(conf Config) (*mongo.Database, error) {
 	ctx := context.Background()
-	credential := options.Credential{
-		AuthMechanism: "SCRAM-SHA-1",
-		Username:      conf.Username,
-		Password:      conf.Password,
-	}
 	uri := fmt.Sprintf("mongodb://%s:%s", conf.Host, conf.Port)
-	client, err := mongo.Connect(ctx, options.Client().ApplyURI(uri).SetAuth(credential))
+	clientOptions := options.Client().ApplyURI(uri)
+	if conf.Username != "" {
+		clientOptions.SetAuth(options.Credential{
+			AuthMechanism: "SCRAM-SHA-1",
+			Username:      conf.Username,
+			Password:      conf.Password,
+		})
+	}
+	if conf.MaxOpenConns > 0 {
+		clientOptions.SetMaxPoolSize(uint64(conf.MaxOpenConns))
+	}
+	if conf.MinIdleConns > 0 {
+		clientOptions.SetMinPoolSize(uint64(conf.MinIdleConns))
+	}
+	if conf.ConnMaxIdleTime > 0 {
+		clientOptions.SetMaxConnIdleTime(conf.ConnMaxIdleTime)
+	}
+	if conf.DialTimeout > 0 {
+		clientOptions.SetConnectTimeout(conf.DialTimeout)
+		clientOptions.SetServerSelectionTimeout(conf.DialTimeout)
+	}
+	if conf.ReadTimeout > 0 {
+		clientOptions.SetSocketTimeout(conf.ReadTimeout)
+	}
+	client, err := mongo.Connect(ctx, clientOptions)
+	if err != nil {
+		return nil, err
+	}
+	err = verify(conf, conf.addr(), func(ctx context.Context) error {
+		return client.Ping(ctx, nil)
+	})
 	if err != nil {
+		_ = client.Disconnect(ctx)
 		return nil, err
 	}
+
 	mongoDb := client.Database(conf.Name)
-	err = mongoDb.CreateCollection(ctx, "wallet")
-	err = mongoDb.CreateCollection(ctx, "charity")
+	for collection, indexes := range noSQLIndexes {
+		err = mongoDb.CreateCollection(ctx, collection)
+		var cmdErr mongo.CommandError
+		if err != nil && !(errors.As(err, &cmdErr) && cmdErr.Code == mongoNamespaceExists) {
+			_ = client.Disconnect(ctx)
+			return nil, err
+		}
+		_, err = mongoDb.Collection(collection).Indexes().CreateMany(ctx, indexes)
+		if err != nil {
+			_ = client.Disconnect(ctx)
+			return nil, err
+		}
+	}
 	return mongoDb, nil
 }
 
-func OpenDB(conf Config) (*sql.DB, error) {
+// NewDB open the mysql connection pool without checking connectivity
+func NewDB(conf Config) (*sql.DB, error) {
 	databaseUrl := fmt.Sprintf(
 		"%s:%s@tcp(%s:%s)/%s?parseTime=true",
 		conf.Username,
@@ -53,5 +160,85 @@ func OpenDB(conf Config) (*sql.DB, error) {
 		conf.Port,
 		conf.Name,
 	)
-	return sql.Open("mysql", databaseUrl)
+	if conf.DialTimeout > 0 {
+		databaseUrl += fmt.Sprintf("&timeout=%s", conf.DialTimeout)
+	}
+	if conf.ReadTimeout > 0 {
+		databaseUrl += fmt.Sprintf("&readTimeout=%s", conf.ReadTimeout)
+	}
+	if conf.WriteTimeout > 0 {
+		databaseUrl += fmt.Sprintf("&writeTimeout=%s", conf.WriteTimeout)
+	}
+	db, err := sql.Open("mysql", databaseUrl)
+	if err != nil {
+		return nil, err
+	}
+	configurePool(db, conf)
+	pools.set(fmt.Sprintf("mysql:%s:%s/%s", conf.Host, conf.Port, conf.Name), func() poolStat {
+		stats := db.Stats()
+		return poolStat{open: stats.OpenConnections, idle: stats.Idle}
+	})
+	return db, nil
+}
+
+// OpenDB open the mysql connection pool and verify the database is
+// reachable before returning it
+func OpenDB(conf Config) (*sql.DB, error) {
+	db, err := NewDB(conf)
+	if err != nil {
+		return nil, err
+	}
+	err = verify(conf, conf.addr(), db.PingContext)
+	if err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+	return db, nil
+}
+
+func configurePool(db *sql.DB, conf Config) {
+	if conf.MaxOpenConns > 0 {
+		db.SetMaxOpenConns(conf.MaxOpenConns)
+	}
+	if conf.MaxIdleConns > 0 {
+		db.SetMaxIdleConns(conf.MaxIdleConns)
+	}
+	if conf.ConnMaxLifetime > 0 {
+		db.SetConnMaxLifetime(conf.ConnMaxLifetime)
+	}
+	if conf.ConnMaxIdleTime > 0 {
+		db.SetConnMaxIdleTime(conf.ConnMaxIdleTime)
+	}
+}
+
+// OpenSQLite open an embedded sqlite database, Name is the database file
+// path, leave it empty or use :memory: for a database living in memory
+func OpenSQLite(conf Config) (*sql.DB, error) {
+	databaseUrl := fmt.Sprintf(
+		"file:%s?_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)",
+		conf.Name,
+	)
+	if conf.Name == "" || conf.Name == ":memory:" {
+		databaseUrl = "file:bareksa_news?mode=memory&cache=shared&_pragma=foreign_keys(1)"
+	}
+	db, err := sql.Open("sqlite", databaseUrl)
+	if err != nil {
+		return nil, err
+	}
+	configurePool(db, conf)
+	return db, nil
+}
+
+type primaryKey struct{}
+
+// WithPrimary force every read made with the returned context to be served
+// by the primary database instead of a replica
+func WithPrimary(ctx context.Context) context.Context {
+	return context.WithValue(ctx, primaryKey{}, true)
+}
+
+// UsePrimary report whether reads must skip the replicas
+func UsePrimary(ctx context.Context) bool {
+	primary, _ := ctx.Value(primaryKey{}).(bool)
+	return primary
 }
@@ -0,0 +1,106 @@
+package dbc
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/suite"
+)
+
+type dbcTestSuite struct {
+	suite.Suite
+}
+
+func TestDbcTestSuite(t *testing.T) {
+	suite.Run(t, new(dbcTestSuite))
+}
+
+func (ts *dbcTestSuite) TestVerify() {
+	errorDummy := errors.New("connection refused")
+
+	// test case
+	tests := []struct {
+		Name         string
+		Retries      int
+		FailAttempts int
+		WantAttempts int
+		WantError    bool
+	}{
+		{
+			Name:         "verify succeed at first attempt",
+			Retries:      3,
+			FailAttempts: 0,
+			WantAttempts: 1,
+			WantError:    false,
+		},
+		{
+			Name:         "verify succeed after retries",
+			Retries:      3,
+			FailAttempts: 2,
+			WantAttempts: 3,
+			WantError:    false,
+		},
+		{
+			Name:         "verify failed after retries",
+			Retries:      2,
+			FailAttempts: 5,
+			WantAttempts: 3,
+			WantError:    true,
+		},
+	}
+
+	for _, test := range tests {
+		ts.Run(test.Name, func() {
+			conf := Config{
+				Host:           "localhost",
+				Port:           "3306",
+				DialTimeout:    time.Second,
+				ConnectRetries: test.Retries,
+				RetryBackoff:   time.Millisecond,
+			}
+			attempts := 0
+			err := verify(conf, "localhost:3306", func(ctx context.Context) error {
+				attempts++
+				_, ok := ctx.Deadline()
+				ts.Assert().True(ok)
+				if attempts <= test.FailAttempts {
+					return errorDummy
+				}
+				return nil
+			})
+			ts.Assert().Equal(test.WantAttempts, attempts)
+			if test.WantError {
+				ts.Assert().ErrorIs(err, errorDummy)
+			} else {
+				ts.Assert().NoError(err)
+			}
+		})
+	}
+}
+
+func (ts *dbcTestSuite) TestPoolMetrics() {
+	db, err := NewDB(Config{Host: "localhost", Port: "3306", Name: "pool_metrics"})
+	ts.Require().NoError(err)
+	defer db.Close()
+
+	registry := prometheus.NewPedanticRegistry()
+	ts.Require().NoError(registry.Register(pools))
+	families, err := registry.Gather()
+	ts.Require().NoError(err)
+
+	// every pool opened by the other tests is reported too
+	var found []string
+	for _, family := range families {
+		for _, metric := range family.GetMetric() {
+			for _, label := range metric.GetLabel() {
+				if label.GetValue() == "mysql:localhost:3306/pool_metrics" {
+					found = append(found, family.GetName())
+				}
+			}
+		}
+	}
+	ts.Assert().ElementsMatch([]string{"bareksa_news_pool_open_connections", "bareksa_news_pool_idle_connections"}, found)
+}
@@ -0,0 +1,135 @@
+package dbc
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/muhammadisa/bareksanews/constant"
+)
+
+// RedisConfig how redis is deployed, the embedded Config holds the
+// credentials, pool and timeouts shared with the other databases
+type RedisConfig struct {
+	Config `yaml:",inline"`
+
+	// Mode constant.RedisStandalone, constant.RedisSentinel or
+	// constant.RedisCluster, standalone is used when it left empty
+	Mode string `yaml:"mode"`
+	// Addrs host:port of the sentinels or the cluster seed nodes, Host
+	// and Port are used when it is empty
+	Addrs []string `yaml:"addrs"`
+	// MasterName name of the master monitored by the sentinels
+	MasterName       string `yaml:"master_name"`
+	SentinelPassword string `yaml:"sentinel_password" secret:"true"`
+	// DB database index, a cluster only has database 0
+	DB int `yaml:"db"`
+
+	// TLS connect over tls, verifying the server with the system roots
+	// unless TLSCAFile is set
+	TLS           bool   `yaml:"tls"`
+	TLSCAFile     string `yaml:"tls_ca_file"`
+	TLSServerName string `yaml:"tls_server_name"`
+}
+
+func (conf RedisConfig) addrs() []string {
+	if len(conf.Addrs) > 0 {
+		return conf.Addrs
+	}
+	return []string{conf.addr()}
+}
+
+func (conf RedisConfig) tlsConfig() (*tls.Config, error) {
+	if !conf.TLS {
+		return nil, nil
+	}
+	tlsConfig := &tls.Config{ServerName: conf.TLSServerName, MinVersion: tls.VersionTLS12}
+	if conf.TLSCAFile == "" {
+		return tlsConfig, nil
+	}
+	pem, err := ioutil.ReadFile(conf.TLSCAFile)
+	if err != nil {
+		return nil, err
+	}
+	tlsConfig.RootCAs = x509.NewCertPool()
+	if !tlsConfig.RootCAs.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("no certificate found in %s", conf.TLSCAFile)
+	}
+	return tlsConfig, nil
+}
+
+// options translate conf into the options of every redis client
+func (conf RedisConfig) options() (*redis.UniversalOptions, error) {
+	tlsConfig, err := conf.tlsConfig()
+	if err != nil {
+		return nil, err
+	}
+	return &redis.UniversalOptions{
+		Addrs:            conf.addrs(),
+		DB:               conf.DB,
+		Username:         conf.Username,
+		Password:         conf.Password,
+		SentinelPassword: conf.SentinelPassword,
+		MasterName:       conf.MasterName,
+		PoolSize:         conf.MaxOpenConns,
+		MinIdleConns:     conf.MinIdleConns,
+		MaxConnAge:       conf.ConnMaxLifetime,
+		IdleTimeout:      conf.ConnMaxIdleTime,
+		DialTimeout:      conf.DialTimeout,
+		ReadTimeout:      conf.ReadTimeout,
+		WriteTimeout:     conf.WriteTimeout,
+		TLSConfig:        tlsConfig,
+	}, nil
+}
+
+// newRedisClient build the client of conf.Mode without connecting
+func newRedisClient(conf RedisConfig) (redis.UniversalClient, error) {
+	opts, err := conf.options()
+	if err != nil {
+		return nil, err
+	}
+	switch conf.Mode {
+	case "", constant.RedisStandalone:
+		return redis.NewClient(opts.Simple()), nil
+	case constant.RedisSentinel:
+		if conf.MasterName == "" {
+			return nil, errors.New("redis sentinel mode needs the master name")
+		}
+		return redis.NewFailoverClient(opts.Failover()), nil
+	case constant.RedisCluster:
+		if conf.DB != 0 {
+			return nil, fmt.Errorf("redis cluster has no database %d", conf.DB)
+		}
+		return redis.NewClusterClient(opts.Cluster()), nil
+	default:
+		return nil, fmt.Errorf("unknown redis mode %q", conf.Mode)
+	}
+}
+
+// OpenRedis connect to a standalone, sentinel or cluster redis
+func OpenRedis(conf RedisConfig) (redis.UniversalClient, error) {
+	client, err := newRedisClient(conf)
+	if err != nil {
+		return nil, err
+	}
+	target := strings.Join(conf.addrs(), ",")
+	err = verify(conf.Config, target, func(ctx context.Context) error {
+		return client.Ping(ctx).Err()
+	})
+	if err != nil {
+		_ = client.Close()
+		return nil, err
+	}
+	if pooled, ok := client.(interface{ PoolStats() *redis.PoolStats }); ok {
+		pools.set(fmt.Sprintf("redis:%s", target), func() poolStat {
+			stats := pooled.PoolStats()
+			return poolStat{open: int(stats.TotalConns), idle: int(stats.IdleConns)}
+		})
+	}
+	return client, nil
+}
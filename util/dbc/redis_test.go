@@ -0,0 +1,140 @@
+package dbc
+
+import (
+	"context"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+	"github.com/muhammadisa/bareksanews/constant"
+	"github.com/stretchr/testify/suite"
+)
+
+type redisTestSuite struct {
+	suite.Suite
+}
+
+func TestRedisTestSuite(t *testing.T) {
+	suite.Run(t, new(redisTestSuite))
+}
+
+func (ts *redisTestSuite) TestNewRedisClient() {
+	tests := []struct {
+		Name      string
+		Conf      RedisConfig
+		Want      interface{}
+		WantError bool
+	}{
+		{
+			Name: "standalone when the mode is empty",
+			Conf: RedisConfig{Config: Config{Host: "localhost", Port: "6379"}, DB: 2},
+			Want: &redis.Client{},
+		},
+		{
+			Name: "sentinel",
+			Conf: RedisConfig{
+				Mode:       constant.RedisSentinel,
+				Addrs:      []string{"sentinel-1:26379", "sentinel-2:26379"},
+				MasterName: "news",
+			},
+			Want: &redis.Client{},
+		},
+		{
+			Name:      "sentinel without master name",
+			Conf:      RedisConfig{Mode: constant.RedisSentinel, Addrs: []string{"sentinel-1:26379"}},
+			WantError: true,
+		},
+		{
+			Name: "cluster",
+			Conf: RedisConfig{Mode: constant.RedisCluster, Addrs: []string{"node-1:6379", "node-2:6379"}},
+			Want: &redis.ClusterClient{},
+		},
+		{
+			Name:      "cluster with a database index",
+			Conf:      RedisConfig{Mode: constant.RedisCluster, Addrs: []string{"node-1:6379"}, DB: 1},
+			WantError: true,
+		},
+		{
+			Name:      "unknown mode",
+			Conf:      RedisConfig{Mode: "ring"},
+			WantError: true,
+		},
+	}
+
+	for _, test := range tests {
+		ts.Run(test.Name, func() {
+			client, err := newRedisClient(test.Conf)
+			if test.WantError {
+				ts.Assert().Error(err)
+				return
+			}
+			ts.Require().NoError(err)
+			defer client.Close()
+			ts.Assert().IsType(test.Want, client)
+		})
+	}
+}
+
+func (ts *redisTestSuite) TestOptions() {
+	conf := RedisConfig{
+		Config: Config{Host: "localhost", Port: "6379", Password: "secret", MaxOpenConns: 20, MaxIdleConns: 5},
+		DB:     3,
+		TLS:    true,
+	}
+	opts, err := conf.options()
+	ts.Require().NoError(err)
+	ts.Assert().Equal([]string{"localhost:6379"}, opts.Addrs)
+	ts.Assert().Equal(3, opts.DB)
+	ts.Assert().Equal("secret", opts.Password)
+	ts.Assert().Equal(20, opts.PoolSize)
+	// redis has no idle cap, the mysql setting is not a floor either
+	ts.Assert().Zero(opts.MinIdleConns)
+	ts.Require().NotNil(opts.TLSConfig)
+	ts.Assert().Nil(opts.TLSConfig.RootCAs)
+
+	// the addresses replace host and port
+	conf.Addrs = []string{"node-1:6379"}
+	opts, err = conf.options()
+	ts.Require().NoError(err)
+	ts.Assert().Equal([]string{"node-1:6379"}, opts.Addrs)
+
+	conf.MinIdleConns = 2
+	opts, err = conf.options()
+	ts.Require().NoError(err)
+	ts.Assert().Equal(2, opts.MinIdleConns)
+}
+
+func (ts *redisTestSuite) TestTLSCAFile() {
+	dir := ts.T().TempDir()
+
+	conf := RedisConfig{TLS: true, TLSCAFile: filepath.Join(dir, "missing.pem")}
+	_, err := conf.options()
+	ts.Assert().Error(err)
+
+	invalid := filepath.Join(dir, "invalid.pem")
+	ts.Require().NoError(ioutil.WriteFile(invalid, []byte("not a certificate"), 0600))
+	conf.TLSCAFile = invalid
+	_, err = conf.options()
+	ts.Assert().Error(err)
+}
+
+func (ts *redisTestSuite) TestOpenRedis() {
+	server, err := miniredis.Run()
+	ts.Require().NoError(err)
+	defer server.Close()
+
+	client, err := OpenRedis(RedisConfig{Addrs: []string{server.Addr()}, DB: 1})
+	ts.Require().NoError(err)
+	defer client.Close()
+
+	ts.Require().NoError(client.Set(context.Background(), "key", "value", 0).Err())
+	server.Select(1)
+	ts.Assert().True(server.Exists("key"))
+
+	addr := server.Addr()
+	server.Close()
+	_, err = OpenRedis(RedisConfig{Addrs: []string{addr}})
+	ts.Assert().Error(err)
+}
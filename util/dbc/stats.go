@@ -0,0 +1,64 @@
+package dbc
+
+import (
+	"sync"
+
+	"github.com/muhammadisa/bareksanews/constant"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// poolStat the connections of a pool
+type poolStat struct {
+	open int
+	idle int
+}
+
+// poolCollector export the connection pool statistics of every opened
+// database, labelled by pool, a pool opened again replaces the previous one
+type poolCollector struct {
+	mu    sync.Mutex
+	pools map[string]func() poolStat
+
+	open *prometheus.Desc
+	idle *prometheus.Desc
+}
+
+var pools = &poolCollector{
+	pools: make(map[string]func() poolStat),
+	open: prometheus.NewDesc(
+		prometheus.BuildFQName(constant.ServiceName, "pool", "open_connections"),
+		"Connections of the pool, in use or idle.",
+		[]string{"pool"}, nil,
+	),
+	idle: prometheus.NewDesc(
+		prometheus.BuildFQName(constant.ServiceName, "pool", "idle_connections"),
+		"Idle connections of the pool.",
+		[]string{"pool"}, nil,
+	),
+}
+
+func init() {
+	prometheus.MustRegister(pools)
+}
+
+// set report the statistics of pool with stat
+func (c *poolCollector) set(pool string, stat func() poolStat) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.pools[pool] = stat
+}
+
+func (c *poolCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.open
+	ch <- c.idle
+}
+
+func (c *poolCollector) Collect(ch chan<- prometheus.Metric) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for pool, stat := range c.pools {
+		s := stat()
+		ch <- prometheus.MustNewConstMetric(c.open, prometheus.GaugeValue, float64(s.open), pool)
+		ch <- prometheus.MustNewConstMetric(c.idle, prometheus.GaugeValue, float64(s.idle), pool)
+	}
+}
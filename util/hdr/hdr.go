@@ -1,19 +1,49 @@
 package hdr
 
 import (
+	"context"
+	"math"
 	"net/http"
+	"strconv"
 	"strings"
+	"time"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
 )
 
+// IfMatch metadata key carrying the expected entity version of an edit
+const IfMatch = `if-match`
+
+// Authorization metadata key carrying the bearer token, the gateway
+// forwards the header under the same key
+const Authorization = `authorization`
+
+// APIKey metadata key carrying the api key of a client, the gateway
+// forwards the X-Api-Key header under the same key
+const APIKey = `x-api-key`
+
+// RetryAfter header and metadata key telling a limited client when to
+// retry, in seconds
+const RetryAfter = `retry-after`
+
+// XForwardedFor metadata key the gateway appends the address of the http
+// client to
+const XForwardedFor = `x-forwarded-for`
+
 func CORS(h http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		headers := []string{"Content-Type", "Accept", "Authorization","Access-Control-Allow-Headers","X-Requested-With"}
+		headers := []string{"Content-Type", "Accept", "Authorization", "Access-Control-Allow-Headers", "X-Requested-With", "If-Match", "X-Api-Key"}
 		methods := []string{"GET", "HEAD", "POST", "PUT", "DELETE"}
 
 		w.Header().Set("Access-Control-Allow-Origin", "*")
 		w.Header().Set("Access-Control-Allow-Credentials", "true")
 		w.Header().Set("Access-Control-Allow-Headers", strings.Join(headers, ","))
 		w.Header().Set("Access-Control-Allow-Methods", strings.Join(methods, ","))
+		w.Header().Set("Access-Control-Expose-Headers", "Retry-After")
 
 		if r.Method == "OPTIONS" {
 			w.WriteHeader(http.StatusOK)
@@ -22,3 +52,56 @@ func CORS(h http.Handler) http.Handler {
 		h.ServeHTTP(w, r)
 	})
 }
+
+// HeaderMatcher forward If-Match and X-Api-Key headers to grpc metadata as
+// is, other headers follow the gateway default rules
+func HeaderMatcher(key string) (string, bool) {
+	switch lower := strings.ToLower(key); lower {
+	case IfMatch, APIKey:
+		return lower, true
+	}
+	return runtime.DefaultHeaderMatcher(key)
+}
+
+// RetryAfterSeconds wait in the whole seconds of a Retry-After header, at
+// least one
+func RetryAfterSeconds(wait time.Duration) int64 {
+	return int64(math.Max(1, math.Ceil(wait.Seconds())))
+}
+
+// HTTPErrorHandler answer like runtime.DefaultHTTPErrorHandler, the retry
+// delay of an error is sent as Retry-After too
+func HTTPErrorHandler(ctx context.Context, mux *runtime.ServeMux, marshaler runtime.Marshaler, w http.ResponseWriter, r *http.Request, err error) {
+	if st, ok := status.FromError(err); ok {
+		for _, detail := range st.Details() {
+			if info, ok := detail.(*errdetails.RetryInfo); ok && info.RetryDelay != nil {
+				w.Header().Set("Retry-After", strconv.FormatInt(RetryAfterSeconds(info.RetryDelay.AsDuration()), 10))
+			}
+		}
+	}
+	runtime.DefaultHTTPErrorHandler(ctx, mux, marshaler, w, r, err)
+}
+
+// IfMatchVersion read the expected version sent through If-Match, both
+// quoted and weak etags are accepted, zero is returned when it is absent
+func IfMatchVersion(ctx context.Context) (int64, error) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return 0, nil
+	}
+	values := md.Get(IfMatch)
+	if len(values) == 0 {
+		return 0, nil
+	}
+	etag := strings.TrimSpace(values[0])
+	etag = strings.TrimPrefix(etag, "W/")
+	etag = strings.Trim(etag, `"`)
+	if etag == "" || etag == "*" {
+		return 0, nil
+	}
+	version, err := strconv.ParseInt(etag, 10, 64)
+	if err != nil || version < 1 {
+		return 0, status.Errorf(codes.InvalidArgument, "invalid %s header %q", IfMatch, values[0])
+	}
+	return version, nil
+}
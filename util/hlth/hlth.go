@@ -0,0 +1,173 @@
+package hlth
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+const (
+	StatusOK          = `ok`
+	StatusUnavailable = `unavailable`
+	// StatusDraining the service shuts down, it finishes the requests in
+	// flight and takes no new ones
+	StatusDraining = `draining`
+)
+
+// Check report whether a dependency is usable, it returns once ctx is done
+type Check func(ctx context.Context) error
+
+type check struct {
+	name     string
+	required bool
+	check    Check
+}
+
+// Result the outcome of one check
+type Result struct {
+	Name     string `json:"name"`
+	Required bool   `json:"required"`
+	Status   string `json:"status"`
+	// LatencyMillis time the check took
+	LatencyMillis float64 `json:"latency_ms"`
+	Error         string  `json:"error,omitempty"`
+}
+
+// Report the readiness of the service and the result of every check
+type Report struct {
+	Status string   `json:"status"`
+	Checks []Result `json:"checks"`
+}
+
+// Checker run the checks registered by every backend, the service is ready
+// while every required check passes and it does not shut down
+type Checker struct {
+	timeout time.Duration
+
+	mu     sync.RWMutex
+	checks []check
+
+	draining int32
+}
+
+// NewChecker bound every check to timeout
+func NewChecker(timeout time.Duration) *Checker {
+	return &Checker{timeout: timeout}
+}
+
+// Register add the check of a backend, a failing required check makes the
+// service unready while the others are only reported
+func (c *Checker) Register(name string, required bool, fn Check) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.checks = append(c.checks, check{name: name, required: required, check: fn})
+}
+
+// Drain make the service unready for good, called once the shutdown starts
+func (c *Checker) Drain() {
+	atomic.StoreInt32(&c.draining, 1)
+}
+
+func (c *Checker) Draining() bool {
+	return atomic.LoadInt32(&c.draining) == 1
+}
+
+// Run every check concurrently
+func (c *Checker) Run(ctx context.Context) Report {
+	c.mu.RLock()
+	checks := append([]check(nil), c.checks...)
+	c.mu.RUnlock()
+
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	report := Report{Status: StatusOK, Checks: make([]Result, len(checks))}
+	var wg sync.WaitGroup
+	for i, ck := range checks {
+		wg.Add(1)
+		go func(i int, ck check) {
+			defer wg.Done()
+			start := time.Now()
+			err := ck.check(ctx)
+			result := Result{
+				Name:          ck.name,
+				Required:      ck.required,
+				Status:        StatusOK,
+				LatencyMillis: float64(time.Since(start).Microseconds()) / 1000,
+			}
+			if err != nil {
+				result.Status = StatusUnavailable
+				result.Error = err.Error()
+			}
+			report.Checks[i] = result
+		}(i, ck)
+	}
+	wg.Wait()
+
+	for _, result := range report.Checks {
+		if result.Required && result.Status != StatusOK {
+			report.Status = StatusUnavailable
+		}
+	}
+	if c.Draining() {
+		report.Status = StatusDraining
+	}
+	return report
+}
+
+// LivenessHandler answer 200 while the process serves http at all, the
+// dependencies are left to the readiness
+func (c *Checker) LivenessHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]string{"status": StatusOK})
+	})
+}
+
+// ReadinessHandler answer the Report, 200 while the service is ready and
+// 503 otherwise
+func (c *Checker) ReadinessHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		report := c.Run(r.Context())
+		code := http.StatusOK
+		if report.Status != StatusOK {
+			code = http.StatusServiceUnavailable
+		}
+		writeJSON(w, code, report)
+	})
+}
+
+func writeJSON(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// Watch run the checks every interval and publish the readiness as the
+// status of services on server, the empty name is the whole server, it
+// returns once ctx is done
+func (c *Checker) Watch(ctx context.Context, server *health.Server, interval time.Duration, services ...string) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		status := healthpb.HealthCheckResponse_SERVING
+		if c.Run(ctx).Status != StatusOK {
+			status = healthpb.HealthCheckResponse_NOT_SERVING
+		}
+		for _, service := range append([]string{""}, services...) {
+			server.SetServingStatus(service, status)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package hlth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+type hlthTestSuite struct {
+	suite.Suite
+}
+
+func TestHlthTestSuite(t *testing.T) {
+	suite.Run(t, new(hlthTestSuite))
+}
+
+func passing(context.Context) error { return nil }
+
+func failing(context.Context) error { return errors.New("connection refused") }
+
+func (ts *hlthTestSuite) TestRun() {
+	// test case
+	tests := []struct {
+		Name     string
+		Database Check
+		Cache    Check
+		Want     string
+	}{
+		{
+			Name:     "every check passes",
+			Database: passing,
+			Cache:    passing,
+			Want:     StatusOK,
+		},
+		{
+			Name:     "optional check fails",
+			Database: passing,
+			Cache:    failing,
+			Want:     StatusOK,
+		},
+		{
+			Name:     "required check fails",
+			Database: failing,
+			Cache:    passing,
+			Want:     StatusUnavailable,
+		},
+		{
+			Name: "required check times out",
+			Database: func(ctx context.Context) error {
+				<-ctx.Done()
+				return ctx.Err()
+			},
+			Cache: passing,
+			Want:  StatusUnavailable,
+		},
+	}
+
+	for _, test := range tests {
+		ts.Run(test.Name, func() {
+			checker := NewChecker(50 * time.Millisecond)
+			checker.Register("database", true, test.Database)
+			checker.Register("cache", false, test.Cache)
+
+			report := checker.Run(context.Background())
+			ts.Assert().Equal(test.Want, report.Status)
+			ts.Require().Len(report.Checks, 2)
+			ts.Assert().Equal("database", report.Checks[0].Name)
+			ts.Assert().True(report.Checks[0].Required)
+			ts.Assert().Equal("cache", report.Checks[1].Name)
+			ts.Assert().False(report.Checks[1].Required)
+		})
+	}
+}
+
+func (ts *hlthTestSuite) TestDrain() {
+	checker := NewChecker(time.Second)
+	checker.Register("database", true, passing)
+	ts.Assert().False(checker.Draining())
+
+	checker.Drain()
+	ts.Assert().True(checker.Draining())
+	ts.Assert().Equal(StatusDraining, checker.Run(context.Background()).Status)
+}
+
+func (ts *hlthTestSuite) TestHandlers() {
+	checker := NewChecker(time.Second)
+	checker.Register("database", true, failing)
+
+	res := httptest.NewRecorder()
+	checker.LivenessHandler().ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	ts.Assert().Equal(http.StatusOK, res.Code)
+
+	res = httptest.NewRecorder()
+	checker.ReadinessHandler().ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	ts.Assert().Equal(http.StatusServiceUnavailable, res.Code)
+	ts.Assert().Equal("application/json", res.Header().Get("Content-Type"))
+
+	var report Report
+	ts.Require().NoError(json.NewDecoder(res.Body).Decode(&report))
+	ts.Assert().Equal(StatusUnavailable, report.Status)
+	ts.Require().Len(report.Checks, 1)
+	ts.Assert().Equal("connection refused", report.Checks[0].Error)
+}
+
+func (ts *hlthTestSuite) TestWatch() {
+	checker := NewChecker(time.Second)
+	checker.Register("database", true, passing)
+	server := health.NewServer()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		checker.Watch(ctx, server, 10*time.Millisecond, "api.v1.BareksaNewsService")
+		close(done)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	status := func(service string) healthpb.HealthCheckResponse_ServingStatus {
+		res, err := server.Check(context.Background(), &healthpb.HealthCheckRequest{Service: service})
+		if err != nil {
+			return healthpb.HealthCheckResponse_UNKNOWN
+		}
+		return res.Status
+	}
+	for _, service := range []string{"", "api.v1.BareksaNewsService"} {
+		ts.Eventually(func() bool { return status(service) == healthpb.HealthCheckResponse_SERVING }, time.Second, 5*time.Millisecond)
+	}
+
+	checker.Drain()
+	ts.Eventually(func() bool { return status("") == healthpb.HealthCheckResponse_NOT_SERVING }, time.Second, 5*time.Millisecond)
+}
@@ -31,3 +31,22 @@ func Create(serviceName string) log.Logger {
 	level.Info(logger).Log(LogInfo, fmt.Sprintf("the service is started at %s", currentTime))
 	return logger
 }
+
+// Filter drop the records of logger below the level named name, one of
+// debug, info, warn or error
+func Filter(logger log.Logger, name string) (log.Logger, error) {
+	var allow level.Option
+	switch name {
+	case "debug":
+		allow = level.AllowDebug()
+	case "info":
+		allow = level.AllowInfo()
+	case "warn":
+		allow = level.AllowWarn()
+	case "error":
+		allow = level.AllowError()
+	default:
+		return nil, fmt.Errorf("unknown log level %q", name)
+	}
+	return level.NewFilter(logger, allow), nil
+}
@@ -0,0 +1,202 @@
+package lmt
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-kit/kit/log/level"
+	"github.com/muhammadisa/bareksanews/constant"
+	"github.com/muhammadisa/bareksanews/gvars"
+	"github.com/muhammadisa/bareksanews/util/auth"
+	"github.com/muhammadisa/bareksanews/util/hdr"
+	"github.com/muhammadisa/bareksanews/util/lgr"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// Limit token bucket of every client of an rpc
+type Limit struct {
+	// Rate tokens refilled every second, zero leaves the rpc unlimited
+	Rate float64 `yaml:"rate"`
+	// Burst tokens the bucket holds, defaults to the rate rounded up
+	Burst int `yaml:"burst"`
+}
+
+// burst the capacity of the bucket, at least one token
+func (l Limit) burst() float64 {
+	if l.Burst > 0 {
+		return float64(l.Burst)
+	}
+	return math.Max(1, math.Ceil(l.Rate))
+}
+
+// Config how the calls are limited, every rpc is unlimited while neither
+// Default nor Limits has a rate
+type Config struct {
+	// Store constant.RateLimitStoreMemory or constant.RateLimitStoreRedis,
+	// redis shares the buckets between the replicas
+	Store string `yaml:"store"`
+	// Default limit of the rpcs left out of Limits
+	Default Limit `yaml:"default"`
+	// Limits the limit of an rpc, overriding Default
+	Limits map[string]Limit `yaml:"limits"`
+	// APIKeys the hex sha256 digest of the api key of every client by
+	// client name, the calls carrying a known key share the bucket of the
+	// client
+	APIKeys map[string]string `yaml:"api_keys"`
+}
+
+// Enabled report whether any rpc is limited
+func (c Config) Enabled() bool {
+	if c.Default.Rate > 0 {
+		return true
+	}
+	for _, limit := range c.Limits {
+		if limit.Rate > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate report a configuration NewLimiter refuses, rpcs lists the names
+// limits may be given to
+func (c Config) Validate(rpcs []string) error {
+	switch c.Store {
+	case "", constant.RateLimitStoreMemory, constant.RateLimitStoreRedis:
+	default:
+		return fmt.Errorf("store: unknown store %q", c.Store)
+	}
+	if c.Default.Rate < 0 || c.Default.Burst < 0 {
+		return errors.New("default: rate and burst can not be negative")
+	}
+	known := make(map[string]bool, len(rpcs))
+	for _, rpc := range rpcs {
+		known[rpc] = true
+	}
+	for rpc, limit := range c.Limits {
+		if !known[rpc] {
+			return fmt.Errorf("limits: unknown rpc %q", rpc)
+		}
+		if limit.Rate < 0 || limit.Burst < 0 {
+			return fmt.Errorf("limits: rate and burst of %s can not be negative", rpc)
+		}
+	}
+	for client, digest := range c.APIKeys {
+		if raw, err := hex.DecodeString(digest); err != nil || len(raw) != sha256.Size {
+			return fmt.Errorf("api_keys: the key of %s is not a hex sha256 digest", client)
+		}
+	}
+	return nil
+}
+
+// Store take the tokens of the buckets
+type Store interface {
+	// Take one token of the bucket of key at now, wait is the time until
+	// the next token when the bucket is empty
+	Take(ctx context.Context, key string, limit Limit, now time.Time) (ok bool, wait time.Duration, err error)
+}
+
+// Limiter limit the calls of every client of every rpc
+type Limiter struct {
+	config Config
+	store  Store
+	// clients the name of every client by the digest of its api key
+	clients map[string]string
+	now     func() time.Time
+}
+
+// NewLimiter limit the calls with the buckets of store
+func NewLimiter(config Config, store Store) *Limiter {
+	l := &Limiter{config: config, store: store, clients: make(map[string]string), now: time.Now}
+	for client, digest := range config.APIKeys {
+		l.clients[strings.ToLower(digest)] = client
+	}
+	return l
+}
+
+// Enabled report whether any rpc is limited
+func (l *Limiter) Enabled() bool {
+	return l.config.Enabled()
+}
+
+// Allow take a token of the bucket of the caller of rpc, an empty bucket
+// is reported with codes.ResourceExhausted carrying the retry delay
+//
+// the calls are let through while the store fails, the database is better
+// served by a missing limit than the whole api by a failing redis
+func (l *Limiter) Allow(ctx context.Context, rpc string) error {
+	limit, ok := l.config.Limits[rpc]
+	if !ok {
+		limit = l.config.Default
+	}
+	if limit.Rate <= 0 {
+		return nil
+	}
+
+	ok, wait, err := l.store.Take(ctx, rpc+":"+l.client(ctx), limit, l.now())
+	if err != nil {
+		level.Warn(gvars.Log).Log(lgr.LogWarn, fmt.Sprintf("rate limit of %s is not enforced: %v", rpc, err))
+		return nil
+	}
+	if ok {
+		return nil
+	}
+
+	// grpc callers read the header, the gateway reads the details
+	_ = grpc.SetHeader(ctx, metadata.Pairs(hdr.RetryAfter, strconv.FormatInt(hdr.RetryAfterSeconds(wait), 10)))
+	st, err := status.New(codes.ResourceExhausted, fmt.Sprintf("rate limit of %s exceeded, retry in %s", rpc, wait)).
+		WithDetails(&errdetails.RetryInfo{RetryDelay: durationpb.New(wait)})
+	if err != nil {
+		return status.Errorf(codes.ResourceExhausted, "rate limit of %s exceeded, retry in %s", rpc, wait)
+	}
+	return st.Err()
+}
+
+// client the bucket owner of the call, the client of a known api key,
+// else the subject of the verified token, else the address of the caller
+func (l *Limiter) client(ctx context.Context) string {
+	md, _ := metadata.FromIncomingContext(ctx)
+	if keys := md.Get(hdr.APIKey); len(keys) > 0 {
+		digest := sha256.Sum256([]byte(keys[0]))
+		if client, ok := l.clients[hex.EncodeToString(digest[:])]; ok {
+			return "key:" + client
+		}
+	}
+	if claims, ok := auth.FromContext(ctx); ok && claims.Subject != "" {
+		return "sub:" + claims.Subject
+	}
+	return "ip:" + clientIP(ctx, md)
+}
+
+// clientIP the address of the grpc peer, the gateway calls have no peer
+// and the gateway appends the address of the http client to
+// x-forwarded-for
+func clientIP(ctx context.Context, md metadata.MD) string {
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		if host, _, err := net.SplitHostPort(p.Addr.String()); err == nil {
+			return host
+		}
+		return p.Addr.String()
+	}
+	if forwarded := md.Get(hdr.XForwardedFor); len(forwarded) > 0 {
+		hops := strings.Split(forwarded[len(forwarded)-1], ",")
+		if ip := strings.TrimSpace(hops[len(hops)-1]); ip != "" {
+			return ip
+		}
+	}
+	return "unknown"
+}
@@ -0,0 +1,215 @@
+package lmt
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-kit/kit/log"
+	"github.com/go-redis/redis/v8"
+	"github.com/muhammadisa/bareksanews/constant"
+	"github.com/muhammadisa/bareksanews/gvars"
+	"github.com/muhammadisa/bareksanews/util/auth"
+	"github.com/stretchr/testify/suite"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+type lmtTestSuite struct {
+	suite.Suite
+	now time.Time
+}
+
+func TestLmtTestSuite(t *testing.T) {
+	suite.Run(t, new(lmtTestSuite))
+}
+
+func (ts *lmtTestSuite) SetupSuite() {
+	gvars.Log = log.NewNopLogger()
+}
+
+func (ts *lmtTestSuite) SetupTest() {
+	ts.now = time.Date(2021, 10, 1, 0, 0, 0, 0, time.UTC)
+}
+
+// limiter a Limiter of config whose clock is ts.now
+func (ts *lmtTestSuite) limiter(config Config, store Store) *Limiter {
+	l := NewLimiter(config, store)
+	l.now = func() time.Time { return ts.now }
+	return l
+}
+
+// fromIP a call of a grpc client at ip
+func fromIP(ip string) context.Context {
+	return peer.NewContext(context.Background(), &peer.Peer{Addr: &net.TCPAddr{IP: net.ParseIP(ip), Port: 51234}})
+}
+
+func digest(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+func (ts *lmtTestSuite) TestMemoryStore() {
+	store := NewMemoryStore()
+	limit := Limit{Rate: 2, Burst: 3}
+
+	// the burst is served at once, then a token every half second
+	for i := 0; i < 3; i++ {
+		ok, _, err := store.Take(context.Background(), "k", limit, ts.now)
+		ts.Require().NoError(err)
+		ts.Assert().True(ok)
+	}
+	ok, wait, err := store.Take(context.Background(), "k", limit, ts.now)
+	ts.Require().NoError(err)
+	ts.Assert().False(ok)
+	ts.Assert().Equal(500*time.Millisecond, wait)
+
+	ok, _, _ = store.Take(context.Background(), "k", limit, ts.now.Add(500*time.Millisecond))
+	ts.Assert().True(ok)
+	ok, wait, _ = store.Take(context.Background(), "k", limit, ts.now.Add(600*time.Millisecond))
+	ts.Assert().False(ok)
+	ts.Assert().Equal(400*time.Millisecond, wait)
+
+	// other keys have their own bucket
+	ok, _, _ = store.Take(context.Background(), "other", limit, ts.now)
+	ts.Assert().True(ok)
+
+	// the bucket never holds more than the burst
+	for i := 0; i < 3; i++ {
+		ok, _, _ = store.Take(context.Background(), "k", limit, ts.now.Add(time.Hour))
+		ts.Assert().True(ok)
+	}
+	ok, _, _ = store.Take(context.Background(), "k", limit, ts.now.Add(time.Hour))
+	ts.Assert().False(ok)
+}
+
+func (ts *lmtTestSuite) TestRedisStoreSharedByReplicas() {
+	mr, err := miniredis.Run()
+	ts.Require().NoError(err)
+	defer mr.Close()
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer client.Close()
+
+	config := Config{Store: constant.RateLimitStoreRedis, Limits: map[string]Limit{"GetNewses": {Rate: 1, Burst: 2}}}
+	replicas := []*Limiter{
+		ts.limiter(config, NewRedisStore(client, "bareksa_news:test:rate_limit:")),
+		ts.limiter(config, NewRedisStore(client, "bareksa_news:test:rate_limit:")),
+	}
+
+	ts.Assert().NoError(replicas[0].Allow(fromIP("10.0.0.1"), "GetNewses"))
+	ts.Assert().NoError(replicas[1].Allow(fromIP("10.0.0.1"), "GetNewses"))
+	err = replicas[0].Allow(fromIP("10.0.0.1"), "GetNewses")
+	ts.Assert().Equal(codes.ResourceExhausted, status.Code(err))
+	ts.Assert().True(mr.Exists("bareksa_news:test:rate_limit:GetNewses:ip:10.0.0.1"))
+
+	// other rpcs and clients are not limited by the bucket
+	ts.Assert().NoError(replicas[1].Allow(fromIP("10.0.0.1"), "GetTags"))
+	ts.Assert().NoError(replicas[1].Allow(fromIP("10.0.0.2"), "GetNewses"))
+
+	ts.now = ts.now.Add(time.Second)
+	ts.Assert().NoError(replicas[1].Allow(fromIP("10.0.0.1"), "GetNewses"))
+	ts.Assert().Error(replicas[0].Allow(fromIP("10.0.0.1"), "GetNewses"))
+
+	// the buckets expire once they are full again
+	mr.FastForward(5 * time.Second)
+	ts.Assert().False(mr.Exists("bareksa_news:test:rate_limit:GetNewses:ip:10.0.0.1"))
+}
+
+func (ts *lmtTestSuite) TestResourceExhausted() {
+	l := ts.limiter(Config{Default: Limit{Rate: 0.5}}, NewMemoryStore())
+	ts.Require().NoError(l.Allow(fromIP("10.0.0.1"), "GetNewses"))
+
+	err := l.Allow(fromIP("10.0.0.1"), "GetNewses")
+	st := status.Convert(err)
+	ts.Require().Equal(codes.ResourceExhausted, st.Code())
+	ts.Require().Len(st.Details(), 1)
+	info, ok := st.Details()[0].(*errdetails.RetryInfo)
+	ts.Require().True(ok)
+	ts.Assert().Equal(2*time.Second, info.RetryDelay.AsDuration())
+}
+
+func (ts *lmtTestSuite) TestClients() {
+	l := ts.limiter(Config{
+		Limits:  map[string]Limit{"GetNewses": {Rate: 1}},
+		APIKeys: map[string]string{"partner": digest("partner-key")},
+	}, NewMemoryStore())
+
+	// test case
+	tests := []struct {
+		Name string
+		Ctx  context.Context
+		Want string
+	}{
+		{
+			Name: "grpc peer",
+			Ctx:  fromIP("10.0.0.1"),
+			Want: "ip:10.0.0.1",
+		},
+		{
+			Name: "gateway call",
+			Ctx:  metadata.NewIncomingContext(context.Background(), metadata.Pairs("x-forwarded-for", "1.2.3.4, 10.0.0.7")),
+			Want: "ip:10.0.0.7",
+		},
+		{
+			Name: "forwarded for of a grpc client",
+			Ctx:  metadata.NewIncomingContext(fromIP("10.0.0.1"), metadata.Pairs("x-forwarded-for", "1.2.3.4")),
+			Want: "ip:10.0.0.1",
+		},
+		{
+			Name: "token subject",
+			Ctx:  auth.NewContext(fromIP("10.0.0.1"), auth.Claims{Subject: "reader-1", Role: constant.RoleReader}),
+			Want: "sub:reader-1",
+		},
+		{
+			Name: "known api key",
+			Ctx:  metadata.NewIncomingContext(auth.NewContext(fromIP("10.0.0.1"), auth.Claims{Subject: "reader-1"}), metadata.Pairs("x-api-key", "partner-key")),
+			Want: "key:partner",
+		},
+		{
+			Name: "unknown api key",
+			Ctx:  metadata.NewIncomingContext(fromIP("10.0.0.1"), metadata.Pairs("x-api-key", "random-key")),
+			Want: "ip:10.0.0.1",
+		},
+	}
+
+	for _, test := range tests {
+		ts.Run(test.Name, func() {
+			ts.Assert().Equal(test.Want, l.client(test.Ctx))
+		})
+	}
+}
+
+// failingStore a store whose redis is down
+type failingStore struct{}
+
+func (failingStore) Take(context.Context, string, Limit, time.Time) (bool, time.Duration, error) {
+	return false, 0, errors.New("connection refused")
+}
+
+func (ts *lmtTestSuite) TestStoreFailureLetsCallsThrough() {
+	l := ts.limiter(Config{Default: Limit{Rate: 1}}, failingStore{})
+	ts.Assert().NoError(l.Allow(fromIP("10.0.0.1"), "GetNewses"))
+
+	// unlimited rpcs never reach the store
+	l = ts.limiter(Config{Limits: map[string]Limit{"GetNewses": {Rate: 1}}}, failingStore{})
+	ts.Assert().False(Config{}.Enabled())
+	ts.Assert().True(Config{Limits: map[string]Limit{"GetNewses": {Rate: 1}}}.Enabled())
+	ts.Assert().NoError(l.Allow(fromIP("10.0.0.1"), "GetTags"))
+}
+
+func (ts *lmtTestSuite) TestValidate() {
+	rpcs := []string{"GetNewses", "GetTags"}
+	ts.Assert().NoError(Config{Limits: map[string]Limit{"GetNewses": {Rate: 5, Burst: 10}}, APIKeys: map[string]string{"partner": digest("k")}}.Validate(rpcs))
+	ts.Assert().Error(Config{Store: "memcached"}.Validate(rpcs))
+	ts.Assert().Error(Config{Default: Limit{Rate: -1}}.Validate(rpcs))
+	ts.Assert().Error(Config{Limits: map[string]Limit{"GetNews": {Rate: 5}}}.Validate(rpcs))
+	ts.Assert().Error(Config{APIKeys: map[string]string{"partner": "partner-key"}}.Validate(rpcs))
+}
@@ -0,0 +1,71 @@
+package lmt
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/muhammadisa/bareksanews/constant"
+)
+
+// bucket the tokens left at last
+type bucket struct {
+	tokens float64
+	last   time.Time
+	// full when the bucket refills completely without calls
+	full time.Time
+}
+
+// memoryStore the buckets of one replica
+type memoryStore struct {
+	mu      sync.Mutex
+	buckets map[string]*bucket
+}
+
+// NewMemoryStore keep the buckets in process, every replica limits its own
+// calls
+func NewMemoryStore() Store {
+	return &memoryStore{buckets: make(map[string]*bucket)}
+}
+
+func (s *memoryStore) Take(_ context.Context, key string, limit Limit, now time.Time) (bool, time.Duration, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	b, ok := s.buckets[key]
+	if !ok {
+		if len(s.buckets) >= constant.RateLimitMaxBuckets {
+			s.evict(now)
+		}
+		b = &bucket{tokens: limit.burst(), last: now}
+		s.buckets[key] = b
+	}
+
+	burst := limit.burst()
+	if elapsed := now.Sub(b.last).Seconds(); elapsed > 0 {
+		b.tokens = math.Min(burst, b.tokens+elapsed*limit.Rate)
+		b.last = now
+	}
+	if b.tokens < 1 {
+		return false, seconds((1 - b.tokens) / limit.Rate), nil
+	}
+	b.tokens--
+	b.full = now.Add(seconds((burst - b.tokens) / limit.Rate))
+	return true, 0, nil
+}
+
+// evict the buckets refilled since their last call, they start full again
+// when they are needed
+func (s *memoryStore) evict(now time.Time) {
+	for key, b := range s.buckets {
+		if !now.Before(b.full) {
+			delete(s.buckets, key)
+		}
+	}
+}
+
+// seconds the duration of s seconds, rounded up to the millisecond
+func seconds(s float64) time.Duration {
+	return time.Duration(math.Ceil(s*1000)) * time.Millisecond
+}
@@ -0,0 +1,57 @@
+package lmt
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// takeScript refill the bucket of KEYS[1] and take one token atomically,
+// ARGV holds the rate per second, the burst and the time in milliseconds,
+// the reply is whether a token was taken and the wait in milliseconds
+var takeScript = redis.NewScript(`
+local rate = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+local bucket = redis.call('HMGET', KEYS[1], 'tokens', 'last')
+local tokens = tonumber(bucket[1]) or burst
+local last = tonumber(bucket[2]) or now
+if now > last then
+	tokens = math.min(burst, tokens + (now - last) * rate / 1000)
+	last = now
+end
+if tokens < 1 then
+	return {0, math.ceil((1 - tokens) * 1000 / rate)}
+end
+tokens = tokens - 1
+redis.call('HMSET', KEYS[1], 'tokens', tostring(tokens), 'last', last)
+redis.call('PEXPIRE', KEYS[1], math.ceil((burst - tokens) * 1000 / rate) + 1000)
+return {1, 0}
+`)
+
+// redisStore the buckets shared by every replica
+type redisStore struct {
+	client redis.UniversalClient
+	prefix string
+}
+
+// NewRedisStore keep the buckets in client under prefix, the replicas
+// sharing it share the limits, a bucket expires once it is full again
+func NewRedisStore(client redis.UniversalClient, prefix string) Store {
+	return &redisStore{client: client, prefix: prefix}
+}
+
+func (s *redisStore) Take(ctx context.Context, key string, limit Limit, now time.Time) (bool, time.Duration, error) {
+	args := []interface{}{
+		strconv.FormatFloat(limit.Rate, 'f', -1, 64),
+		strconv.FormatFloat(limit.burst(), 'f', -1, 64),
+		now.UnixNano() / int64(time.Millisecond),
+	}
+	reply, err := takeScript.Run(ctx, s.client, []string{s.prefix + key}, args...).Int64Slice()
+	if err != nil {
+		return false, 0, err
+	}
+	return reply[0] == 1, time.Duration(reply[1]) * time.Millisecond, nil
+}
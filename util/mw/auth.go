@@ -0,0 +1,22 @@
+package mw
+
+import (
+	"context"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/muhammadisa/bareksanews/util/auth"
+)
+
+// AuthMiddleware authorize the caller of method, the claims verified by
+// the grpc interceptor are reused and the gateway calls are verified here
+func AuthMiddleware(authorizer *auth.Authorizer, method string) endpoint.Middleware {
+	return func(next endpoint.Endpoint) endpoint.Endpoint {
+		return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+			ctx, err = authorizer.Authorize(ctx, method)
+			if err != nil {
+				return nil, err
+			}
+			return next(ctx, request)
+		}
+	}
+}
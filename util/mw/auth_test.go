@@ -0,0 +1,52 @@
+package mw
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/muhammadisa/bareksanews/constant"
+	"github.com/muhammadisa/bareksanews/util/auth"
+	"github.com/stretchr/testify/suite"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+type authTestSuite struct {
+	suite.Suite
+	authorizer *auth.Authorizer
+}
+
+func TestAuthTestSuite(t *testing.T) {
+	suite.Run(t, new(authTestSuite))
+}
+
+func (ts *authTestSuite) SetupSuite() {
+	authorizer, err := auth.NewAuthorizer(auth.Config{HMACSecret: "hmac-secret", RolesClaim: constant.RolesClaim})
+	ts.Require().NoError(err)
+	ts.authorizer = authorizer
+}
+
+func (ts *authTestSuite) TestAuthMiddleware() {
+	var called bool
+	ep := AuthMiddleware(ts.authorizer, "DeleteTopic")(func(ctx context.Context, request interface{}) (interface{}, error) {
+		called = true
+		claims, _ := auth.FromContext(ctx)
+		return claims.Subject, nil
+	})
+
+	_, err := ep(context.Background(), nil)
+	ts.Assert().Equal(codes.Unauthenticated, status.Code(err))
+	ts.Assert().False(called)
+
+	// the gateway forwards the authorization header as metadata
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"sub": "admin-1", "roles": []string{constant.RoleAdmin}, "exp": time.Now().Add(time.Hour).Unix()}).SignedString([]byte("hmac-secret"))
+	ts.Require().NoError(err)
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", "Bearer "+token))
+	subject, err := ep(ctx, nil)
+	ts.Require().NoError(err)
+	ts.Assert().True(called)
+	ts.Assert().Equal("admin-1", subject)
+}
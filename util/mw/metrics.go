@@ -0,0 +1,81 @@
+package mw
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/go-kit/kit/metrics"
+	kitprometheus "github.com/go-kit/kit/metrics/prometheus"
+	"github.com/muhammadisa/bareksanews/constant"
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	labelMethod = `method`
+	labelCode   = `code`
+)
+
+// Instruments the metrics MetricsMiddleware records for every endpoint
+type Instruments struct {
+	// Requests labelled by method
+	Requests metrics.Counter
+	// Errors labelled by method and grpc code
+	Errors metrics.Counter
+	// Latency labelled by method, in seconds
+	Latency metrics.Histogram
+}
+
+// NewInstruments the prometheus instruments of the endpoints, registered
+// with registerer
+func NewInstruments(registerer prometheus.Registerer) (Instruments, error) {
+	requests := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: constant.ServiceName,
+		Subsystem: "endpoint",
+		Name:      "requests_total",
+		Help:      "Requests received by every endpoint.",
+	}, []string{labelMethod})
+	errs := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: constant.ServiceName,
+		Subsystem: "endpoint",
+		Name:      "errors_total",
+		Help:      "Requests of every endpoint that failed, by grpc code.",
+	}, []string{labelMethod, labelCode})
+	latency := prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: constant.ServiceName,
+		Subsystem: "endpoint",
+		Name:      "request_duration_seconds",
+		Help:      "Time every endpoint took to answer.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{labelMethod})
+	for _, collector := range []prometheus.Collector{requests, errs, latency} {
+		if err := registerer.Register(collector); err != nil {
+			return Instruments{}, err
+		}
+	}
+	return Instruments{
+		Requests: kitprometheus.NewCounter(requests),
+		Errors:   kitprometheus.NewCounter(errs),
+		Latency:  kitprometheus.NewHistogram(latency),
+	}, nil
+}
+
+// MetricsMiddleware count the requests of method, its errors by grpc code
+// and observe its latency, errors without a grpc status count as Unknown
+func MetricsMiddleware(instruments Instruments, method string) endpoint.Middleware {
+	requests := instruments.Requests.With(labelMethod, method)
+	latency := instruments.Latency.With(labelMethod, method)
+	return func(next endpoint.Endpoint) endpoint.Endpoint {
+		return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+			defer func(begin time.Time) {
+				requests.Add(1)
+				latency.Observe(time.Since(begin).Seconds())
+				if err != nil {
+					instruments.Errors.With(labelMethod, method, labelCode, status.Code(err).String()).Add(1)
+				}
+			}(time.Now())
+			return next(ctx, request)
+		}
+	}
+}
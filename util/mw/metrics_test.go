@@ -0,0 +1,115 @@
+package mw
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/muhammadisa/bareksanews/repository/errs"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/suite"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type metricsTestSuite struct {
+	suite.Suite
+	registry    *prometheus.Registry
+	instruments Instruments
+}
+
+func TestMetricsTestSuite(t *testing.T) {
+	suite.Run(t, new(metricsTestSuite))
+}
+
+func (ts *metricsTestSuite) SetupTest() {
+	ts.registry = prometheus.NewRegistry()
+	instruments, err := NewInstruments(ts.registry)
+	ts.Require().NoError(err)
+	ts.instruments = instruments
+}
+
+// metric the sample of name carrying every label of labels, nil when none
+// was recorded
+func (ts *metricsTestSuite) metric(name string, labels map[string]string) *dto.Metric {
+	families, err := ts.registry.Gather()
+	ts.Require().NoError(err)
+	for _, family := range families {
+		if family.GetName() != name {
+			continue
+		}
+	metrics:
+		for _, metric := range family.GetMetric() {
+			for _, pair := range metric.GetLabel() {
+				if want, ok := labels[pair.GetName()]; ok && want != pair.GetValue() {
+					continue metrics
+				}
+			}
+			return metric
+		}
+	}
+	return nil
+}
+
+func (ts *metricsTestSuite) TestMetricsMiddleware() {
+	// test case
+	tests := []struct {
+		Name string
+		Err  error
+		Code string
+	}{
+		{
+			Name: "success",
+		},
+		{
+			Name: "grpc status",
+			Err:  status.Error(codes.InvalidArgument, "page must not be negative"),
+			Code: codes.InvalidArgument.String(),
+		},
+		{
+			Name: "repository error",
+			Err:  errs.NotFound("tag", "1"),
+			Code: codes.NotFound.String(),
+		},
+		{
+			Name: "plain error",
+			Err:  errors.New("connection reset"),
+			Code: codes.Unknown.String(),
+		},
+	}
+
+	for _, test := range tests {
+		ts.Run(test.Name, func() {
+			ts.SetupTest()
+			ep := MetricsMiddleware(ts.instruments, "GetTags")(func(ctx context.Context, request interface{}) (interface{}, error) {
+				return nil, test.Err
+			})
+			_, err := ep(context.Background(), nil)
+			ts.Assert().Equal(test.Err, err)
+
+			requests := ts.metric("bareksa_news_endpoint_requests_total", map[string]string{"method": "GetTags"})
+			ts.Require().NotNil(requests)
+			ts.Assert().Equal(float64(1), requests.GetCounter().GetValue())
+
+			latency := ts.metric("bareksa_news_endpoint_request_duration_seconds", map[string]string{"method": "GetTags"})
+			ts.Require().NotNil(latency)
+			ts.Assert().Equal(uint64(1), latency.GetHistogram().GetSampleCount())
+
+			failed := ts.metric("bareksa_news_endpoint_errors_total", map[string]string{"method": "GetTags"})
+			if test.Err == nil {
+				ts.Assert().Nil(failed)
+				return
+			}
+			ts.Require().NotNil(failed)
+			ts.Assert().Equal(float64(1), failed.GetCounter().GetValue())
+			ts.Assert().Nil(ts.metric("bareksa_news_endpoint_errors_total", map[string]string{"code": "OK"}))
+			ts.Assert().NotNil(ts.metric("bareksa_news_endpoint_errors_total", map[string]string{"code": test.Code}))
+		})
+	}
+}
+
+func (ts *metricsTestSuite) TestRegisterTwice() {
+	_, err := NewInstruments(ts.registry)
+	ts.Assert().Error(err)
+}
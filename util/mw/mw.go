@@ -8,6 +8,7 @@ import (
 	"github.com/go-kit/kit/endpoint"
 	"github.com/go-kit/kit/log"
 	"github.com/go-kit/kit/log/level"
+	"github.com/muhammadisa/bareksanews/repository/errs"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 	"time"
@@ -41,6 +42,12 @@ func CircuitBreakerMiddleware(command string) endpoint.Middleware {
 			var logicErr error
 			err = hystrix.Do(command, func() (err error) {
 				resp, logicErr = next(ctx, request)
+				// repository errors are caused by the request itself,
+				// they must not open the circuit
+				var repoErr *errs.Error
+				if errors.As(logicErr, &repoErr) {
+					return nil
+				}
 				return logicErr
 			}, func(err error) error {
 				return err
@@ -0,0 +1,22 @@
+package mw
+
+import (
+	"context"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/muhammadisa/bareksanews/util/lmt"
+)
+
+// RateLimitMiddleware take a token of the bucket of the caller of method,
+// it runs after AuthMiddleware so the callers with a token are limited by
+// their subject
+func RateLimitMiddleware(limiter *lmt.Limiter, method string) endpoint.Middleware {
+	return func(next endpoint.Endpoint) endpoint.Endpoint {
+		return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+			if err = limiter.Allow(ctx, method); err != nil {
+				return nil, err
+			}
+			return next(ctx, request)
+		}
+	}
+}
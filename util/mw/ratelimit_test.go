@@ -0,0 +1,49 @@
+package mw
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"github.com/muhammadisa/bareksanews/util/hdr"
+	"github.com/muhammadisa/bareksanews/util/lmt"
+	"github.com/stretchr/testify/suite"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+type rateLimitTestSuite struct {
+	suite.Suite
+}
+
+func TestRateLimitTestSuite(t *testing.T) {
+	suite.Run(t, new(rateLimitTestSuite))
+}
+
+func (ts *rateLimitTestSuite) TestRateLimitMiddleware() {
+	limiter := lmt.NewLimiter(lmt.Config{Limits: map[string]lmt.Limit{"GetNewses": {Rate: 1}}}, lmt.NewMemoryStore())
+	var calls int
+	ep := RateLimitMiddleware(limiter, "GetNewses")(func(ctx context.Context, request interface{}) (interface{}, error) {
+		calls++
+		return nil, nil
+	})
+
+	// the gateway forwards the address of the http client
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("x-forwarded-for", "10.0.0.1"))
+	_, err := ep(ctx, nil)
+	ts.Require().NoError(err)
+	_, err = ep(ctx, nil)
+	ts.Require().Equal(codes.ResourceExhausted, status.Code(err))
+	ts.Assert().Equal(1, calls)
+
+	// the gateway answers 429 with the retry delay
+	mux := runtime.NewServeMux()
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/v1/newses", nil)
+	hdr.HTTPErrorHandler(context.Background(), mux, &runtime.JSONPb{}, w, req, err)
+	ts.Assert().Equal(http.StatusTooManyRequests, w.Code)
+	ts.Assert().Equal("1", w.Header().Get("Retry-After"))
+}
@@ -0,0 +1,100 @@
+package smry
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// ellipsis appended to a sentence cut to fit the maximum length
+const ellipsis = `…`
+
+// abbreviations ending with a period that do not end a sentence
+var abbreviations = map[string]bool{
+	"mr": true, "mrs": true, "ms": true, "dr": true, "prof": true,
+	"st": true, "jl": true, "no": true, "vs": true, "etc": true,
+	"e.g": true, "i.e": true,
+}
+
+var blankLine = regexp.MustCompile(`\n\s*\n`)
+
+// Extract the first sentences of text as its summary, at most sentences of
+// them and at most maxLength runes, a first sentence longer than maxLength
+// is cut at the last word that fits
+func Extract(text string, sentences, maxLength int) string {
+	var summary []string
+	length := 0
+	for _, sentence := range Sentences(text) {
+		if len(summary) == sentences {
+			break
+		}
+		sentenceLength := utf8.RuneCountInString(sentence)
+		if len(summary) > 0 {
+			sentenceLength++
+		}
+		if maxLength > 0 && length+sentenceLength > maxLength {
+			if len(summary) == 0 {
+				summary = append(summary, cut(sentence, maxLength))
+			}
+			break
+		}
+		summary = append(summary, sentence)
+		length += sentenceLength
+	}
+	return strings.Join(summary, " ")
+}
+
+// Sentences split text into sentences with collapsed whitespaces, a
+// paragraph always ends its last sentence
+func Sentences(text string) (res []string) {
+	for _, paragraph := range blankLine.Split(text, -1) {
+		words := strings.Fields(paragraph)
+		start := 0
+		for i, word := range words {
+			if i == len(words)-1 || endsSentence(word) {
+				res = append(res, strings.Join(words[start:i+1], " "))
+				start = i + 1
+			}
+		}
+	}
+	return res
+}
+
+func endsSentence(word string) bool {
+	trimmed := strings.TrimRightFunc(word, func(r rune) bool {
+		return r == '"' || r == '\'' || r == ')' || r == '”' || r == '’'
+	})
+	if !strings.HasSuffix(trimmed, ".") && !strings.HasSuffix(trimmed, "!") && !strings.HasSuffix(trimmed, "?") {
+		return false
+	}
+	if !strings.HasSuffix(trimmed, ".") {
+		return true
+	}
+	stem := strings.ToLower(strings.TrimLeftFunc(strings.TrimSuffix(trimmed, "."), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}))
+	// initials like "J." and known abbreviations keep the sentence going
+	if utf8.RuneCountInString(stem) == 1 && unicode.IsLetter([]rune(stem)[0]) {
+		return false
+	}
+	return !abbreviations[stem]
+}
+
+func cut(sentence string, maxLength int) string {
+	limit := maxLength - utf8.RuneCountInString(ellipsis)
+	if limit <= 0 {
+		return string([]rune(sentence)[:maxLength])
+	}
+	runes := []rune(sentence)
+	cutAt := limit
+	for i := limit; i > 0; i-- {
+		if unicode.IsSpace(runes[i]) {
+			cutAt = i
+			break
+		}
+	}
+	return strings.TrimRightFunc(string(runes[:cutAt]), func(r rune) bool {
+		return unicode.IsSpace(r) || unicode.IsPunct(r)
+	}) + ellipsis
+}
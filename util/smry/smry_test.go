@@ -0,0 +1,103 @@
+package smry
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type smryTestSuite struct {
+	suite.Suite
+}
+
+func TestSmryTestSuite(t *testing.T) {
+	suite.Run(t, new(smryTestSuite))
+}
+
+func (ts *smryTestSuite) TestSentences() {
+	// test case
+	tests := []struct {
+		Name string
+		Text string
+		Want []string
+	}{
+		{
+			Name: "punctuations",
+			Text: "Markets rallied today. Did bonds follow? Not really!",
+			Want: []string{"Markets rallied today.", "Did bonds follow?", "Not really!"},
+		},
+		{
+			Name: "abbreviations, initials and decimals",
+			Text: "Dr. Budi met J. Smith at Jl. Sudirman. Rates rose 3.5 percent.",
+			Want: []string{"Dr. Budi met J. Smith at Jl. Sudirman.", "Rates rose 3.5 percent."},
+		},
+		{
+			Name: "quotes and paragraphs",
+			Text: "Headline without period\n\nHe said \"it is over.\" Then   left\nthe room.",
+			Want: []string{"Headline without period", "He said \"it is over.\"", "Then left the room."},
+		},
+		{
+			Name: "empty",
+			Text: "  \n ",
+			Want: nil,
+		},
+	}
+
+	for _, test := range tests {
+		ts.Run(test.Name, func() {
+			ts.Assert().Equal(test.Want, Sentences(test.Text))
+		})
+	}
+}
+
+func (ts *smryTestSuite) TestExtract() {
+	text := "First sentence here. Second sentence here. Third sentence here. Fourth sentence here."
+
+	// test case
+	tests := []struct {
+		Name      string
+		Sentences int
+		MaxLength int
+		Want      string
+	}{
+		{
+			Name:      "first sentences",
+			Sentences: 2,
+			Want:      "First sentence here. Second sentence here.",
+		},
+		{
+			Name:      "more sentences than the text",
+			Sentences: 10,
+			Want:      text,
+		},
+		{
+			Name:      "stop before the sentence exceeding the length",
+			Sentences: 3,
+			MaxLength: 50,
+			Want:      "First sentence here. Second sentence here.",
+		},
+		{
+			Name:      "cut a first sentence exceeding the length",
+			Sentences: 3,
+			MaxLength: 12,
+			Want:      "First…",
+		},
+	}
+
+	for _, test := range tests {
+		ts.Run(test.Name, func() {
+			summary := Extract(text, test.Sentences, test.MaxLength)
+			ts.Assert().Equal(test.Want, summary)
+			if test.MaxLength > 0 {
+				ts.Assert().LessOrEqual(utf8.RuneCountInString(summary), test.MaxLength)
+			}
+		})
+	}
+}
+
+func (ts *smryTestSuite) TestExtractLongWord() {
+	summary := Extract(strings.Repeat("a", 40), 3, 10)
+	ts.Assert().Equal(strings.Repeat("a", 9)+ellipsis, summary)
+}